@@ -0,0 +1,300 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Config holds every tunable of the service. Values come from the defaults
+// below, then the optional file named by CONFIG_FILE (YAML or JSON, using the
+// lower-case keys), then environment variables (the upper-case keys), each
+// overriding the previous one.
+type Config struct {
+	Port               string
+	MongoURI           string
+	MongoDatabase      string
+	RequestTimeout     time.Duration
+	SecretKey          string
+	AccessTokenTTL     time.Duration
+	RefreshTokenTTL    time.Duration
+	PinTokenTTL        time.Duration
+	PasswordResetTTL   time.Duration
+	BcryptCost         int
+	PinBcryptCost      int
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginLockoutBase   time.Duration
+	LoginLockoutMax    time.Duration
+	LoginFailureWindow time.Duration
+	Notifier           string
+	NotifierFile       string
+	KitchenFeedPoll    time.Duration
+	KitchenHeartbeat   time.Duration
+	KitchenEventTTL    time.Duration
+	// PaymentGateway selects the card processor; only the built-in
+	// simulator exists so far.
+	PaymentGateway           string
+	PaymentSimulatorTimeout  time.Duration
+	PaymentSimulatorDeclines string
+}
+
+type setting struct {
+	key   string
+	apply func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{"port", stringSetting(func(cfg *Config) *string { return &cfg.Port })},
+	{"mongo_uri", stringSetting(func(cfg *Config) *string { return &cfg.MongoURI })},
+	{"mongo_database", stringSetting(func(cfg *Config) *string { return &cfg.MongoDatabase })},
+	{"request_timeout", durationSetting(func(cfg *Config) *time.Duration { return &cfg.RequestTimeout })},
+	{"secret_key", stringSetting(func(cfg *Config) *string { return &cfg.SecretKey })},
+	{"access_token_ttl", durationSetting(func(cfg *Config) *time.Duration { return &cfg.AccessTokenTTL })},
+	{"refresh_token_ttl", durationSetting(func(cfg *Config) *time.Duration { return &cfg.RefreshTokenTTL })},
+	{"pin_token_ttl", durationSetting(func(cfg *Config) *time.Duration { return &cfg.PinTokenTTL })},
+	{"password_reset_ttl", durationSetting(func(cfg *Config) *time.Duration { return &cfg.PasswordResetTTL })},
+	{"bcrypt_cost", intSetting(func(cfg *Config) *int { return &cfg.BcryptCost })},
+	{"pin_bcrypt_cost", intSetting(func(cfg *Config) *int { return &cfg.PinBcryptCost })},
+	{"login_max_failures", intSetting(func(cfg *Config) *int { return &cfg.LoginMaxFailures })},
+	{"login_ip_max_failures", intSetting(func(cfg *Config) *int { return &cfg.LoginIPMaxFailures })},
+	{"login_lockout_base", durationSetting(func(cfg *Config) *time.Duration { return &cfg.LoginLockoutBase })},
+	{"login_lockout_max", durationSetting(func(cfg *Config) *time.Duration { return &cfg.LoginLockoutMax })},
+	{"login_failure_window", durationSetting(func(cfg *Config) *time.Duration { return &cfg.LoginFailureWindow })},
+	{"notifier", stringSetting(func(cfg *Config) *string { return &cfg.Notifier })},
+	{"notifier_file", stringSetting(func(cfg *Config) *string { return &cfg.NotifierFile })},
+	{"kitchen_feed_poll", durationSetting(func(cfg *Config) *time.Duration { return &cfg.KitchenFeedPoll })},
+	{"kitchen_heartbeat", durationSetting(func(cfg *Config) *time.Duration { return &cfg.KitchenHeartbeat })},
+	{"kitchen_event_ttl", durationSetting(func(cfg *Config) *time.Duration { return &cfg.KitchenEventTTL })},
+	{"payment_gateway", stringSetting(func(cfg *Config) *string { return &cfg.PaymentGateway })},
+	{"payment_simulator_timeout", durationSetting(func(cfg *Config) *time.Duration { return &cfg.PaymentSimulatorTimeout })},
+	{"payment_simulator_declines", stringSetting(func(cfg *Config) *string { return &cfg.PaymentSimulatorDeclines })},
+}
+
+func Defaults() Config {
+	return Config{
+		Port:               "8000",
+		MongoURI:           "mongodb://localhost:27017",
+		MongoDatabase:      "restaurant",
+		RequestTimeout:     100 * time.Second,
+		AccessTokenTTL:     15 * time.Minute,
+		RefreshTokenTTL:    7 * 24 * time.Hour,
+		PinTokenTTL:        10 * time.Minute,
+		PasswordResetTTL:   30 * time.Minute,
+		BcryptCost:         14,
+		PinBcryptCost:      10,
+		LoginMaxFailures:   5,
+		LoginIPMaxFailures: 20,
+		LoginLockoutBase:   30 * time.Second,
+		LoginLockoutMax:    time.Hour,
+		LoginFailureWindow: 15 * time.Minute,
+		Notifier:           "log",
+		NotifierFile:       "notifications.log",
+		KitchenFeedPoll:    2 * time.Second,
+		KitchenHeartbeat:   15 * time.Second,
+		KitchenEventTTL:    24 * time.Hour,
+
+		PaymentGateway:          "simulator",
+		PaymentSimulatorTimeout: 30 * time.Second,
+	}
+}
+
+// Load builds the configuration from the defaults, CONFIG_FILE and the
+// environment, and validates it.
+func Load() (*Config, error) {
+	values := map[string]string{}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values = fileValues
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(strings.ToUpper(s.key)); ok {
+			values[s.key] = value
+		}
+	}
+
+	return FromValues(values)
+}
+
+// FromValues applies values, keyed like the configuration file, on top of the
+// defaults and validates the result.
+func FromValues(values map[string]string) (*Config, error) {
+	cfg := Defaults()
+	known := map[string]bool{}
+
+	for _, s := range settings {
+		known[s.key] = true
+		value, ok := values[s.key]
+		if !ok {
+			continue
+		}
+		if err := s.apply(&cfg, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", s.key, err)
+		}
+	}
+
+	for key := range values {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown setting %s", key)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (cfg *Config) Validate() error {
+	var problems []string
+
+	if cfg.SecretKey == "" {
+		problems = append(problems, "secret_key must be set")
+	}
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, "port must be a number between 1 and 65535")
+	}
+	if !strings.HasPrefix(cfg.MongoURI, "mongodb://") && !strings.HasPrefix(cfg.MongoURI, "mongodb+srv://") {
+		problems = append(problems, "mongo_uri must start with mongodb:// or mongodb+srv://")
+	}
+	if cfg.MongoDatabase == "" {
+		problems = append(problems, "mongo_database must be set")
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		problems = append(problems, fmt.Sprintf("bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if cfg.PinBcryptCost < bcrypt.MinCost || cfg.PinBcryptCost > bcrypt.MaxCost {
+		problems = append(problems, fmt.Sprintf("pin_bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+	}
+	if cfg.AccessTokenTTL >= cfg.RefreshTokenTTL {
+		problems = append(problems, "access_token_ttl must be shorter than refresh_token_ttl")
+	}
+	if cfg.LoginLockoutBase > cfg.LoginLockoutMax {
+		problems = append(problems, "login_lockout_base must not exceed login_lockout_max")
+	}
+	if cfg.Notifier != "log" && cfg.Notifier != "file" {
+		problems = append(problems, "notifier must be log or file")
+	}
+	if cfg.PaymentGateway != "simulator" {
+		problems = append(problems, "payment_gateway must be simulator")
+	}
+	for _, decline := range strings.Split(cfg.PaymentSimulatorDeclines, ",") {
+		token, code, found := strings.Cut(decline, ":")
+		if strings.TrimSpace(decline) != "" && (!found || strings.TrimSpace(token) == "" || strings.TrimSpace(code) == "") {
+			problems = append(problems, "payment_simulator_declines must be token:code pairs separated by commas")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// SimulatorDeclines reads payment_simulator_declines into decline codes by
+// card token.
+func (cfg *Config) SimulatorDeclines() map[string]string {
+	declines := map[string]string{}
+	for _, decline := range strings.Split(cfg.PaymentSimulatorDeclines, ",") {
+		token, code, found := strings.Cut(decline, ":")
+		if found {
+			declines[strings.TrimSpace(token)] = strings.TrimSpace(code)
+		}
+	}
+	return declines
+}
+
+var current *Config
+var once sync.Once
+
+// Get returns the process wide configuration, loading it on first use. The
+// service refuses to start when the configuration is invalid.
+func Get() *Config {
+	once.Do(func() {
+		cfg, err := Load()
+		if err != nil {
+			log.Fatal(err)
+		}
+		current = cfg
+	})
+
+	return current
+}
+
+// Use installs cfg as the process wide configuration instead of loading it,
+// for callers such as tests that build their own.
+func Use(cfg *Config) {
+	once.Do(func() {})
+	current = cfg
+}
+
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	// JSON is valid YAML, so one decoder covers both formats
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	for key, value := range raw {
+		values[strings.ToLower(key)] = fmt.Sprint(value)
+	}
+
+	return values, nil
+}
+
+func stringSetting(field func(cfg *Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func intSetting(field func(cfg *Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if number <= 0 {
+			return errors.New("must be positive")
+		}
+		*field(cfg) = number
+		return nil
+	}
+}
+
+func durationSetting(field func(cfg *Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if duration <= 0 {
+			return errors.New("must be positive")
+		}
+		*field(cfg) = duration
+		return nil
+	}
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/repository"
+)
+
+// Controller holds what the handlers depend on. Build it once with New and
+// register its handlers in the routes.
+type Controller struct {
+	store    *repository.Store
+	notifier helpers.Notifier
+	gateway  helpers.PaymentGateway
+	// kitchenEvents wakes the kitchen feeds of this process when an event
+	// is recorded; feeds also poll for events recorded by other processes
+	kitchenEvents *helpers.Signal
+}
+
+func New(store *repository.Store, notifier helpers.Notifier, gateway helpers.PaymentGateway) *Controller {
+	return &Controller{store: store, notifier: notifier, gateway: gateway, kitchenEvents: helpers.NewSignal()}
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errCreditConflict is wrapped by the reasons an invoice cannot be credited
+// as asked, which are answered with 409.
+var errCreditConflict = errors.New("cannot be credited")
+
+// InvoiceCredit asks for a credit note on an invoice. Amount can be left out
+// to credit whatever the invoice still charges.
+type InvoiceCredit struct {
+	Amount *models.Money `json:"amount" validate:"omitempty,gt=0"`
+	Reason string        `json:"reason" validate:"required,min=2,max=500"`
+}
+
+// CreateCreditNote corrects an issued invoice, which itself never changes, by
+// crediting part or all of it back under a credit note of its own number.
+func (ctl *Controller) CreateCreditNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request InvoiceCredit
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+		if invoice.InvoiceNumber == "" {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice was issued before invoices were numbered and cannot be credited"})
+			return
+		}
+		if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice has been voided"})
+			return
+		}
+		if invoice.Owed() <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice has been credited in full"})
+			return
+		}
+
+		amount := invoice.Owed()
+		if request.Amount != nil {
+			amount = *request.Amount
+		}
+		if amount > invoice.Owed() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only " + invoice.Owed().String() + " is left to credit on the invoice"})
+			return
+		}
+
+		creditNote, err := ctl.issueCreditNote(ctx, invoice, amount, request.Reason, helpers.GetActor(c))
+		if errors.Is(err, errCreditConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while issuing the credit note"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, creditNote)
+	}
+}
+
+func (ctl *Controller) GetCreditNotes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allCreditNotes, err := ctl.store.CreditNotes.List(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the credit notes"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allCreditNotes)
+	}
+}
+
+func (ctl *Controller) GetCreditNote() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		creditNote, err := ctl.store.CreditNotes.Get(ctx, helpers.GetTenant(c), c.Param("credit_note_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "credit note not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the credit note"})
+			return
+		}
+
+		c.JSON(http.StatusOK, creditNote)
+	}
+}
+
+// issueCreditNote credits amount back on invoice under the next credit note
+// number. The invoice is credited first, so that two credit notes cannot
+// together credit more than it charges, and credited back if no credit note
+// could be issued for it.
+func (ctl *Controller) issueCreditNote(ctx context.Context, invoice models.Invoice, amount models.Money, reason string, issuedBy string) (models.CreditNote, error) {
+	restaurant, err := ctl.store.Restaurants.Get(ctx, invoice.RestaurantId)
+	if err != nil && err != repository.ErrNotFound {
+		return models.CreditNote{}, err
+	}
+
+	credited, err := ctl.store.Invoices.AddCredit(ctx, invoice.RestaurantId, invoice.InvoiceId, invoice.Credited, amount)
+	if err != nil {
+		return models.CreditNote{}, err
+	}
+	if !credited {
+		return models.CreditNote{}, fmt.Errorf("invoice %w: it was credited meanwhile, please reload it", errCreditConflict)
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	creditNote := models.CreditNote{
+		ID:            primitive.NewObjectID(),
+		FiscalYear:    restaurant.FiscalYear(now),
+		InvoiceId:     invoice.InvoiceId,
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        amount,
+		Reason:        reason,
+		IssuedBy:      issuedBy,
+		CreatedAt:     now,
+		RestaurantId:  invoice.RestaurantId,
+	}
+	creditNote.CreditNoteId = creditNote.ID.Hex()
+
+	creditNote, err = ctl.store.CreditNotes.Issue(ctx, creditNote, restaurant.CreditNoteNumberPrefix())
+	if err != nil {
+		ctl.store.Invoices.AddCredit(ctx, invoice.RestaurantId, invoice.InvoiceId, invoice.Credited+amount, -amount)
+		return models.CreditNote{}, err
+	}
+
+	return creditNote, nil
+}
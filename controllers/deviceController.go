@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (ctl *Controller) GetDevices() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allDevices, err := ctl.store.Devices.List(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching devices"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allDevices)
+	}
+}
+
+// CreateDevice registers a kitchen display or printer. The device key is only
+// returned once and cannot be recovered, only rotated.
+func (ctl *Controller) CreateDevice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var device models.Device
+		if err := c.BindJSON(&device); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(device); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		device.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		device.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		device.RevokedAt = nil
+		device.ID = primitive.NewObjectID()
+		device.DeviceId = device.ID.Hex()
+		device.RestaurantId = helpers.GetTenant(c)
+
+		deviceKey, keyHash := helpers.NewDeviceKey(device.DeviceId)
+		device.KeyHash = keyHash
+
+		if err := ctl.store.Devices.Create(ctx, device); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the device"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"device": device, "device_key": deviceKey})
+	}
+}
+
+// RotateDeviceKey issues a new key for the device; the old one stops working
+// immediately.
+func (ctl *Controller) RotateDeviceKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		device, ok := ctl.activeDevice(ctx, c)
+		if !ok {
+			return
+		}
+
+		deviceKey, keyHash := helpers.NewDeviceKey(device.DeviceId)
+		device.KeyHash = keyHash
+		device.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		if err := ctl.store.Devices.Update(ctx, device); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while rotating the device key"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"device": device, "device_key": deviceKey})
+	}
+}
+
+func (ctl *Controller) RevokeDevice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		device, ok := ctl.activeDevice(ctx, c)
+		if !ok {
+			return
+		}
+
+		now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		device.RevokedAt = &now
+		device.UpdatedAt = now
+
+		if err := ctl.store.Devices.Update(ctx, device); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while revoking the device"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "device revoked"})
+	}
+}
+
+// activeDevice loads the device named in the path, answering 404 itself when
+// it is missing, revoked or belongs to another restaurant.
+func (ctl *Controller) activeDevice(ctx context.Context, c *gin.Context) (models.Device, bool) {
+	device, err := ctl.store.Devices.Get(ctx, c.Param("device_id"))
+	if err != nil && err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the device"})
+		return device, false
+	}
+	if err == repository.ErrNotFound || device.RestaurantId != helpers.GetTenant(c) || device.RevokedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "device not found or revoked"})
+		return device, false
+	}
+
+	return device, true
+}
@@ -2,29 +2,24 @@ package controllers
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"math"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var foodCollection = database.OpenCollection(database.Client, "foods")
 var validate = validator.New()
 
-func GetFoods() gin.HandlerFunc {
+func (ctl *Controller) GetFoods() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		recordPerPage, err := strconv.Atoi(c.Query("recordPerPage"))
@@ -38,65 +33,44 @@ func GetFoods() gin.HandlerFunc {
 		}
 
 		startIndex := (page - 1) * recordPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-
-		matchStage := bson.D{{"$match", bson.D{}}}
-		groupStage := bson.D{
-			{"$group", bson.D{
-				{"$_id", bson.D{
-					{"_id", "null"}}},
-				{"total_count", bson.D{
-					{"$sum", 1}}},
-				{"data", bson.D{
-					{"$push", "$$ROOT"}}},
-			}}}
-		projectStage := bson.D{
-			{"$project", bson.D{
-				{"_id", 0},
-				{"total_count", 1},
-				{"food_items", bson.D{{"$slice", []interface{}{"data", startIndex, recordPerPage}}}},
-			}}}
-
-		result, err := foodCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage,
-			groupStage,
-			projectStage,
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the food items"})
+		if index, err := strconv.Atoi(c.Query("startIndex")); err == nil && index >= 0 {
+			startIndex = index
 		}
 
-		var allFoods []bson.M
-		if err = result.All(ctx, &allFoods); err != nil {
-			log.Fatal(err)
+		allFoods, total, err := ctl.store.Foods.List(ctx, helpers.GetTenant(c), repository.Page{Skip: startIndex, Limit: recordPerPage})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the food items"})
+			return
 		}
 
-		c.JSON(http.StatusOK, allFoods[0])
+		c.JSON(http.StatusOK, gin.H{"total_count": total, "food_items": allFoods})
 	}
 }
 
-func GetFood() gin.HandlerFunc {
+func (ctl *Controller) GetFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 
 		foodId := c.Param("food_id")
 		defer cancel()
 
-		var food models.Food
-
-		err := foodCollection.FindOne(ctx, bson.M{"food_id": foodId}).Decode(&food)
+		food, err := ctl.store.Foods.Get(ctx, helpers.GetTenant(c), foodId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "food item not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while fetching the food item"})
+			return
 		}
 		c.JSON(http.StatusOK, food)
 	}
 }
 
-func CreateFood() gin.HandlerFunc {
+func (ctl *Controller) CreateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var food models.Food
-		var menu models.Menu
 
 		defer cancel()
 		if err := c.BindJSON(&food); err != nil {
@@ -108,11 +82,17 @@ func CreateFood() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": validationError.Error()})
 			return
 		}
+		if err := food.PrepareModifierGroups(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := food.PrepareVariants(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.MenuId}).Decode(&menu)
-		if err != nil {
-			msg := fmt.Sprintf("menu with id %s does not exist", food.MenuId)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if _, err := ctl.store.Menus.Get(ctx, helpers.GetTenant(c), *food.MenuId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "menu with id " + *food.MenuId + " does not exist"})
 			return
 		}
 
@@ -120,27 +100,21 @@ func CreateFood() gin.HandlerFunc {
 		food.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		food.ID = primitive.NewObjectID()
 		food.FoodId = food.ID.Hex()
+		food.RestaurantId = helpers.GetTenant(c)
 
-		var num = toFixed(*food.Price, 2)
-		food.Price = &num
-
-		result, insertErr := foodCollection.InsertOne(ctx, food)
-		if insertErr != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the food item %s", insertErr)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := ctl.store.Foods.Create(ctx, food); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the food item"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
-		return
+		c.JSON(http.StatusCreated, food)
 	}
 }
 
-func UpdateFood() gin.HandlerFunc {
+func (ctl *Controller) UpdateFood() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var food models.Food
-		var menu models.Menu
 
 		foodId := c.Param("food_id")
 		defer cancel()
@@ -150,63 +124,65 @@ func UpdateFood() gin.HandlerFunc {
 			return
 		}
 
-		var updatedObj primitive.D
+		storedFood, err := ctl.store.Foods.Get(ctx, helpers.GetTenant(c), foodId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "food item not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while fetching the food item"})
+			return
+		}
 
 		if food.Name != nil {
-			updatedObj = append(updatedObj, bson.E{Key: "name", Value: food.Name})
+			storedFood.Name = food.Name
 		}
 		if food.Price != nil {
-			updatedObj = append(updatedObj, bson.E{Key: "price", Value: food.Price})
+			storedFood.Price = food.Price
 		}
 		if food.FoodImage != nil {
-			updatedObj = append(updatedObj, bson.E{Key: "food_image", Value: food.FoodImage})
+			storedFood.FoodImage = food.FoodImage
+		}
+		if food.Station != nil {
+			storedFood.Station = food.Station
+		}
+		if food.ModifierGroups != nil {
+			storedFood.ModifierGroups = food.ModifierGroups
+		}
+		if food.Variants != nil {
+			storedFood.Variants = food.Variants
+		}
+		if food.Category != nil {
+			storedFood.Category = food.Category
 		}
 		if food.MenuId != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"menu_id": food.MenuId}).Decode(&menu)
-			defer cancel()
-
-			if err != nil {
-				msg := fmt.Sprintf("menu with id %s does not exist", food.MenuId)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			if _, err := ctl.store.Menus.Get(ctx, helpers.GetTenant(c), *food.MenuId); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "menu with id " + *food.MenuId + " does not exist"})
 				return
 			}
 
-			updatedObj = append(updatedObj, bson.E{Key: "menu_id", Value: food.MenuId})
+			storedFood.MenuId = food.MenuId
 		}
 
-		food.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updatedObj = append(updatedObj, bson.E{Key: "updated_at", Value: food.UpdatedAt})
-
-		upsert := true
-		filter := bson.M{"food_id": foodId}
-
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		if validationError := validate.Struct(storedFood); validationError != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationError.Error()})
+			return
 		}
-
-		result, err := foodCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{"$set", updatedObj},
-			},
-			&opt,
-		)
-		if err != nil {
-			msg := fmt.Sprintf("error ocurred while updating the food item %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := storedFood.PrepareModifierGroups(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := storedFood.PrepareVariants(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, result)
-
-	}
-}
 
-func round(num float64) int {
-	return int(num + math.Copysign(0.5, num))
-}
+		storedFood.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-func toFixed(num float64, precision int) float64 {
-	output := math.Pow(10, float64(precision))
-	return float64(round(num*output)) / output
+		if err := ctl.store.Foods.Update(ctx, storedFood); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the food item"})
+			return
+		}
+		c.JSON(http.StatusOK, storedFood)
+	}
 }
@@ -2,77 +2,78 @@ package controllers
 
 import (
 	"context"
-	"fmt"
-	"log"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type InvoiceViewFormat struct {
-	InvoiceId      string
-	PaymentMethod  string
-	OrderId        string
-	PaymentStatus  *string
-	PaymentDue     interface{}
-	TableNumber    interface{}
-	PaymentDueDate time.Time
-	OrderDetails   interface{}
+	InvoiceId        string
+	InvoiceNumber    string
+	PaymentMethod    string
+	OrderId          string
+	PaymentStatus    *string
+	PaymentDue       models.Money
+	TableNumber      interface{}
+	PaymentDueDate   time.Time
+	OrderDetails     interface{}
+	Totals           *models.OrderTotals
+	AppliedDiscounts []models.AppliedDiscount
+	Payments         []models.Payment
+	AmountPaid       models.Money
+	Balance          models.Money
+	Tips             models.Money
+	Reversals        []models.Reversal
+	AmountRefunded   models.Money
+	CreditNotes      []models.CreditNote
+	AmountCredited   models.Money
 }
 
-var invoiceCollection *mongo.Collection = database.OpenCollection(database.Client, "invoice")
-
-func GetInvoices() gin.HandlerFunc {
+func (ctl *Controller) GetInvoices() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
-		result, err := invoiceCollection.Find(context.TODO(), bson.M{})
-		defer func(result *mongo.Cursor, ctx context.Context) {
-			err := result.Close(ctx)
-			if err != nil {
-
-			}
-		}(result, ctx)
-
+		allInvoices, err := ctl.store.Invoices.List(ctx, helpers.GetTenant(c))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching orders"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching invoices"})
 			return
 		}
 
-		var allInvoices []bson.M
-		if err = result.All(ctx, &allInvoices); err != nil {
-			log.Fatal(err)
-		}
-
 		c.JSON(http.StatusOK, allInvoices)
 	}
 }
 
-func GetInvoice() gin.HandlerFunc {
+func (ctl *Controller) GetInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		invoiceId := c.Param("invoice_id")
-		var invoice models.Invoice
 
-		err := invoiceCollection.FindOne(ctx, bson.M{"invoice_id": invoiceId}).Decode(&invoice)
+		invoice, err := ctl.store.Invoices.Get(ctx, helpers.GetTenant(c), invoiceId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching invoice"})
+			return
 		}
-		//c.JSON(http.StatusOK, invoice)
 
 		var invoiceView InvoiceViewFormat
 
-		allOrdersItems, err := ItemsByOrder(invoice.OrderId)
+		orderView, err := ctl.ItemsByOrder(ctx, helpers.GetTenant(c), invoice.OrderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the invoiced order"})
+			return
+		}
 
 		invoiceView.OrderId = invoice.OrderId
 		invoiceView.PaymentDueDate = invoice.PaymentDueDate
@@ -83,18 +84,53 @@ func GetInvoice() gin.HandlerFunc {
 		}
 
 		invoiceView.InvoiceId = invoice.InvoiceId
-		invoiceView.PaymentStatus = *&invoice.PaymentStatus
-		invoiceView.PaymentDue = allOrdersItems[0]["payment_due"]
-		invoiceView.TableNumber = allOrdersItems[0]["table_number"]
-		invoiceView.OrderDetails = allOrdersItems[0]["order_items"]
+		invoiceView.InvoiceNumber = invoice.InvoiceNumber
+		invoiceView.PaymentStatus = invoice.PaymentStatus
+		invoiceView.PaymentDue = orderView.PaymentDue
+		if invoice.Totals != nil {
+			invoiceView.PaymentDue = invoice.Totals.Total
+			invoiceView.Totals = invoice.Totals
+		}
+		invoiceView.TableNumber = orderView.TableNumber
+		invoiceView.OrderDetails = orderView.OrderItems
+		invoiceView.Payments = []models.Payment{}
+		if invoice.Payments != nil {
+			invoiceView.Payments = invoice.Payments
+		}
+		invoiceView.AmountPaid = invoice.AmountPaid()
+		invoiceView.AmountCredited = invoice.Credited
+		invoiceView.Balance = invoiceView.PaymentDue - invoiceView.AmountCredited - invoiceView.AmountPaid
+		invoiceView.Tips = invoice.Tips()
+		invoiceView.AppliedDiscounts = []models.AppliedDiscount{}
+		if invoice.Totals != nil && invoice.Totals.Discounts != nil {
+			invoiceView.AppliedDiscounts = invoice.Totals.Discounts
+		}
+
+		reversals, err := ctl.store.Reversals.ListByInvoice(ctx, invoice.RestaurantId, invoice.InvoiceId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the refunds of the invoice"})
+			return
+		}
+		invoiceView.Reversals = reversals
+		invoiceView.AmountRefunded = models.Refunded(reversals, "")
+
+		creditNotes, err := ctl.store.CreditNotes.ListByInvoice(ctx, invoice.RestaurantId, invoice.InvoiceId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the credit notes of the invoice"})
+			return
+		}
+		invoiceView.CreditNotes = creditNotes
 
 		c.JSON(http.StatusOK, invoiceView)
 	}
 }
 
-func CreateInvoice() gin.HandlerFunc {
+// CreateInvoice issues the invoice of an order under the next invoice number
+// of the restaurant and fiscal year. An order is invoiced again only once its
+// invoice has been voided.
+func (ctl *Controller) CreateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		var invoice models.Invoice
@@ -103,12 +139,24 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		var order models.Order
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": invoice.OrderId}).Decode(&order)
+		if issued, err := ctl.store.Invoices.GetByOrder(ctx, helpers.GetTenant(c), invoice.OrderId); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "order has already been invoiced as " + receiptFileName(issued) + ", void that invoice first"})
+			return
+		} else if err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the invoice of the order"})
+			return
+		}
+
+		totals, err := ctl.OrderTotals(ctx, helpers.GetTenant(c), invoice.OrderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Order was not found with id " + invoice.OrderId})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while pricing the order"})
 			return
 		}
+		invoice.Totals = &totals
 
 		status := "PENDING"
 
@@ -121,6 +169,7 @@ func CreateInvoice() gin.HandlerFunc {
 		invoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		invoice.ID = primitive.NewObjectID()
 		invoice.InvoiceId = invoice.ID.Hex()
+		invoice.RestaurantId = helpers.GetTenant(c)
 
 		validationErr := validate.Struct(invoice)
 		if validationErr != nil {
@@ -128,20 +177,45 @@ func CreateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		insertResult, insertErr := invoiceCollection.InsertOne(ctx, invoice)
-		if insertErr != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the invoice %s", insertErr)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		// the status follows the payments taken on the invoice
+		invoice.Payments = nil
+		invoice.Credited = 0
+		paymentStatus := invoice.StatusAfterPayments()
+		invoice.PaymentStatus = &paymentStatus
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, invoice.RestaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
 			return
 		}
+		invoice.FiscalYear = restaurant.FiscalYear(invoice.CreatedAt)
 
-		c.JSON(http.StatusCreated, insertResult)
+		invoice, err = ctl.store.Invoices.Issue(ctx, invoice, restaurant.InvoiceNumberPrefix())
+		if err == repository.ErrOrderInvoiced {
+			c.JSON(http.StatusConflict, gin.H{"error": "order has already been invoiced, void that invoice first"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the invoice"})
+			return
+		}
+
+		// the order keeps the discounts it was invoiced with, so they stay on
+		// record however promotions change afterwards
+		if err := ctl.store.Orders.SetAppliedDiscounts(ctx, invoice.RestaurantId, invoice.OrderId, totals.Discounts); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the discounts on the order"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, invoice)
 	}
 }
 
-func UpdateInvoice() gin.HandlerFunc {
+// UpdateInvoice changes how an invoice is to be paid. What it charges was
+// fixed when it was issued; corrections take a credit note.
+func (ctl *Controller) UpdateInvoice() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		var invoice models.Invoice
@@ -152,45 +226,73 @@ func UpdateInvoice() gin.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"invoice_id": invoiceId}
-		var updateObj primitive.D
-
-		if invoice.PaymentStatus != nil {
-			updateObj = append(updateObj, bson.E{Key: "payment_method", Value: invoice.PaymentMethod})
+		storedInvoice, err := ctl.store.Invoices.Get(ctx, helpers.GetTenant(c), invoiceId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching invoice"})
+			return
 		}
 
-		if invoice.PaymentStatus != nil {
-			updateObj = append(updateObj, bson.E{Key: "payment_status", Value: invoice.PaymentStatus})
+		if storedInvoice.InvoiceNumber != "" && !sameIssue(invoice, storedInvoice) {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice " + storedInvoice.InvoiceNumber + " has been issued and cannot be changed, issue a credit note instead"})
+			return
+		}
 
+		if invoice.PaymentMethod != nil {
+			storedInvoice.PaymentMethod = invoice.PaymentMethod
 		}
 
-		invoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: invoice.UpdatedAt})
+		if invoice.PaymentStatus != nil && *invoice.PaymentStatus != storedInvoice.StatusAfterPayments() {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice has " + storedInvoice.Balance().String() + " outstanding, its status follows the payments taken"})
+			return
+		}
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		if validationErr := validate.Struct(storedInvoice); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
 		}
 
-		status := "PENDING"
-		if invoice.PaymentStatus == nil {
-			invoice.PaymentStatus = &status
+		// the payment method is all that is left to change
+		if invoice.PaymentMethod == nil {
+			c.JSON(http.StatusOK, storedInvoice)
+			return
 		}
 
-		result, err := invoiceCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{"$set", updateObj},
-			},
-			&opt,
-		)
+		storedInvoice.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		err = ctl.store.Invoices.SetPaymentMethod(ctx, storedInvoice.RestaurantId, storedInvoice.InvoiceId, *storedInvoice.PaymentMethod, storedInvoice.UpdatedAt)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+			return
+		}
 		if err != nil {
-			msg := fmt.Sprintf("error ocurred while updating the invoice %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the invoice"})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, storedInvoice)
+	}
+}
+
+// sameIssue reports whether the fields of update that were fixed when invoice
+// was issued are left out or still the same.
+func sameIssue(update models.Invoice, invoice models.Invoice) bool {
+	switch {
+	case update.InvoiceNumber != "" && update.InvoiceNumber != invoice.InvoiceNumber:
+		return false
+	case update.FiscalYear != 0 && update.FiscalYear != invoice.FiscalYear:
+		return false
+	case update.Sequence != 0 && update.Sequence != invoice.Sequence:
+		return false
+	case update.OrderId != "" && update.OrderId != invoice.OrderId:
+		return false
+	case update.Credited != 0 && update.Credited != invoice.Credited:
+		return false
+	case update.Totals != nil && (invoice.Totals == nil || update.Totals.Total != invoice.Totals.Total):
+		return false
 	}
+	return true
 }
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// kitchenFeedBatch is how many events the feed reads from the store at once.
+const kitchenFeedBatch = 100
+
+// kitchenFeedGapGrace is how long the feed waits for a missing sequence, which
+// another request may still be storing, before it skips over it.
+const kitchenFeedGapGrace = 5 * time.Second
+
+// GetKitchenFeed streams kitchen events as Server-Sent Events: new order
+// items, status changes and voids. The station and order_id query parameters
+// narrow it down. A screen that reconnects sends the last event ID it saw in
+// the Last-Event-ID header, or the last_event_id parameter, and gets every
+// event after it; without one the feed starts with the next event, so screens
+// should connect before loading the station queue.
+func (ctl *Controller) GetKitchenFeed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		restaurantId := helpers.GetTenant(c)
+		station := c.Query("station")
+		orderId := c.Query("order_id")
+
+		lastEventId := c.GetHeader("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = c.Query("last_event_id")
+		}
+
+		var last int64
+		if lastEventId != "" {
+			sequence, err := strconv.ParseInt(lastEventId, 10, 64)
+			if err != nil || sequence < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event id " + lastEventId})
+				return
+			}
+			last = sequence
+		} else {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), config.Get().RequestTimeout)
+			sequence, err := ctl.store.KitchenEvents.LastSequence(ctx, restaurantId)
+			cancel()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while opening the kitchen feed"})
+				return
+			}
+			last = sequence
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		poll := time.NewTicker(config.Get().KitchenFeedPoll)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(config.Get().KitchenHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			wake := ctl.kitchenEvents.Wait()
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), config.Get().RequestTimeout)
+			events, err := ctl.store.KitchenEvents.ListAfter(ctx, restaurantId, last, kitchenFeedBatch)
+			cancel()
+			if err != nil {
+				log.Println("could not read the kitchen feed:", err)
+				return
+			}
+
+			for _, event := range events {
+				if event.Sequence != last+1 && time.Since(event.CreatedAt) < kitchenFeedGapGrace {
+					break
+				}
+				last = event.Sequence
+
+				if (station != "" && event.OrderItem.Station != station) || (orderId != "" && event.OrderItem.OrderId != orderId) {
+					continue
+				}
+				if err := writeKitchenEvent(c, event); err != nil {
+					return
+				}
+			}
+			c.Writer.Flush()
+
+			if len(events) == kitchenFeedBatch && events[len(events)-1].Sequence == last {
+				continue
+			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-wake:
+			case <-poll.C:
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Writer, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func writeKitchenEvent(c *gin.Context, event models.KitchenEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data)
+	return err
+}
+
+// publishKitchenEvent records a change to orderItem for the kitchen feed. The
+// change itself is already stored by then, so failing to record it is only
+// logged.
+func (ctl *Controller) publishKitchenEvent(ctx context.Context, eventType string, orderItem models.OrderItem) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	event := models.KitchenEvent{
+		ID:           primitive.NewObjectID(),
+		Type:         eventType,
+		OrderItem:    orderItem,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(config.Get().KitchenEventTTL),
+		RestaurantId: orderItem.RestaurantId,
+	}
+
+	if _, err := ctl.store.KitchenEvents.Append(ctx, event); err != nil {
+		log.Println("could not record the kitchen event:", err)
+		return
+	}
+	ctl.kitchenEvents.Notify()
+}
@@ -2,64 +2,54 @@ package controllers
 
 import (
 	"context"
-	"fmt"
-	"log"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var menuCollection *mongo.Collection = database.OpenCollection(database.Client, "menus")
-
-func GetMenus() gin.HandlerFunc {
+func (ctl *Controller) GetMenus() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		result, err := menuCollection.Find(context.TODO(), bson.M{})
-
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
+		allMenus, err := ctl.store.Menus.List(ctx, helpers.GetTenant(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching menus"})
 			return
 		}
 
-		var allMenus []bson.M
-		if err = result.All(ctx, &allMenus); err != nil {
-			log.Fatal(err)
-		}
-
 		c.JSON(http.StatusOK, allMenus)
-		return
 	}
 }
 
-func GetMenu() gin.HandlerFunc {
+func (ctl *Controller) GetMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		menuId := c.Param("menu_id")
 		defer cancel()
-		var menu models.Menu
 
-		err := menuCollection.FindOne(ctx, bson.M{"menu_id": menuId}).Decode(&menu)
+		menu, err := ctl.store.Menus.Get(ctx, helpers.GetTenant(c), menuId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching menu"})
 			return
 		}
 		c.JSON(http.StatusOK, menu)
-		return
 	}
 }
 
-func CreateMenu() gin.HandlerFunc {
+func (ctl *Controller) CreateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var menu models.Menu
 		defer cancel()
 		if err := c.BindJSON(&menu); err != nil {
@@ -77,22 +67,20 @@ func CreateMenu() gin.HandlerFunc {
 		menu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		menu.ID = primitive.NewObjectID()
 		menu.MenuId = menu.ID.Hex()
+		menu.RestaurantId = helpers.GetTenant(c)
 
-		_, insertErr := menuCollection.InsertOne(ctx, menu)
-		if insertErr != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the menu %s", insertErr)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := ctl.store.Menus.Create(ctx, menu); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the menu"})
 			return
 		}
 
 		c.JSON(http.StatusCreated, menu)
-		return
 	}
 }
 
-func UpdateMenu() gin.HandlerFunc {
+func (ctl *Controller) UpdateMenu() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var menu models.Menu
 		defer cancel()
 
@@ -102,52 +90,45 @@ func UpdateMenu() gin.HandlerFunc {
 		}
 
 		menuId := c.Param("menu_id")
-		filter := bson.M{"menu_id": menuId}
-
-		var updateObj primitive.D
+		storedMenu, err := ctl.store.Menus.Get(ctx, helpers.GetTenant(c), menuId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching menu"})
+			return
+		}
 
-		if menu.StartDate != nil && menu.EndDate != nil {
+		if (menu.StartDate == nil) != (menu.EndDate == nil) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date must be updated together"})
+			return
+		}
+		if menu.StartDate != nil {
 			if !inTimeSpan(*menu.StartDate, *menu.EndDate, time.Now()) {
 				msg := "Kindly enter a valid date range"
 				c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 				return
 			}
-			updateObj = append(updateObj, bson.E{Key: "start_date", Value: menu.StartDate})
-			updateObj = append(updateObj, bson.E{Key: "end_date", Value: menu.EndDate})
-
-			if menu.Name != "" {
-				updateObj = append(updateObj, bson.E{Key: "name", Value: menu.Name})
-			}
-
-			if menu.Category != "" {
-				updateObj = append(updateObj, bson.E{Key: "category", Value: menu.Category})
-			}
+			storedMenu.StartDate = menu.StartDate
+			storedMenu.EndDate = menu.EndDate
+		}
 
-			menu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-			updateObj = append(updateObj, bson.E{Key: "updated_at", Value: menu.UpdatedAt})
+		if menu.Name != "" {
+			storedMenu.Name = menu.Name
+		}
 
-			upsert := true
-			opt := options.UpdateOptions{
-				Upsert: &upsert,
-			}
+		if menu.Category != "" {
+			storedMenu.Category = menu.Category
+		}
 
-			result, err := menuCollection.UpdateOne(
-				ctx,
-				filter,
-				bson.D{
-					{"$set", updateObj},
-				},
-				&opt)
-			if err != nil {
-				msg := fmt.Sprintf("error ocurred while updating the menu %s", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{"message": "Menu updated successfully"})
-			c.JSON(http.StatusOK, result)
+		storedMenu.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
+		if err := ctl.store.Menus.Update(ctx, storedMenu); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the menu"})
 			return
 		}
+		c.JSON(http.StatusOK, storedMenu)
 	}
 }
 
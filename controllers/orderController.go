@@ -2,58 +2,55 @@ package controllers
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"errors"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var orderCollection *mongo.Collection = database.OpenCollection(database.Client, "orders")
-
-func GetOrders() gin.HandlerFunc {
+func (ctl *Controller) GetOrders() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
-		result, err := orderCollection.Find(context.TODO(), bson.M{})
+		allOrders, err := ctl.store.Orders.List(ctx, helpers.GetTenant(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching orders"})
-		}
-		var allOrders []bson.M
-		if err = result.All(ctx, &allOrders); err != nil {
-			log.Fatal(err)
+			return
 		}
 		c.JSON(http.StatusOK, allOrders)
 	}
 }
 
-func GetOrder() gin.HandlerFunc {
+func (ctl *Controller) GetOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 		orderId := c.Param("order_id")
-		var order models.Order
 
-		err := orderCollection.FindOne(ctx, bson.M{"order_id": orderId}).Decode(&order)
+		order, err := ctl.store.Orders.Get(ctx, helpers.GetTenant(c), orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+			return
 		}
 		c.JSON(http.StatusOK, order)
 	}
 }
 
-func CreateOrder() gin.HandlerFunc {
+func (ctl *Controller) CreateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-		var table models.Table
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var order models.Order
 
 		defer cancel()
@@ -69,103 +66,442 @@ func CreateOrder() gin.HandlerFunc {
 			return
 		}
 
-		if order.TableId != nil {
-			err := tableCollection.FindOne(ctx, bson.M{"table_id": order.TableId}).Decode(&table)
-			defer cancel()
-			if err != nil {
-				msg := fmt.Sprintf("Table was not found with id %s", order.TableId)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
-				return
-			}
+		if _, err := ctl.store.Tables.Get(ctx, helpers.GetTenant(c), *order.TableId); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Table was not found with id " + *order.TableId})
+			return
 		}
 
-		order.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-		order.ID = primitive.NewObjectID()
-		order.OrderId = order.ID.Hex()
-
-		result, err := orderCollection.InsertOne(ctx, order)
-
+		order.RestaurantId = helpers.GetTenant(c)
+		order.ServerId = c.GetString("uid")
+		order, err := ctl.OrderItemOrderCreator(ctx, order, helpers.GetActor(c))
 		if err != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the order %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the order"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		c.JSON(http.StatusCreated, order)
 	}
 }
 
-func UpdateOrder() gin.HandlerFunc {
+func (ctl *Controller) UpdateOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var order models.Order
-		var table models.Table
 		defer cancel()
 
 		orderId := c.Param("order_id")
-		var updateObj primitive.D
 
 		if err := c.BindJSON(&order); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
 
+		storedOrder, err := ctl.store.Orders.Get(ctx, helpers.GetTenant(c), orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+			return
+		}
+		if !ctl.orderEditable(ctx, c, storedOrder) {
+			return
+		}
+
 		if order.TableId != nil {
-			err := menuCollection.FindOne(ctx, bson.M{"table_id": order.TableId}).Decode(&table)
-			defer cancel()
+			if _, err := ctl.store.Tables.Get(ctx, helpers.GetTenant(c), *order.TableId); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Table was not found with id " + *order.TableId})
+				return
+			}
+
+			storedOrder.TableId = order.TableId
+		}
 
-			if err != nil {
-				msg := fmt.Sprintf("Menu was not found with id %s", order.TableId)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		// handing the order over to another server hands its tips over too
+		if order.ServerId != "" && order.ServerId != storedOrder.ServerId {
+			if err := helpers.CheckUserRole(c, models.RoleAdmin, models.RoleManager); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "only managers can hand an order over to another server"})
+				return
+			}
+			server, err := ctl.store.Users.Get(ctx, order.ServerId)
+			if err != nil || server.RestaurantId != storedOrder.RestaurantId {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Server was not found with id " + order.ServerId})
 				return
 			}
 
-			updateObj = append(updateObj, bson.E{"menu", order.TableId})
+			storedOrder.ServerId = order.ServerId
 		}
 
-		order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{Key: "updated_at", Value: order.UpdatedAt})
+		storedOrder.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		upsert := true
-		filter := bson.M{"order_id": orderId}
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		updated, err := ctl.store.Orders.Reassign(ctx, storedOrder.RestaurantId, storedOrder.OrderId, storedOrder.CurrentStatus(), storedOrder.TableId, storedOrder.ServerId, storedOrder.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Order update failed"})
+			return
+		}
+		if !updated {
+			c.JSON(http.StatusConflict, gin.H{"error": "order status changed meanwhile, please reload it"})
+			return
 		}
 
-		result, err := orderCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{"$set", updateObj},
-			},
-			&opt,
-		)
+		c.JSON(http.StatusOK, storedOrder)
+	}
+}
+
+type OrderTransition struct {
+	Status string `json:"status"`
+}
+
+// TransitionOrder moves an order to the next status of its lifecycle. Moves
+// that OrderTransitions does not allow are rejected with 409 Conflict, and so
+// is PAID: an order is paid when its invoice is.
+func (ctl *Controller) TransitionOrder() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var transition OrderTransition
+		if err := c.BindJSON(&transition); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, known := models.OrderTransitions[transition.Status]; !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown order status " + transition.Status})
+			return
+		}
 
+		orderId := c.Param("order_id")
+		order, err := ctl.store.Orders.Get(ctx, helpers.GetTenant(c), orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
 		if err != nil {
-			msg := fmt.Sprintf("Order Item update failed %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+			return
+		}
+
+		if transition.Status == models.OrderPaid {
+			c.JSON(http.StatusConflict, gin.H{"error": "orders are marked PAID when their invoice is paid"})
+			return
+		}
+		if !order.CanTransition(transition.Status) {
+			c.JSON(http.StatusConflict, gin.H{"error": "order cannot move from " + order.CurrentStatus() + " to " + transition.Status})
 			return
 		}
+		if transition.Status == models.OrderCancelled && !ctl.cancellable(ctx, c, order) {
+			return
+		}
+
+		change := models.OrderStatusChange{Status: transition.Status, ChangedBy: helpers.GetActor(c)}
+		change.ChangedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		moved, err := ctl.store.Orders.Transition(ctx, order.RestaurantId, order.OrderId, order.CurrentStatus(), change)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while changing the order status"})
+			return
+		}
+		if !moved {
+			c.JSON(http.StatusConflict, gin.H{"error": "order status changed meanwhile, please reload it"})
+			return
+		}
+
+		if change.Status == models.OrderCancelled {
+			if err := ctl.voidLiveItems(ctx, order, change.ChangedAt); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while voiding the order items"})
+				return
+			}
+		}
+
+		order.Status = change.Status
+		order.StatusHistory = append(order.StatusHistory, change)
+		order.UpdatedAt = change.ChangedAt
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// cancellable answers the request itself when order cannot be cancelled by
+// the user: invoiced orders are voided through their invoice, and only
+// managers may throw away items that are still live.
+func (ctl *Controller) cancellable(ctx context.Context, c *gin.Context, order models.Order) bool {
+	_, err := ctl.store.Invoices.GetByOrder(ctx, order.RestaurantId, order.OrderId)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "order has been invoiced, void its invoice instead"})
+		return false
+	}
+	if err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the invoice of the order"})
+		return false
+	}
+
+	orderItems, err := ctl.store.OrderItems.ListByOrder(ctx, order.RestaurantId, order.OrderId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+		return false
+	}
+	for _, orderItem := range orderItems {
+		if orderItem.CurrentStatus() == models.OrderItemVoided {
+			continue
+		}
+		if err := helpers.CheckUserRole(c, models.RoleAdmin, models.RoleManager); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only managers can cancel an order with items on it, void the items first"})
+			return false
+		}
+		break
+	}
+
+	return true
+}
+
+// orderEditable answers 409 itself when order can no longer be changed:
+// once it is paid or cancelled, or once it has been invoiced, when the
+// invoice is corrected with a credit note or voided instead.
+func (ctl *Controller) orderEditable(ctx context.Context, c *gin.Context, order models.Order) bool {
+	if order.Closed() {
+		c.JSON(http.StatusConflict, gin.H{"error": "order is " + order.CurrentStatus() + " and can no longer be changed"})
+		return false
+	}
+
+	invoice, err := ctl.store.Invoices.GetByOrder(ctx, order.RestaurantId, order.OrderId)
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "order has been invoiced as " + receiptFileName(invoice) + ", correct the invoice with a credit note instead"})
+		return false
+	}
+	if err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the invoice of the order"})
+		return false
+	}
+
+	return true
+}
+
+// settleOrder marks the order of invoice PAID once the invoice is paid,
+// whatever status the order had reached.
+func (ctl *Controller) settleOrder(ctx context.Context, invoice models.Invoice, by string) error {
+	for attempt := 0; attempt < 3; attempt++ {
+		order, err := ctl.store.Orders.Get(ctx, invoice.RestaurantId, invoice.OrderId)
+		if err == repository.ErrNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if order.Closed() {
+			return nil
+		}
 
-		c.JSON(http.StatusOK, result)
+		change := models.OrderStatusChange{Status: models.OrderPaid, ChangedAt: invoice.UpdatedAt, ChangedBy: by}
+		moved, err := ctl.store.Orders.Transition(ctx, order.RestaurantId, order.OrderId, order.CurrentStatus(), change)
+		if err != nil || moved {
+			return err
+		}
 	}
+
+	return errors.New("order " + invoice.OrderId + " kept changing status while it was marked paid")
 }
 
-func OrderItemOrderCreator(order models.Order) string {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
+// voidLiveItems takes the items of a cancelled order off the station queues.
+func (ctl *Controller) voidLiveItems(ctx context.Context, order models.Order, at time.Time) error {
+	orderItems, err := ctl.store.OrderItems.ListByOrder(ctx, order.RestaurantId, order.OrderId)
+	if err != nil {
+		return err
+	}
 
+	for _, orderItem := range orderItems {
+		if !orderItem.CanTransition(models.OrderItemVoided) {
+			continue
+		}
+		voided, err := ctl.store.OrderItems.SetStatus(ctx, order.RestaurantId, orderItem.OrderItemId, orderItem.CurrentStatus(), models.OrderItemVoided, at)
+		if err != nil {
+			return err
+		}
+		if voided {
+			orderItem.Status = models.OrderItemVoided
+			orderItem.StatusUpdatedAt = at
+			orderItem.UpdatedAt = at
+			ctl.publishKitchenEvent(ctx, models.KitchenItemVoided, orderItem)
+		}
+	}
+
+	return nil
+}
+
+// OrderItemOrderCreator stores a new, open order and returns it with its ids,
+// status and timestamps filled in.
+func (ctl *Controller) OrderItemOrderCreator(ctx context.Context, order models.Order, createdBy string) (models.Order, error) {
 	order.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 	order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	order.Status = models.OrderOpen
+	order.StatusHistory = []models.OrderStatusChange{{Status: models.OrderOpen, ChangedAt: order.CreatedAt, ChangedBy: createdBy}}
+	order.Discount = nil
+	order.Coupons = nil
+	order.AppliedDiscounts = nil
 	order.ID = primitive.NewObjectID()
 	order.OrderId = order.ID.Hex()
 
-	_, err := orderCollection.InsertOne(ctx, order)
+	if err := ctl.store.Orders.Create(ctx, order); err != nil {
+		return models.Order{}, err
+	}
+
+	return order, nil
+}
+
+// GetOrderTotals prices the order as it stands: subtotal, discounts, service
+// charge, tax by category, rounding and the total due.
+func (ctl *Controller) GetOrderTotals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		totals, err := ctl.OrderTotals(ctx, helpers.GetTenant(c), c.Param("order_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while pricing the order"})
+			return
+		}
+
+		c.JSON(http.StatusOK, totals)
+	}
+}
+
+// SetOrderDiscount takes the discount in the body off the whole order,
+// replacing any discount it had. The manager setting it is recorded as the
+// one who approved it.
+func (ctl *Controller) SetOrderDiscount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var discount models.Discount
+		if err := c.BindJSON(&discount); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(discount); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		discount.ApprovedBy = helpers.GetActor(c)
+		discount.ApprovedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		ctl.discountOrder(ctx, c, &discount)
+	}
+}
+
+func (ctl *Controller) RemoveOrderDiscount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		ctl.discountOrder(ctx, c, nil)
+	}
+}
+
+// discountOrder gives the order named in the path discount, or removes its
+// discount when discount is nil, and answers the request itself.
+func (ctl *Controller) discountOrder(ctx context.Context, c *gin.Context, discount *models.Discount) {
+	order, err := ctl.store.Orders.Get(ctx, helpers.GetTenant(c), c.Param("order_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+		return
+	}
+	if !ctl.orderEditable(ctx, c, order) {
+		return
+	}
+
+	order.Discount = discount
+	order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	updated, err := ctl.store.Orders.SetDiscount(ctx, order.RestaurantId, order.OrderId, order.CurrentStatus(), discount, order.UpdatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Order update failed"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "order status changed meanwhile, please reload it"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// OrderTotals prices an order with the pricing settings of its restaurant,
+// its automatic promotions and the coupons redeemed on the order, and the
+// number of guests at its table.
+func (ctl *Controller) OrderTotals(ctx context.Context, restaurantId string, orderId string) (models.OrderTotals, error) {
+	order, err := ctl.store.Orders.Get(ctx, restaurantId, orderId)
+	if err != nil {
+		return models.OrderTotals{}, err
+	}
+
+	orderItems, err := ctl.store.OrderItems.ListByOrder(ctx, restaurantId, orderId)
 	if err != nil {
-		return "error occurred while inserting the order"
+		return models.OrderTotals{}, err
+	}
+
+	foodIds := []string{}
+	for _, orderItem := range orderItems {
+		if orderItem.FoodId != nil {
+			foodIds = append(foodIds, *orderItem.FoodId)
+		}
+	}
+	foods, err := ctl.store.Foods.GetMany(ctx, restaurantId, foodIds)
+	if err != nil {
+		return models.OrderTotals{}, err
+	}
+	pricing := models.PricingContext{Foods: map[string]models.Food{}, Menus: map[string]models.Menu{}}
+	for _, food := range foods {
+		pricing.Foods[food.FoodId] = food
+	}
+
+	menus, err := ctl.store.Menus.List(ctx, restaurantId)
+	if err != nil {
+		return models.OrderTotals{}, err
+	}
+	for _, menu := range menus {
+		pricing.Menus[menu.MenuId] = menu
+	}
+
+	promotions, err := ctl.store.Promotions.List(ctx, restaurantId)
+	if err != nil {
+		return models.OrderTotals{}, err
+	}
+	for _, promotion := range promotions {
+		if !promotion.IsCoupon() || slices.Contains(order.Coupons, *promotion.Code) {
+			pricing.Promotions = append(pricing.Promotions, promotion)
+		}
+	}
+
+	if order.TableId != nil {
+		table, err := ctl.store.Tables.Get(ctx, restaurantId, *order.TableId)
+		if err != nil && err != repository.ErrNotFound {
+			return models.OrderTotals{}, err
+		}
+		if table.NumberOfGuests != nil {
+			pricing.Guests = *table.NumberOfGuests
+		}
+	}
+
+	restaurant, err := ctl.store.Restaurants.Get(ctx, restaurantId)
+	if err != nil && err != repository.ErrNotFound {
+		return models.OrderTotals{}, err
+	}
+	if restaurant.Pricing != nil {
+		pricing.Settings = *restaurant.Pricing
 	}
+	pricing.Location = restaurant.Location()
 
-	return order.OrderId
+	totals := models.CalculateTotals(order, orderItems, pricing)
+	totals.Currency = restaurant.CurrencyCode()
+	return totals, nil
 }
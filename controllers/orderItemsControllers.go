@@ -2,17 +2,16 @@ package controllers
 
 import (
 	"context"
-	"log"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type OrderItemPack struct {
@@ -20,62 +19,88 @@ type OrderItemPack struct {
 	OrderItems []models.OrderItem
 }
 
-var orderItemsCollection *mongo.Collection = database.OpenCollection(database.Client, "orderItem")
+// OrderView is an order together with its table and the food behind each of
+// its items, as shown on invoices.
+type OrderView struct {
+	OrderId     string          `json:"order_id"`
+	TableId     string          `json:"table_id"`
+	TableNumber *int            `json:"table_number"`
+	PaymentDue  models.Money    `json:"payment_due"`
+	TotalCount  int             `json:"total_count"`
+	OrderItems  []OrderItemView `json:"order_items"`
+}
+
+type OrderItemView struct {
+	OrderItemId string                    `json:"order_item_id"`
+	FoodId      string                    `json:"food_id"`
+	FoodName    *string                   `json:"food_name"`
+	FoodImage   *string                   `json:"food_image"`
+	Price       *models.Money             `json:"price"`
+	Size        *string                   `json:"size"`
+	Quantity    int                       `json:"quantity"`
+	Modifiers   []models.SelectedModifier `json:"modifiers"`
+	LineTotal   models.Money              `json:"line_total"`
+	Seat        *int                      `json:"seat"`
+	Status      string                    `json:"status"`
+}
 
-func GetOrderItems() gin.HandlerFunc {
+func (ctl *Controller) GetOrderItems() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
-		result, err := orderItemsCollection.Find(context.TODO(), bson.M{})
+		allOrderedItems, err := ctl.store.OrderItems.List(ctx, helpers.GetTenant(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occured while listing ordered items"})
 			return
 		}
 
-		var allOrderedItems []bson.M
-		if err = result.All(ctx, &allOrderedItems); err != nil {
-			log.Fatal(err)
-			return
-		}
-
 		c.JSON(http.StatusOK, allOrderedItems)
 	}
 }
 
-func GetOrderItem() gin.HandlerFunc {
+func (ctl *Controller) GetOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		orderId := c.Param("order_id")
-		allOrderItems, err := ItemsByOrder(orderId)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		orderItemId := c.Param("order_item_id")
 
+		orderItem, err := ctl.store.OrderItems.Get(ctx, helpers.GetTenant(c), orderItemId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
 			return
 		}
-		c.JSON(http.StatusOK, allOrderItems)
+		c.JSON(http.StatusOK, orderItem)
 	}
 }
 
-func GetOrderItemsByOrder() gin.HandlerFunc {
+func (ctl *Controller) GetOrderItemsByOrder() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
-		orderItemId := c.Param("order_item_id")
-		var orderItem models.OrderItem
-
-		err := orderItemsCollection.FindOne(ctx, bson.M{"order_item_id": orderItemId}).Decode(&orderItem)
+		orderId := c.Param("order_id")
+		orderView, err := ctl.ItemsByOrder(ctx, helpers.GetTenant(c), orderId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
 			return
 		}
-		c.JSON(http.StatusOK, orderItem)
+		c.JSON(http.StatusOK, orderView)
 	}
 }
 
-func CreateOrderItem() gin.HandlerFunc {
+func (ctl *Controller) CreateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var orderItemPack OrderItemPack
 		var order models.Order
 		defer cancel()
@@ -86,238 +111,438 @@ func CreateOrderItem() gin.HandlerFunc {
 		}
 
 		order.OrderDate, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-		orderItemsToBeInserted := []interface{}{}
 		order.TableId = orderItemPack.TableId
-		order_id := OrderItemOrderCreator(order)
+		order.RestaurantId = helpers.GetTenant(c)
 
+		foodIds := []string{}
 		for _, orderItem := range orderItemPack.OrderItems {
-			orderItem.OrderId = order_id
+			if orderItem.FoodId != nil {
+				foodIds = append(foodIds, *orderItem.FoodId)
+			}
+		}
+		foods, err := ctl.store.Foods.GetMany(ctx, order.RestaurantId, foodIds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the food items"})
+			return
+		}
+		foodsById := map[string]models.Food{}
+		for _, food := range foods {
+			foodsById[food.FoodId] = food
+		}
 
-			validationErr := validate.Struct(orderItem)
+		orderItemsToBeInserted := []models.OrderItem{}
+		for _, orderItem := range orderItemPack.OrderItems {
+			orderItem.RestaurantId = order.RestaurantId
 
+			validationErr := validate.Struct(orderItem)
 			if validationErr != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
 				return
 			}
 
+			food, found := foodsById[*orderItem.FoodId]
+			if !found {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "food with id " + *orderItem.FoodId + " does not exist"})
+				return
+			}
+			if err := orderItem.PriceFrom(food); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if orderItem.Quantity == nil {
+				var one = 1
+				orderItem.Quantity = &one
+			}
+
 			orderItem.ID = primitive.NewObjectID()
 			orderItem.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 			orderItem.OrderItemId = orderItem.ID.Hex()
-
-			var number = toFixed(*orderItem.UnitPrice, 2)
-			orderItem.UnitPrice = &number
+			orderItem.Status = models.OrderItemQueued
+			orderItem.Discount = nil
+			orderItem.StatusUpdatedAt = orderItem.CreatedAt
+			orderItem.Station = stationOf(food)
 
 			orderItemsToBeInserted = append(orderItemsToBeInserted, orderItem)
 		}
 
-		insertResult, insertErr := orderItemsCollection.InsertMany(ctx, orderItemsToBeInserted)
-		if insertErr != nil {
-			log.Fatal(insertErr)
+		order.ServerId = c.GetString("uid")
+		order, err = ctl.OrderItemOrderCreator(ctx, order, helpers.GetActor(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while inserting the order"})
 			return
 		}
+		for i := range orderItemsToBeInserted {
+			orderItemsToBeInserted[i].OrderId = order.OrderId
+		}
 
-		c.JSON(http.StatusCreated, insertResult)
+		if err := ctl.store.OrderItems.CreateMany(ctx, orderItemsToBeInserted); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while inserting the order items"})
+			return
+		}
+		for _, orderItem := range orderItemsToBeInserted {
+			ctl.publishKitchenEvent(ctx, models.KitchenItemCreated, orderItem)
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"order": order, "order_items": orderItemsToBeInserted})
 	}
 }
 
-func UpdateOrderItem() gin.HandlerFunc {
+func (ctl *Controller) UpdateOrderItem() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var orderItem models.OrderItem
 		defer cancel()
 
 		orderItemId := c.Param("order_item_id")
-		filter := bson.M{"order_item_id": orderItemId}
+		if err := c.BindJSON(&orderItem); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 
-		var updateObj primitive.D
+		storedOrderItem, err := ctl.store.OrderItems.Get(ctx, helpers.GetTenant(c), orderItemId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+			return
+		}
 
-		if orderItem.UnitPrice != nil {
-			updateObj = append(updateObj, bson.E{Key: "unit_price", Value: *&orderItem.UnitPrice})
+		if !ctl.itemEditable(ctx, c, storedOrderItem) {
+			return
 		}
+
 		if orderItem.Quantity != nil {
-			updateObj = append(updateObj, bson.E{Key: "quantity", Value: *orderItem.Quantity})
+			storedOrderItem.Quantity = orderItem.Quantity
 		}
+		repriced := orderItem.FoodId != nil || orderItem.Size != nil || orderItem.Modifiers != nil
 		if orderItem.FoodId != nil {
-			updateObj = append(updateObj, bson.E{Key: "food_id", Value: *orderItem.FoodId})
+			storedOrderItem.FoodId = orderItem.FoodId
+		}
+		if orderItem.Size != nil {
+			storedOrderItem.Size = orderItem.Size
+		}
+		if orderItem.Modifiers != nil {
+			storedOrderItem.Modifiers = orderItem.Modifiers
+		}
+		if orderItem.Seat != nil {
+			storedOrderItem.Seat = orderItem.Seat
 		}
 
-		orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updateObj = append(updateObj, bson.E{"updated_at", orderItem.UpdatedAt})
+		if validationErr := validate.Struct(storedOrderItem); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
+		if repriced {
+			food, err := ctl.store.Foods.Get(ctx, helpers.GetTenant(c), *storedOrderItem.FoodId)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "food with id " + *storedOrderItem.FoodId + " does not exist"})
+				return
+			}
+			if err := storedOrderItem.PriceFrom(food); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			storedOrderItem.Station = stationOf(food)
 		}
 
-		result, errResult := orderItemsCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{
-					"$set", updateObj,
-				},
-			},
-			&opt,
-		)
+		storedOrderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		if errResult != nil {
+		if err := ctl.store.OrderItems.Update(ctx, storedOrderItem); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating order item"})
 			return
 		}
 
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, storedOrderItem)
+	}
+}
+
+type OrderItemStatus struct {
+	Status string `json:"status"`
+}
+
+// SetOrderItemStatus moves an order item to the kitchen status in the body, as
+// long as OrderItemTransitions allows it.
+func (ctl *Controller) SetOrderItemStatus() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var orderItemStatus OrderItemStatus
+		if err := c.BindJSON(&orderItemStatus); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, known := models.OrderItemTransitions[orderItemStatus.Status]; !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown order item status " + orderItemStatus.Status})
+			return
+		}
+		// voids need a reason and may need a manager, see VoidOrderItem
+		if orderItemStatus.Status == models.OrderItemVoided {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order items are voided through /orderItems/:order_item_id/void"})
+			return
+		}
+
+		ctl.moveOrderItem(ctx, c, func(orderItem models.OrderItem) string { return orderItemStatus.Status })
+	}
+}
+
+// BumpOrderItem moves an order item on to the next kitchen status: queued
+// items start cooking, cooking ones are ready and ready ones are served.
+func (ctl *Controller) BumpOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		ctl.moveOrderItem(ctx, c, models.OrderItem.NextStatus)
+	}
+}
+
+// GetStationQueue lists the items a station still has to prepare or hand
+// over, oldest first.
+func (ctl *Controller) GetStationQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		station := c.Param("station")
+		if !slices.Contains(models.Stations, station) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown station " + station})
+			return
+		}
+
+		queue, err := ctl.store.OrderItems.ListByStation(ctx, helpers.GetTenant(c), station, models.LiveOrderItemStatuses)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the station queue"})
+			return
+		}
+
+		c.JSON(http.StatusOK, queue)
+	}
+}
+
+// SetOrderItemDiscount takes the discount in the body off an order item,
+// replacing any discount it had.
+func (ctl *Controller) SetOrderItemDiscount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var discount models.Discount
+		if err := c.BindJSON(&discount); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(discount); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		discount.ApprovedBy = helpers.GetActor(c)
+		discount.ApprovedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		ctl.discountOrderItem(ctx, c, &discount)
+	}
+}
+
+func (ctl *Controller) RemoveOrderItemDiscount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		ctl.discountOrderItem(ctx, c, nil)
+	}
+}
+
+// discountOrderItem gives the order item named in the path discount, or
+// removes its discount when discount is nil, and answers the request itself.
+func (ctl *Controller) discountOrderItem(ctx context.Context, c *gin.Context, discount *models.Discount) {
+	orderItem, err := ctl.store.OrderItems.Get(ctx, helpers.GetTenant(c), c.Param("order_item_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+		return
+	}
+
+	if !ctl.itemEditable(ctx, c, orderItem) {
+		return
+	}
+
+	orderItem.Discount = discount
+	orderItem.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	if err := ctl.store.OrderItems.Update(ctx, orderItem); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating order item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, orderItem)
+}
+
+// moveOrderItem moves the order item named in the path to the status next
+// picks for it, and answers the request itself.
+func (ctl *Controller) moveOrderItem(ctx context.Context, c *gin.Context, next func(models.OrderItem) string) {
+	orderItem, err := ctl.store.OrderItems.Get(ctx, helpers.GetTenant(c), c.Param("order_item_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+		return
+	}
+
+	if !ctl.orderStillOpen(ctx, c, orderItem) {
+		return
 	}
+
+	status := next(orderItem)
+	if !orderItem.CanTransition(status) {
+		c.JSON(http.StatusConflict, gin.H{"error": "order item cannot move from " + orderItem.CurrentStatus() + " to " + status})
+		return
+	}
+
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	moved, err := ctl.store.OrderItems.SetStatus(ctx, orderItem.RestaurantId, orderItem.OrderItemId, orderItem.CurrentStatus(), status, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while changing the order item status"})
+		return
+	}
+	if !moved {
+		c.JSON(http.StatusConflict, gin.H{"error": "order item status changed meanwhile, please reload it"})
+		return
+	}
+
+	orderItem.Status = status
+	orderItem.StatusUpdatedAt = now
+	orderItem.UpdatedAt = now
+	ctl.publishKitchenEvent(ctx, kitchenEventFor(status), orderItem)
+
+	c.JSON(http.StatusOK, orderItem)
 }
 
-func ItemsByOrder(orderId string) (OrderItems []primitive.M, err error) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-
-	matchStage := bson.D{
-		{"$match", bson.D{
-			{"order_id", orderId},
-		}},
-	}
-
-	lookupFoodStage := bson.D{
-		{
-			"$lookup", bson.D{
-				{"from", "food"},
-				{"localField", "food_id"},
-				{"foreignField", "food_id"},
-				{"as", "food"},
-			},
-		},
-	}
-	unwindFoodStage := bson.D{
-		{
-			"$unwind", bson.D{
-				{"path", "$food"},
-				{"preserveNullAndEmptyArrays", true}},
-		},
-	}
-
-	lookupOrderStage := bson.D{
-		{
-			"$lookup", bson.D{
-				{"from", "order"},
-				{"localField", "order_id"},
-				{"foreignField", "order_id"},
-				{"as", "order"},
-			},
-		},
-	}
-	unwindOrderStage := bson.D{
-		{
-			"$unwind", bson.D{
-				{"path", "$order"},
-				{"preserveNullAndEmptyArrays", true}},
-		},
-	}
-
-	lookupTableStage := bson.D{
-		{
-			"$lookup", bson.D{
-				{"from", "table"},
-				{"localField", "order.table_id"},
-				{"foreignField", "table_id"},
-				{"as", "table"},
-			},
-		},
-	}
-	unwindTableStage := bson.D{
-		{
-			"$unwind", bson.D{
-				{"path", "$table"},
-				{"preserveNullAndEmptyArrays", true}},
-		},
-	}
-
-	projectStage := bson.D{
-		{
-			"$project", bson.D{
-				{"_id", 0},
-				{"amount", "$food.price"},
-				{"total_count", 1},
-				{"food_name", "$food.name"},
-				{"food_image", "$food.food_image"},
-				{"table_number", "$table.table_number"},
-				{"table_id", "$table.table_id"},
-				{"order_id", "$order.order_id"},
-				{"price", "$food.price"},
-				{"quantity", 1},
-			}},
-	}
-
-	groupStage := bson.D{
-		{
-			"$group", bson.D{
-				{
-					"_id", bson.D{
-						{"order_id", "$order_id"},
-						{"table_id", "$table_id"},
-						{"table_number", "$table_number"},
-					},
-				},
-				{
-					"payment_due", bson.D{
-						{"$sum", "$amount"},
-					},
-				},
-				{
-					"total_count", bson.D{
-						{"$sum", 1},
-					},
-				},
-				{
-					"order_items", bson.D{
-						{"$push", "$order_items"},
-					},
-				},
-			},
-		},
-	}
-
-	projectStage2 := bson.D{
-		{
-			"$project", bson.D{
-				{"_id", 0},
-				{"payment_due", 1},
-				{"total_count", 1},
-				{"table_number", "$_id.table_number"},
-				{"order_items", 1},
-				{"table_id", 1},
-				{"order_id", 1},
-			},
-		},
-	}
-
-	result, err := orderItemsCollection.Aggregate(
-		ctx,
-		mongo.Pipeline{
-			matchStage,
-			lookupFoodStage,
-			unwindFoodStage,
-			lookupOrderStage,
-			unwindOrderStage,
-			lookupTableStage,
-			unwindTableStage,
-			projectStage,
-			groupStage,
-			projectStage2,
-		},
-	)
+// orderStillOpen answers 409 itself when the order of orderItem has been
+// cancelled. Paid orders are still cooked and served.
+func (ctl *Controller) orderStillOpen(ctx context.Context, c *gin.Context, orderItem models.OrderItem) bool {
+	order, err := ctl.store.Orders.Get(ctx, orderItem.RestaurantId, orderItem.OrderId)
+	if err != nil && err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+		return false
+	}
+	if err == nil && order.CurrentStatus() == models.OrderCancelled {
+		c.JSON(http.StatusConflict, gin.H{"error": "order is " + order.CurrentStatus() + " and its items can no longer be changed"})
+		return false
+	}
+
+	return true
+}
 
+// itemEditable answers the request itself when orderItem has been voided or
+// its order can no longer be changed, see orderEditable.
+func (ctl *Controller) itemEditable(ctx context.Context, c *gin.Context, orderItem models.OrderItem) bool {
+	if orderItem.CurrentStatus() == models.OrderItemVoided {
+		c.JSON(http.StatusConflict, gin.H{"error": "order item is " + models.OrderItemVoided + " and can no longer be changed"})
+		return false
+	}
+
+	order, err := ctl.store.Orders.Get(ctx, orderItem.RestaurantId, orderItem.OrderId)
+	if err == repository.ErrNotFound {
+		return true
+	}
 	if err != nil {
-		panic(err)
-		return nil, err
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+		return false
 	}
 
-	if err = result.All(ctx, &OrderItems); err != nil {
-		panic(err)
+	return ctl.orderEditable(ctx, c, order)
+}
+
+func kitchenEventFor(status string) string {
+	if status == models.OrderItemVoided {
+		return models.KitchenItemVoided
+	}
+	return models.KitchenItemStatusChanged
+}
+
+func stationOf(food models.Food) string {
+	if food.Station == nil {
+		return ""
+	}
+	return *food.Station
+}
+
+// ItemsByOrder assembles the order with its table and items, and totals what
+// is due for it. Voided items are listed, with their status, but not charged.
+func (ctl *Controller) ItemsByOrder(ctx context.Context, restaurantId string, orderId string) (OrderView, error) {
+	order, err := ctl.store.Orders.Get(ctx, restaurantId, orderId)
+	if err != nil {
+		return OrderView{}, err
+	}
+
+	orderView := OrderView{OrderId: order.OrderId, OrderItems: []OrderItemView{}}
+	if order.TableId != nil {
+		orderView.TableId = *order.TableId
+		table, err := ctl.store.Tables.Get(ctx, restaurantId, *order.TableId)
+		if err != nil && err != repository.ErrNotFound {
+			return OrderView{}, err
+		}
+		orderView.TableNumber = table.TableNumber
+	}
+
+	orderItems, err := ctl.store.OrderItems.ListByOrder(ctx, restaurantId, orderId)
+	if err != nil {
+		return OrderView{}, err
+	}
+
+	foodIds := []string{}
+	for _, orderItem := range orderItems {
+		if orderItem.FoodId != nil {
+			foodIds = append(foodIds, *orderItem.FoodId)
+		}
+	}
+	foods, err := ctl.store.Foods.GetMany(ctx, restaurantId, foodIds)
+	if err != nil {
+		return OrderView{}, err
+	}
+	foodsById := map[string]models.Food{}
+	for _, food := range foods {
+		foodsById[food.FoodId] = food
+	}
+
+	for _, orderItem := range orderItems {
+		itemView := OrderItemView{
+			OrderItemId: orderItem.OrderItemId,
+			Price:       orderItem.UnitPrice,
+			Size:        orderItem.Size,
+			Quantity:    orderItem.CurrentQuantity(),
+			Modifiers:   orderItem.Modifiers,
+			Seat:        orderItem.Seat,
+			Status:      orderItem.CurrentStatus(),
+		}
+		if orderItem.FoodId != nil {
+			food := foodsById[*orderItem.FoodId]
+			itemView.FoodId = *orderItem.FoodId
+			itemView.FoodName = food.Name
+			itemView.FoodImage = food.FoodImage
+			if orderItem.UnitPrice == nil {
+				orderItem.UnitPrice = food.Price
+				itemView.Price = food.Price
+			}
+		}
+		itemView.LineTotal = orderItem.LineTotal()
+		if itemView.Status != models.OrderItemVoided {
+			orderView.PaymentDue += itemView.LineTotal
+		}
+
+		orderView.OrderItems = append(orderView.OrderItems, itemView)
 	}
+	orderView.TotalCount = len(orderView.OrderItems)
 
-	return OrderItems, nil
+	return orderView, nil
 }
@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=100"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6,max=100"`
+}
+
+func (ctl *Controller) ChangePassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request ChangePasswordRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		foundUser, err := ctl.store.Users.Get(ctx, c.GetString("uid"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+			return
+		}
+
+		passwordIsValid, msg := VerifyPassword(request.CurrentPassword, *foundUser.Password)
+		if !passwordIsValid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+
+		if err := ctl.setPassword(ctx, foundUser.UserId, request.NewPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating the password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password changed, please sign in again"})
+	}
+}
+
+// ForgotPassword always answers the same way so it cannot be used to find out
+// which e-mail addresses have an account.
+func (ctl *Controller) ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request ForgotPasswordRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		response := gin.H{"message": "if the e-mail belongs to an account, a reset link has been sent"}
+
+		foundUser, err := ctl.store.Users.GetByEmail(ctx, request.Email)
+		if err != nil {
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		token, err := helpers.CreatePasswordReset(ctx, ctl.store.PasswordResets, foundUser.UserId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while creating the reset token"})
+			return
+		}
+
+		notification := helpers.Notification{
+			To:      *foundUser.Email,
+			Subject: "Reset your password",
+			Body:    fmt.Sprintf("Use this code to reset your password within %s: %s", config.Get().PasswordResetTTL, token),
+		}
+		if err := ctl.notifier.Notify(ctx, notification); err != nil {
+			log.Println("could not deliver the password reset:", err)
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+func (ctl *Controller) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request ResetPasswordRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		userId, err := helpers.ConsumePasswordReset(ctx, ctl.store.PasswordResets, request.Token)
+		if err == helpers.ErrInvalidResetToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking the reset token"})
+			return
+		}
+
+		if err := ctl.setPassword(ctx, userId, request.NewPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating the password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "password has been reset, please sign in"})
+	}
+}
+
+// setPassword stores the new password hash and signs the user out everywhere.
+func (ctl *Controller) setPassword(ctx context.Context, userId string, password string) error {
+	foundUser, err := ctl.store.Users.Get(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword := HashPassword(password)
+	foundUser.Password = &hashedPassword
+	foundUser.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	if err := ctl.store.Users.Update(ctx, foundUser); err != nil {
+		return err
+	}
+
+	return helpers.RevokeAllUserTokens(ctx, ctl.store, userId)
+}
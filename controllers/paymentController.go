@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InvoicePayment is a payment towards an invoice: either an amount, or the
+// part of the bill a split gives the guest paying. The tip comes on top.
+type InvoicePayment struct {
+	Method       string        `json:"method" validate:"required,eq=CASH|eq=CARD"`
+	Amount       *models.Money `json:"amount" validate:"omitempty,gt=0"`
+	Tip          *models.Money `json:"tip" validate:"omitempty,min=0"`
+	Split        string        `json:"split" validate:"omitempty,eq=EVEN|eq=SEAT|eq=ITEMS"`
+	Ways         int           `json:"ways" validate:"required_if=Split EVEN,omitempty,min=2,max=50"`
+	Seat         int           `json:"seat" validate:"required_if=Split SEAT,omitempty,min=1,max=100"`
+	OrderItemIds []string      `json:"order_item_ids" validate:"required_if=Split ITEMS"`
+}
+
+// InvoiceCardPayment is a payment towards an invoice charged to the card
+// the token stands for.
+type InvoiceCardPayment struct {
+	InvoicePayment
+	CardToken string `json:"card_token" validate:"required"`
+}
+
+// AddInvoicePayment records a cash payment towards an invoice. Splits work
+// out the amount themselves: evenly, by the items at a seat, or by the items
+// picked, each item being paid for at most once. The invoice is PAID once
+// nothing is left outstanding.
+func (ctl *Controller) AddInvoicePayment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request InvoicePayment
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !validPayment(c, request) {
+			return
+		}
+		if request.Method == models.PaymentCard {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card payments are taken through the payment gateway, at /invoice/:invoice_id/pay"})
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+		invoice, ok = ctl.payable(ctx, c, invoice)
+		if !ok {
+			return
+		}
+		payment, ok := ctl.paymentFor(ctx, c, invoice, request)
+		if !ok {
+			return
+		}
+
+		paid, added, err := ctl.addPayment(ctx, invoice, payment)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the payment"})
+			return
+		}
+		if !added {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice was paid towards meanwhile, please reload it"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, paid)
+	}
+}
+
+// PayInvoice charges a card through the payment gateway: it authorizes the
+// amount, captures it and records the payment. Every attempt is recorded on
+// the invoice, declined or not.
+//
+// Requests carry an Idempotency-Key header. Repeating a request with the
+// same key answers as the first one did and never charges the card twice.
+func (ctl *Controller) PayInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request InvoiceCardPayment
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		request.Method = models.PaymentCard
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if !validPayment(c, request.InvoicePayment) {
+			return
+		}
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "card payments need an Idempotency-Key header"})
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok || !replayCardPayment(c, invoice, key) {
+			return
+		}
+		invoice, ok = ctl.payable(ctx, c, invoice)
+		if !ok {
+			return
+		}
+		payment, ok := ctl.paymentFor(ctx, c, invoice, request.InvoicePayment)
+		if !ok {
+			return
+		}
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, invoice.RestaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+
+		attempt := models.CardTransaction{IdempotencyKey: key, Amount: payment.Amount + payment.Tip}
+		attempt.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		charge := helpers.CardCharge{Amount: attempt.Amount, Currency: restaurant.CurrencyCode(), CardToken: request.CardToken, Reference: cardReference(invoice, key)}
+		result, err := ctl.gateway.Authorize(ctx, charge)
+		if err == nil && result.Status == helpers.GatewayApproved {
+			if _, err = ctl.gateway.Capture(ctx, result.TransactionId, attempt.Amount); err != nil {
+				// the card may or may not have been charged, so whatever
+				// was authorized is released, even once the request timed out
+				voidCtx, cancelVoid := context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+				attempt.Void = models.CardVoided
+				if _, voidErr := ctl.gateway.Void(voidCtx, result.TransactionId); voidErr != nil {
+					attempt.Void = voidErr.Error()
+				}
+				cancelVoid()
+			}
+		}
+		attempt.TransactionId, attempt.Brand, attempt.Last4 = result.TransactionId, result.Brand, result.Last4
+		attempt.DeclineCode, attempt.Message = result.DeclineCode, result.Message
+
+		switch {
+		case err != nil:
+			attempt.Status, attempt.Message = models.CardFailed, err.Error()
+		case result.Status == helpers.GatewayDeclined:
+			attempt.Status = models.CardDeclined
+		default:
+			attempt.Status = models.CardCaptured
+		}
+
+		if attempt.Status == models.CardCaptured {
+			payment.Method = models.PaymentCard
+			payment.TransactionId = result.TransactionId
+			payment.IdempotencyKey = key
+			payment.CreatedAt = attempt.CreatedAt
+
+			paid, added, err := ctl.addPayment(ctx, invoice, payment)
+			if err == nil && added {
+				if err := ctl.store.Invoices.AddCardTransaction(ctx, invoice.RestaurantId, invoice.InvoiceId, attempt); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the card transaction"})
+					return
+				}
+				paid.CardTransactions = append(paid.CardTransactions, attempt)
+				c.JSON(http.StatusCreated, paid)
+				return
+			}
+
+			// a request sent at the same time with the same key was given
+			// the same authorization and recorded this very capture
+			if err == nil {
+				current, getErr := ctl.store.Invoices.Get(ctx, invoice.RestaurantId, invoice.InvoiceId)
+				if getErr != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching invoice"})
+					return
+				}
+				if recorded, found := current.PaymentWithKey(key); found && recorded.TransactionId == result.TransactionId {
+					c.JSON(http.StatusOK, current)
+					return
+				}
+			}
+
+			// the payment could not be recorded, so the card is refunded
+			attempt.Status, attempt.Message = models.CardRefunded, "refunded: the payment could not be recorded"
+			refundCtx, cancelRefund := context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+			defer cancelRefund()
+			if _, err := ctl.gateway.Refund(refundCtx, result.TransactionId, attempt.Amount); err != nil {
+				attempt.Status, attempt.Message = models.CardFailed, "captured but neither recorded nor refunded: "+err.Error()
+			}
+		}
+
+		if err := ctl.store.Invoices.AddCardTransaction(ctx, invoice.RestaurantId, invoice.InvoiceId, attempt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the card transaction"})
+			return
+		}
+		cardFailure(c, attempt)
+	}
+}
+
+// replayCardPayment answers a request repeated with the idempotency key of
+// an earlier one like the earlier one was answered, and reports whether the
+// request is new.
+func replayCardPayment(c *gin.Context, invoice models.Invoice, key string) bool {
+	if _, paid := invoice.PaymentWithKey(key); paid {
+		c.JSON(http.StatusOK, invoice)
+		return false
+	}
+	for _, attempt := range invoice.CardTransactions {
+		if attempt.IdempotencyKey == key && attempt.Status == models.CardDeclined {
+			cardFailure(c, attempt)
+			return false
+		}
+	}
+
+	return true
+}
+
+// cardReference is the reference the card payment made with key is
+// authorized under. Authorizing a reference again gives back the same
+// authorization, which is what a retry after a timeout needs, unless that
+// authorization was already voided or refunded: the retry is then authorized
+// afresh.
+func cardReference(invoice models.Invoice, key string) string {
+	settled := 0
+	for _, attempt := range invoice.CardTransactions {
+		if attempt.IdempotencyKey == key && (attempt.Void == models.CardVoided || attempt.Status == models.CardRefunded) {
+			settled++
+		}
+	}
+
+	reference := invoice.InvoiceId + ":" + key
+	if settled > 0 {
+		reference += ":" + strconv.Itoa(settled)
+	}
+	return reference
+}
+
+// cardFailure answers a card payment attempt that did not go through.
+func cardFailure(c *gin.Context, attempt models.CardTransaction) {
+	switch {
+	case attempt.Status == models.CardDeclined:
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": "card was " + attempt.Message, "decline_code": attempt.DeclineCode})
+	case attempt.Status == models.CardRefunded:
+		c.JSON(http.StatusConflict, gin.H{"error": "the payment could not be recorded, the card has been refunded"})
+	case attempt.Message == helpers.ErrGatewayTimeout.Error():
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "payment gateway did not answer in time, the card was not charged"})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "payment gateway failed: " + attempt.Message})
+	}
+}
+
+// validPayment answers 400 itself when request does not say how much to pay.
+func validPayment(c *gin.Context, request InvoicePayment) bool {
+	if validationErr := validate.Struct(request); validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+		return false
+	}
+	if (request.Amount == nil) == (request.Split == models.SplitNone) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a payment needs either an amount or a split"})
+		return false
+	}
+
+	return true
+}
+
+// invoiceInPath fetches the invoice named in the path, answering the request
+// itself when it cannot.
+func (ctl *Controller) invoiceInPath(ctx context.Context, c *gin.Context) (models.Invoice, bool) {
+	invoice, err := ctl.store.Invoices.Get(ctx, helpers.GetTenant(c), c.Param("invoice_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invoice not found"})
+		return invoice, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching invoice"})
+		return invoice, false
+	}
+
+	return invoice, true
+}
+
+// payable returns invoice with its totals, answering the request itself when
+// it cannot be paid towards.
+func (ctl *Controller) payable(ctx context.Context, c *gin.Context, invoice models.Invoice) (models.Invoice, bool) {
+	if invoice.Totals == nil {
+		// invoices created before totals were stored are priced now
+		totals, err := ctl.OrderTotals(ctx, invoice.RestaurantId, invoice.OrderId)
+		set := false
+		if err == nil {
+			set, err = ctl.store.Invoices.SetTotals(ctx, invoice.RestaurantId, invoice.InvoiceId, totals)
+		}
+		if err == nil && set {
+			invoice.Totals = &totals
+		}
+		if err == nil && !set {
+			// another request priced it first
+			invoice, err = ctl.store.Invoices.Get(ctx, invoice.RestaurantId, invoice.InvoiceId)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while pricing the order"})
+			return invoice, false
+		}
+	}
+
+	if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided {
+		c.JSON(http.StatusConflict, gin.H{"error": "invoice has been voided"})
+		return invoice, false
+	}
+	if invoice.Balance() <= 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "invoice is already paid"})
+		return invoice, false
+	}
+
+	return invoice, true
+}
+
+// paymentFor works out the payment request makes towards invoice, answering
+// the request itself when it cannot.
+func (ctl *Controller) paymentFor(ctx context.Context, c *gin.Context, invoice models.Invoice, request InvoicePayment) (models.Payment, bool) {
+	balance := invoice.Balance()
+	payment := models.Payment{Method: request.Method, Split: request.Split, ReceivedBy: helpers.GetActor(c)}
+	if request.Tip != nil {
+		payment.Tip = *request.Tip
+	}
+
+	switch request.Split {
+	case models.SplitNone:
+		if *request.Amount > balance {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount exceeds the outstanding balance of " + balance.String()})
+			return payment, false
+		}
+		payment.Amount = *request.Amount
+	case models.SplitEven:
+		payment.Ways = request.Ways
+		payment.Amount = invoice.EvenShare(request.Ways)
+		if payment.Amount <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "every share of the even split has been paid"})
+			return payment, false
+		}
+	case models.SplitSeat, models.SplitItems:
+		orderItems, err := ctl.store.OrderItems.ListByOrder(ctx, invoice.RestaurantId, invoice.OrderId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+			return payment, false
+		}
+
+		payment.Seat = request.Seat
+		payment.OrderItemIds, err = itemsToPay(invoice, orderItems, request)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return payment, false
+		}
+		for _, orderItemId := range payment.OrderItemIds {
+			if slices.Contains(invoice.PaidItems(), orderItemId) {
+				c.JSON(http.StatusConflict, gin.H{"error": "order item " + orderItemId + " has already been paid for"})
+				return payment, false
+			}
+		}
+		payment.Amount = invoice.Totals.LinesDue(payment.OrderItemIds)
+	}
+	payment.Amount = min(payment.Amount, balance)
+
+	payment.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	payment.PaymentId = primitive.NewObjectID().Hex()
+
+	return payment, true
+}
+
+// addPayment records payment on invoice, unless the invoice was paid towards
+// since it was fetched, and returns the invoice with the payment.
+func (ctl *Controller) addPayment(ctx context.Context, invoice models.Invoice, payment models.Payment) (models.Invoice, bool, error) {
+	paid := invoice
+	paid.Payments = append(slices.Clone(invoice.Payments), payment)
+	status := paid.StatusAfterPayments()
+
+	added, err := ctl.store.Invoices.AddPayment(ctx, invoice.RestaurantId, invoice.InvoiceId, len(invoice.Payments), payment, status)
+	if err != nil || !added {
+		return invoice, false, err
+	}
+
+	paid.PaymentStatus = &status
+	paid.UpdatedAt = payment.CreatedAt
+	if status == models.InvoicePaid {
+		// the payment stands even if the order cannot be marked paid now
+		if err := ctl.settleOrder(ctx, paid, payment.ReceivedBy); err != nil {
+			log.Println("could not mark the order paid:", err)
+		}
+	}
+	return paid, true, nil
+}
+
+// itemsToPay lists the billed order items a seat or item split pays for.
+func itemsToPay(invoice models.Invoice, orderItems []models.OrderItem, request InvoicePayment) ([]string, error) {
+	billed := map[string]bool{}
+	for _, line := range invoice.Totals.Lines {
+		billed[line.OrderItemId] = true
+	}
+
+	if request.Split == models.SplitItems {
+		for _, orderItemId := range request.OrderItemIds {
+			if !billed[orderItemId] {
+				return nil, errors.New("order item " + orderItemId + " is not on the invoice")
+			}
+		}
+		return request.OrderItemIds, nil
+	}
+
+	atSeat := []string{}
+	for _, orderItem := range orderItems {
+		if billed[orderItem.OrderItemId] && orderItem.Seat != nil && *orderItem.Seat == request.Seat {
+			atSeat = append(atSeat, orderItem.OrderItemId)
+		}
+	}
+	if len(atSeat) == 0 {
+		return nil, fmt.Errorf("nothing was ordered at seat %d", request.Seat)
+	}
+
+	return atSeat, nil
+}
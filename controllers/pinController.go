@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SetPinRequest struct {
+	Pin string `json:"pin" validate:"required,numeric,min=4,max=6"`
+}
+
+type PinLoginRequest struct {
+	TerminalId  string `json:"terminal_id" validate:"required"`
+	TerminalKey string `json:"terminal_key" validate:"required"`
+	UserId      string `json:"user_id" validate:"required"`
+	Pin         string `json:"pin" validate:"required"`
+}
+
+// SetPin stores the quick-login PIN of a user. Staff set their own, managers
+// and admins can set anybody's.
+func (ctl *Controller) SetPin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		userId := c.Param("user_id")
+		if err := helpers.MatchUserRoleToUid(c, userId, models.RoleAdmin, models.RoleManager); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		var request SetPinRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		foundUser, err := ctl.store.Users.Get(ctx, userId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+			return
+		}
+		if err == repository.ErrNotFound || (userId != c.GetString("uid") && foundUser.RestaurantId != helpers.GetTenant(c)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		hashedPin := HashPin(request.Pin)
+		foundUser.Pin = &hashedPin
+		foundUser.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := ctl.store.Users.Update(ctx, foundUser); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating the pin"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "pin updated"})
+	}
+}
+
+// PinLogin signs a user in on a registered terminal with their PIN. Whoever
+// was active on the terminal before is signed out, so staff can switch users
+// without a full sign-out. PIN sessions are short-lived and cannot be refreshed.
+func (ctl *Controller) PinLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request PinLoginRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		terminal, err := ctl.store.Terminals.Get(ctx, request.TerminalId)
+		if err != nil || terminal.KeyHash != helpers.HashSecret(request.TerminalKey) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "terminal is not registered"})
+			return
+		}
+
+		userKey := "pin:" + request.UserId
+		terminalKey := "terminal:" + request.TerminalId
+		lockedUntil, err := helpers.LoginLockedUntil(ctx, ctl.store.LoginAttempts, userKey, terminalKey)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking sign-in attempts"})
+			return
+		}
+		if !lockedUntil.IsZero() {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed sign-in attempts, try again later"})
+			return
+		}
+
+		foundUser, err := ctl.store.Users.Get(ctx, request.UserId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+			return
+		}
+
+		if err != nil || foundUser.RestaurantId != terminal.RestaurantId || foundUser.Pin == nil || !VerifyPin(request.Pin, *foundUser.Pin) {
+			if recordErr := helpers.RecordLoginFailure(ctx, ctl.store.LoginAttempts, userKey, "", config.Get().LoginMaxFailures); recordErr != nil {
+				log.Println(recordErr)
+			}
+			if recordErr := helpers.RecordLoginFailure(ctx, ctl.store.LoginAttempts, terminalKey, "", config.Get().LoginIPMaxFailures); recordErr != nil {
+				log.Println(recordErr)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user or pin"})
+			return
+		}
+
+		if err := helpers.ClearLoginFailures(ctx, ctl.store.LoginAttempts, userKey); err != nil {
+			log.Println(err)
+		}
+
+		token, _, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserId, UserRole(foundUser), terminal.RestaurantId, terminal.TerminalId, foundUser.SessionEpoch)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while generating the token"})
+			return
+		}
+
+		claims, msg := helpers.ValidateToken(token)
+		if msg != "" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+			return
+		}
+
+		//End the previous user's session on this terminal before handing it over
+		if terminal.ActiveTokenId != "" && terminal.ActiveUntil != nil && terminal.ActiveUntil.After(time.Now()) {
+			if err := helpers.RevokeToken(ctx, ctl.store, terminal.ActiveTokenId, terminal.ActiveUserId, *terminal.ActiveUntil); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while switching users"})
+				return
+			}
+		}
+
+		activeUntil := time.Unix(claims.ExpiresAt, 0)
+		terminal.ActiveUserId = foundUser.UserId
+		terminal.ActiveTokenId = claims.Id
+		terminal.ActiveUntil = &activeUntil
+		terminal.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := ctl.store.Terminals.Update(ctx, terminal); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while updating the terminal"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"token":       token,
+			"expires_at":  activeUntil,
+			"user_id":     foundUser.UserId,
+			"first_name":  foundUser.FirstName,
+			"last_name":   foundUser.LastName,
+			"role":        UserRole(foundUser),
+			"terminal_id": terminal.TerminalId,
+		})
+	}
+}
+
+// HashPin uses a lower bcrypt cost than passwords so switching users on the
+// terminal stays instant; the lockout is what protects the short PIN space.
+func HashPin(pin string) string {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(pin), config.Get().PinBcryptCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return string(bytes)
+}
+
+func VerifyPin(providedPin string, storedPin string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(storedPin), []byte(providedPin)) == nil
+}
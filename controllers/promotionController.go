@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (ctl *Controller) GetPromotions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allPromotions, err := ctl.store.Promotions.List(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching promotions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allPromotions)
+	}
+}
+
+func (ctl *Controller) GetPromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		promotion, err := ctl.store.Promotions.Get(ctx, helpers.GetTenant(c), c.Param("promotion_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching promotion"})
+			return
+		}
+
+		c.JSON(http.StatusOK, promotion)
+	}
+}
+
+func (ctl *Controller) CreatePromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var promotion models.Promotion
+		if err := c.BindJSON(&promotion); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(promotion); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := promotion.Prepare(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		promotion.RestaurantId = helpers.GetTenant(c)
+		if !ctl.couponCodeFree(ctx, c, promotion) {
+			return
+		}
+
+		promotion.Uses = 0
+		promotion.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		promotion.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		promotion.ID = primitive.NewObjectID()
+		promotion.PromotionId = promotion.ID.Hex()
+
+		if err := ctl.store.Promotions.Create(ctx, promotion); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the promotion"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, promotion)
+	}
+}
+
+func (ctl *Controller) UpdatePromotion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var promotion models.Promotion
+		if err := c.BindJSON(&promotion); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		storedPromotion, err := ctl.store.Promotions.Get(ctx, helpers.GetTenant(c), c.Param("promotion_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "promotion not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching promotion"})
+			return
+		}
+
+		if promotion.Name != nil {
+			storedPromotion.Name = promotion.Name
+		}
+		if promotion.Type != nil {
+			storedPromotion.Type = promotion.Type
+		}
+		if promotion.Percent != 0 {
+			storedPromotion.Percent = promotion.Percent
+		}
+		if promotion.Amount != 0 {
+			storedPromotion.Amount = promotion.Amount
+		}
+		if promotion.BuyQuantity != 0 {
+			storedPromotion.BuyQuantity = promotion.BuyQuantity
+		}
+		if promotion.FreeQuantity != 0 {
+			storedPromotion.FreeQuantity = promotion.FreeQuantity
+		}
+		if promotion.FoodIds != nil {
+			storedPromotion.FoodIds = promotion.FoodIds
+		}
+		if promotion.MenuCategories != nil {
+			storedPromotion.MenuCategories = promotion.MenuCategories
+		}
+		if promotion.StartsAt != nil {
+			storedPromotion.StartsAt = promotion.StartsAt
+		}
+		if promotion.EndsAt != nil {
+			storedPromotion.EndsAt = promotion.EndsAt
+		}
+		if promotion.Days != nil {
+			storedPromotion.Days = promotion.Days
+		}
+		if promotion.StartTime != nil {
+			storedPromotion.StartTime = promotion.StartTime
+		}
+		if promotion.EndTime != nil {
+			storedPromotion.EndTime = promotion.EndTime
+		}
+		if promotion.Code != nil {
+			storedPromotion.Code = promotion.Code
+		}
+		if promotion.MaxUses != 0 {
+			storedPromotion.MaxUses = promotion.MaxUses
+		}
+		if promotion.Active != nil {
+			storedPromotion.Active = promotion.Active
+		}
+
+		if validationErr := validate.Struct(storedPromotion); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		if err := storedPromotion.Prepare(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if !ctl.couponCodeFree(ctx, c, storedPromotion) {
+			return
+		}
+
+		storedPromotion.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		if err := ctl.store.Promotions.Update(ctx, storedPromotion); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the promotion"})
+			return
+		}
+
+		c.JSON(http.StatusOK, storedPromotion)
+	}
+}
+
+// couponCodeFree answers 409 itself when another promotion of the restaurant
+// already uses the coupon code of promotion.
+func (ctl *Controller) couponCodeFree(ctx context.Context, c *gin.Context, promotion models.Promotion) bool {
+	if !promotion.IsCoupon() {
+		return true
+	}
+
+	existing, err := ctl.store.Promotions.GetByCode(ctx, promotion.RestaurantId, *promotion.Code)
+	if err == repository.ErrNotFound || err == nil && existing.PromotionId == promotion.PromotionId {
+		return true
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking the coupon code"})
+		return false
+	}
+
+	c.JSON(http.StatusConflict, gin.H{"error": "coupon code " + *promotion.Code + " is already taken"})
+	return false
+}
+
+type CouponRedemption struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// ApplyCoupon redeems a coupon code on an open order. Every redemption counts
+// towards the usage limit of the coupon until it is removed again.
+func (ctl *Controller) ApplyCoupon() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var redemption CouponRedemption
+		if err := c.BindJSON(&redemption); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(redemption); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		order, ok := ctl.openOrder(ctx, c)
+		if !ok {
+			return
+		}
+
+		code := models.NormalizeCouponCode(redemption.Code)
+		promotion, err := ctl.store.Promotions.GetByCode(ctx, order.RestaurantId, code)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon " + code + " does not exist"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the coupon"})
+			return
+		}
+
+		if slices.Contains(order.Coupons, code) {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon " + code + " is already applied to the order"})
+			return
+		}
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, order.RestaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+		if !promotion.RunsAt(time.Now(), restaurant.Location()) {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon " + code + " is not valid at this time"})
+			return
+		}
+
+		redeemed, err := ctl.store.Promotions.Redeem(ctx, order.RestaurantId, promotion.PromotionId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while redeeming the coupon"})
+			return
+		}
+		if !redeemed {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon " + code + " has been used up"})
+			return
+		}
+
+		order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		added, err := ctl.store.Orders.AddCoupon(ctx, order.RestaurantId, order.OrderId, code, order.UpdatedAt)
+		if err != nil || !added {
+			// the use redeemed above goes back to the coupon
+			ctl.store.Promotions.Release(ctx, order.RestaurantId, promotion.PromotionId)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Order update failed"})
+			return
+		}
+		if !added {
+			c.JSON(http.StatusConflict, gin.H{"error": "coupon " + code + " is already applied to the order"})
+			return
+		}
+
+		order.Coupons = append(order.Coupons, code)
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// RemoveCoupon takes a coupon code off an open order and gives its use back.
+func (ctl *Controller) RemoveCoupon() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		order, ok := ctl.openOrder(ctx, c)
+		if !ok {
+			return
+		}
+
+		code := models.NormalizeCouponCode(c.Param("code"))
+		index := slices.Index(order.Coupons, code)
+		if index < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon " + code + " is not applied to the order"})
+			return
+		}
+
+		order.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		removed, err := ctl.store.Orders.RemoveCoupon(ctx, order.RestaurantId, order.OrderId, code, order.UpdatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Order update failed"})
+			return
+		}
+		if !removed {
+			c.JSON(http.StatusNotFound, gin.H{"error": "coupon " + code + " is not applied to the order"})
+			return
+		}
+		order.Coupons = slices.Delete(order.Coupons, index, index+1)
+
+		promotion, err := ctl.store.Promotions.GetByCode(ctx, order.RestaurantId, code)
+		if err == nil {
+			err = ctl.store.Promotions.Release(ctx, order.RestaurantId, promotion.PromotionId)
+		}
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while releasing the coupon"})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// openOrder fetches the order named in the path, answering the request itself
+// when it does not exist or can no longer be changed.
+func (ctl *Controller) openOrder(ctx context.Context, c *gin.Context) (models.Order, bool) {
+	order, err := ctl.store.Orders.Get(ctx, helpers.GetTenant(c), c.Param("order_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return order, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order"})
+		return order, false
+	}
+	if !ctl.orderEditable(ctx, c, order) {
+		return order, false
+	}
+
+	return order, true
+}
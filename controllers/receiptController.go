@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvoiceReceipt renders the receipt of an invoice with the layouts of the
+// restaurant, as HTML or, for format=pdf or clients that only accept PDF, as
+// a PDF to print.
+func (ctl *Controller) GetInvoiceReceipt() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		format := c.Query("format")
+		if format == "" {
+			format = "html"
+			if accept := c.GetHeader("Accept"); strings.Contains(accept, "application/pdf") && !strings.Contains(accept, "text/html") {
+				format = "pdf"
+			}
+		}
+		if format != "html" && format != "pdf" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "receipts are rendered as html or pdf"})
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, invoice.RestaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+
+		receipt, err := ctl.receiptOf(ctx, restaurant, invoice)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching the invoiced order"})
+			return
+		}
+
+		htmlLayout, textLayout := helpers.ReceiptTemplates(restaurant.Receipt)
+		if format == "pdf" {
+			document, err := helpers.RenderReceiptPDF(textLayout, receipt)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while rendering the receipt: " + err.Error()})
+				return
+			}
+			c.Header("Content-Disposition", `inline; filename="`+receiptFileName(invoice)+`.pdf"`)
+			c.Data(http.StatusOK, "application/pdf", document)
+			return
+		}
+
+		page, err := helpers.RenderReceiptHTML(htmlLayout, receipt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while rendering the receipt: " + err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+// receiptOf lays invoice out for the guest. Lines are listed as they were
+// priced when the invoice was issued.
+func (ctl *Controller) receiptOf(ctx context.Context, restaurant models.Restaurant, invoice models.Invoice) (models.Receipt, error) {
+	receipt := models.Receipt{
+		RestaurantName:    models.StringOf(restaurant.Name),
+		RestaurantAddress: models.StringOf(restaurant.Address),
+		RestaurantPhone:   models.StringOf(restaurant.Phone),
+		InvoiceNumber:     invoice.InvoiceNumber,
+		IssuedAt:          invoice.CreatedAt.In(restaurant.Location()),
+		Currency:          restaurant.CurrencyCode(),
+		Lines:             []models.ReceiptLine{},
+		Discounts:         []models.AppliedDiscount{},
+		Taxes:             []models.TaxTotals{},
+		Payments:          []models.Payment{},
+		Credited:          invoice.Credited,
+		Paid:              invoice.AmountPaid(),
+		Tips:              invoice.Tips(),
+		Balance:           invoice.Balance(),
+		Voided:            invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided,
+	}
+	if receipt.InvoiceNumber == "" {
+		receipt.InvoiceNumber = invoice.InvoiceId
+	}
+	if invoice.Payments != nil {
+		receipt.Payments = invoice.Payments
+	}
+	receipt.TipLine = !receipt.Voided && receipt.Balance > 0
+	if restaurant.Receipt != nil {
+		receipt.Footer = restaurant.Receipt.Footer
+	}
+
+	orderView, err := ctl.ItemsByOrder(ctx, invoice.RestaurantId, invoice.OrderId)
+	if err != nil && err != repository.ErrNotFound {
+		return receipt, err
+	}
+	if orderView.TableNumber != nil {
+		receipt.TableNumber = *orderView.TableNumber
+	}
+	itemsById := map[string]OrderItemView{}
+	for _, item := range orderView.OrderItems {
+		itemsById[item.OrderItemId] = item
+	}
+
+	totals := invoice.Totals
+	if totals == nil {
+		// invoices created before totals were stored are priced now
+		priced, err := ctl.OrderTotals(ctx, invoice.RestaurantId, invoice.OrderId)
+		if err != nil {
+			return receipt, err
+		}
+		totals = &priced
+		receipt.Balance = priced.Total - invoice.Credited - receipt.Paid
+	}
+
+	for _, line := range totals.Lines {
+		item := itemsById[line.OrderItemId]
+		name := models.StringOf(item.FoodName)
+		if name == "" {
+			name = "Item"
+		}
+		receipt.Lines = append(receipt.Lines, models.ReceiptLine{Name: name, Size: models.StringOf(item.Size), Quantity: line.Quantity, Amount: line.Gross})
+	}
+	if totals.Currency != "" {
+		receipt.Currency = totals.Currency
+	}
+	if totals.Discounts != nil {
+		receipt.Discounts = totals.Discounts
+	}
+	if totals.Taxes != nil {
+		receipt.Taxes = totals.Taxes
+	}
+	receipt.Subtotal = totals.Subtotal
+	receipt.ServiceChargeRate, receipt.ServiceCharge = totals.ServiceChargeRate, totals.ServiceCharge
+	receipt.GratuityRate, receipt.Gratuity = totals.GratuityRate, totals.Gratuity
+	receipt.Tax, receipt.Rounding, receipt.Total = totals.Tax, totals.Rounding, totals.Total
+
+	return receipt, nil
+}
+
+func receiptFileName(invoice models.Invoice) string {
+	if invoice.InvoiceNumber != "" {
+		return invoice.InvoiceNumber
+	}
+	return invoice.InvoiceId
+}
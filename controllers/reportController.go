@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocationReport sums up a restaurant. NetSales is what it sold once voided
+// invoices and refunds are taken off; voided items never count as sold.
+type LocationReport struct {
+	RestaurantId    string       `json:"restaurant_id"`
+	Name            string       `json:"name"`
+	Currency        string       `json:"currency"`
+	OrderCount      int          `json:"order_count"`
+	ItemCount       int          `json:"item_count"`
+	Sales           models.Money `json:"sales"`
+	VoidedItems     int          `json:"voided_items"`
+	ItemVoids       models.Money `json:"item_voids"`
+	PaidInvoices    int          `json:"paid_invoices"`
+	PendingInvoices int          `json:"pending_invoices"`
+	VoidedInvoices  int          `json:"voided_invoices"`
+	InvoiceVoids    models.Money `json:"invoice_voids"`
+	Refunds         models.Money `json:"refunds"`
+	NetSales        models.Money `json:"net_sales"`
+}
+
+// GetLocationsReport compares every restaurant of the group side by side. The
+// optional from/to query parameters (RFC3339) restrict it to a period.
+func (ctl *Controller) GetLocationsReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var period repository.Period
+		if from, err := time.Parse(time.RFC3339, c.Query("from")); err == nil {
+			period.From = from
+		}
+		if to, err := time.Parse(time.RFC3339, c.Query("to")); err == nil {
+			period.To = to
+		}
+
+		allRestaurants, err := ctl.store.Restaurants.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the restaurants"})
+			return
+		}
+
+		totals, err := ctl.store.Reports.LocationTotals(ctx, period)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while totalling the locations"})
+			return
+		}
+		totalsByRestaurant := map[string]repository.LocationTotals{}
+		for _, total := range totals {
+			totalsByRestaurant[total.RestaurantId] = total
+		}
+
+		allReports := []LocationReport{}
+		for _, restaurant := range allRestaurants {
+			total := totalsByRestaurant[restaurant.RestaurantId]
+			allReports = append(allReports, LocationReport{
+				RestaurantId:    restaurant.RestaurantId,
+				Name:            *restaurant.Name,
+				Currency:        restaurant.CurrencyCode(),
+				OrderCount:      total.OrderCount,
+				ItemCount:       total.ItemCount,
+				Sales:           total.Sales,
+				VoidedItems:     total.VoidedItems,
+				ItemVoids:       total.ItemVoids,
+				PaidInvoices:    total.PaidInvoices,
+				PendingInvoices: total.PendingInvoices,
+				VoidedInvoices:  total.VoidedInvoices,
+				InvoiceVoids:    total.InvoiceVoids,
+				Refunds:         total.Refunds,
+				NetSales:        total.Sales - total.InvoiceVoids - total.Refunds,
+			})
+		}
+
+		c.JSON(http.StatusOK, allReports)
+	}
+}
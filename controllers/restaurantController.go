@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetRestaurants lists every location for owners and only their own for
+// everybody else.
+func (ctl *Controller) GetRestaurants() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		if c.GetString("role") != models.RoleOwner {
+			restaurant, err := ctl.store.Restaurants.Get(ctx, helpers.GetTenant(c))
+			if err == repository.ErrNotFound {
+				c.JSON(http.StatusOK, []models.Restaurant{})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurants"})
+				return
+			}
+
+			c.JSON(http.StatusOK, []models.Restaurant{restaurant})
+			return
+		}
+
+		allRestaurants, err := ctl.store.Restaurants.List(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurants"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allRestaurants)
+	}
+}
+
+func (ctl *Controller) GetRestaurant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		restaurantId := c.Param("restaurant_id")
+		if c.GetString("role") != models.RoleOwner && restaurantId != helpers.GetTenant(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you are not authorized to access this resource"})
+			return
+		}
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, restaurantId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "restaurant not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+
+		c.JSON(http.StatusOK, restaurant)
+	}
+}
+
+func (ctl *Controller) CreateRestaurant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var restaurant models.Restaurant
+		if err := c.BindJSON(&restaurant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(restaurant); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		restaurant.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		restaurant.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		restaurant.ID = primitive.NewObjectID()
+		restaurant.RestaurantId = restaurant.ID.Hex()
+
+		if err := ctl.store.Restaurants.Create(ctx, restaurant); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the restaurant"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, restaurant)
+	}
+}
+
+func (ctl *Controller) UpdateRestaurant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var restaurant models.Restaurant
+		if err := c.BindJSON(&restaurant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		storedRestaurant, err := ctl.store.Restaurants.Get(ctx, c.Param("restaurant_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "restaurant not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+
+		if restaurant.Name != nil {
+			storedRestaurant.Name = restaurant.Name
+		}
+		if restaurant.Address != nil {
+			storedRestaurant.Address = restaurant.Address
+		}
+		if restaurant.Phone != nil {
+			storedRestaurant.Phone = restaurant.Phone
+		}
+		if restaurant.Currency != "" {
+			storedRestaurant.Currency = restaurant.Currency
+		}
+		if restaurant.TimeZone != "" {
+			storedRestaurant.TimeZone = restaurant.TimeZone
+		}
+		if restaurant.Pricing != nil {
+			storedRestaurant.Pricing = restaurant.Pricing
+		}
+		if restaurant.ReversalApprovalLimit != 0 {
+			storedRestaurant.ReversalApprovalLimit = restaurant.ReversalApprovalLimit
+		}
+		if restaurant.TipOutRates != nil {
+			storedRestaurant.TipOutRates = restaurant.TipOutRates
+		}
+		if restaurant.InvoicePrefix != "" {
+			storedRestaurant.InvoicePrefix = restaurant.InvoicePrefix
+		}
+		if restaurant.CreditNotePrefix != "" {
+			storedRestaurant.CreditNotePrefix = restaurant.CreditNotePrefix
+		}
+		if restaurant.FiscalYearStartMonth != 0 {
+			storedRestaurant.FiscalYearStartMonth = restaurant.FiscalYearStartMonth
+		}
+		if restaurant.Receipt != nil {
+			storedRestaurant.Receipt = restaurant.Receipt
+		}
+
+		if validationErr := validate.Struct(storedRestaurant); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+		tipOut := 0.0
+		for _, rate := range storedRestaurant.TipOutRates {
+			tipOut += rate
+		}
+		if tipOut > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "servers cannot tip out more than 100% of their tips"})
+			return
+		}
+		if err := helpers.CheckReceiptTemplates(storedRestaurant.Receipt); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		storedRestaurant.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+		if err := ctl.store.Restaurants.Update(ctx, storedRestaurant); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the restaurant"})
+			return
+		}
+
+		c.JSON(http.StatusOK, storedRestaurant)
+	}
+}
@@ -0,0 +1,521 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errReversalConflict is wrapped by the reasons a reversal can no longer be
+// carried out, which are answered with 409.
+var errReversalConflict = errors.New("cannot be reversed")
+
+// InvoiceRefund is a refund of a payment taken on an invoice. PaymentId can
+// be left out when the invoice has a single payment, and Amount to refund
+// whatever is left of the payment.
+type InvoiceRefund struct {
+	models.ReversalReason
+	PaymentId string        `json:"payment_id"`
+	Amount    *models.Money `json:"amount" validate:"omitempty,gt=0"`
+}
+
+// VoidOrderItem takes an order item off the bill, whatever the kitchen has
+// done with it, as long as the order has not been invoiced yet.
+func (ctl *Controller) VoidOrderItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var reason models.ReversalReason
+		if !bindReversal(c, &reason) {
+			return
+		}
+
+		orderItem, err := ctl.store.OrderItems.Get(ctx, helpers.GetTenant(c), c.Param("order_item_id"))
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "order item not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching order items"})
+			return
+		}
+		if err := ctl.voidableItem(ctx, orderItem); err != nil {
+			reversalFailure(c, err)
+			return
+		}
+
+		ctl.requestReversal(ctx, c, models.Reversal{
+			Type:           models.ReversalVoidItem,
+			ReversalReason: reason,
+			Amount:         orderItem.LineTotal(),
+			OrderId:        orderItem.OrderId,
+			OrderItemId:    orderItem.OrderItemId,
+		})
+	}
+}
+
+// VoidInvoice cancels an invoice nothing is paid on anymore. The invoice is
+// kept, as VOIDED.
+func (ctl *Controller) VoidInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var reason models.ReversalReason
+		if !bindReversal(c, &reason) {
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+		if err := ctl.voidableInvoice(ctx, invoice); err != nil {
+			reversalFailure(c, err)
+			return
+		}
+
+		ctl.requestReversal(ctx, c, models.Reversal{
+			Type:           models.ReversalVoidInvoice,
+			ReversalReason: reason,
+			Amount:         invoice.AmountDue(),
+			OrderId:        invoice.OrderId,
+			InvoiceId:      invoice.InvoiceId,
+		})
+	}
+}
+
+// RefundInvoice gives back a payment taken on an invoice, in full or in
+// part. Card payments are refunded through the payment gateway; cash refunds
+// are handed over at the till and only recorded.
+func (ctl *Controller) RefundInvoice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request InvoiceRefund
+		if !bindReversal(c, &request) {
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+		payment, ok := paymentToRefund(c, invoice, request.PaymentId)
+		if !ok {
+			return
+		}
+
+		reversals, err := ctl.store.Reversals.ListByInvoice(ctx, invoice.RestaurantId, invoice.InvoiceId)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the refunds"})
+			return
+		}
+		refundable := models.Refundable(payment, reversals)
+		if refundable <= 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "payment has already been refunded in full"})
+			return
+		}
+		amount := refundable
+		if request.Amount != nil {
+			amount = *request.Amount
+		}
+		if amount > refundable {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount exceeds the " + refundable.String() + " left to refund on the payment"})
+			return
+		}
+
+		reserved, err := ctl.store.Invoices.ReserveRefund(ctx, invoice.RestaurantId, invoice.InvoiceId, payment.PaymentId, payment.Refunded, amount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while reserving the refund"})
+			return
+		}
+		if !reserved {
+			c.JSON(http.StatusConflict, gin.H{"error": "payment was refunded meanwhile, please reload it"})
+			return
+		}
+
+		reversal := models.Reversal{
+			Type:           models.ReversalRefund,
+			ReversalReason: request.ReversalReason,
+			Amount:         amount,
+			OrderId:        invoice.OrderId,
+			InvoiceId:      invoice.InvoiceId,
+			PaymentId:      payment.PaymentId,
+			Method:         payment.Method,
+			TransactionId:  payment.TransactionId,
+		}
+		if !ctl.requestReversal(ctx, c, reversal) {
+			ctl.releaseRefund(ctx, reversal)
+		}
+	}
+}
+
+// GetReversals lists the voids and refunds of the restaurant, only those in
+// the status given in the query when there is one.
+func (ctl *Controller) GetReversals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allReversals, err := ctl.store.Reversals.List(ctx, helpers.GetTenant(c), c.Query("status"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the reversals"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allReversals)
+	}
+}
+
+func (ctl *Controller) GetReversal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		reversal, ok := ctl.reversalInPath(ctx, c)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, reversal)
+	}
+}
+
+// ApproveReversal carries out a void or refund that was waiting for a
+// manager. A refund the gateway fails is kept as FAILED.
+func (ctl *Controller) ApproveReversal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		reversal, ok := ctl.decideReversal(ctx, c, models.ReversalCompleted)
+		if !ok {
+			return
+		}
+
+		if err := ctl.performReversal(ctx, &reversal); err != nil {
+			ctl.releaseRefund(ctx, reversal)
+			reversal.Status, reversal.Message = models.ReversalFailed, err.Error()
+			if err := ctl.store.Reversals.Update(ctx, reversal); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the reversal"})
+				return
+			}
+			reversalFailure(c, err)
+			return
+		}
+		if err := ctl.store.Reversals.Update(ctx, reversal); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the reversal"})
+			return
+		}
+
+		c.JSON(http.StatusOK, reversal)
+	}
+}
+
+// RejectReversal turns down a void or refund that was waiting for a manager.
+func (ctl *Controller) RejectReversal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		reversal, ok := ctl.decideReversal(ctx, c, models.ReversalRejected)
+		if !ok {
+			return
+		}
+		ctl.releaseRefund(ctx, reversal)
+
+		c.JSON(http.StatusOK, reversal)
+	}
+}
+
+// bindReversal binds and validates the body of a void or refund request,
+// answering 400 itself when it is not valid.
+func bindReversal(c *gin.Context, request interface{}) bool {
+	if err := c.BindJSON(request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if validationErr := validate.Struct(request); validationErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+		return false
+	}
+
+	return true
+}
+
+// paymentToRefund finds the payment a refund is for, answering the request
+// itself when it cannot.
+func paymentToRefund(c *gin.Context, invoice models.Invoice, paymentId string) (models.Payment, bool) {
+	if len(invoice.Payments) == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "nothing has been paid on the invoice"})
+		return models.Payment{}, false
+	}
+	if paymentId == "" {
+		if len(invoice.Payments) > 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "the invoice has several payments, say which one to refund with payment_id"})
+			return models.Payment{}, false
+		}
+		return invoice.Payments[0], true
+	}
+
+	for _, payment := range invoice.Payments {
+		if payment.PaymentId == paymentId {
+			return payment, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "payment not found on the invoice"})
+	return models.Payment{}, false
+}
+
+// requestReversal records reversal and carries it out, or leaves it waiting
+// for a manager when it goes over the approval limit of the restaurant and
+// was not asked for by one. It answers the request itself, and reports
+// whether the reversal was carried out or is waiting.
+func (ctl *Controller) requestReversal(ctx context.Context, c *gin.Context, reversal models.Reversal) bool {
+	restaurant, err := ctl.store.Restaurants.Get(ctx, helpers.GetTenant(c))
+	if err != nil && err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+		return false
+	}
+
+	reversal.ID = primitive.NewObjectID()
+	reversal.ReversalId = reversal.ID.Hex()
+	reversal.RestaurantId = helpers.GetTenant(c)
+	reversal.RequestedBy = helpers.GetActor(c)
+	reversal.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	reversal.UpdatedAt = reversal.CreatedAt
+
+	manager := helpers.CheckUserRole(c, models.RoleAdmin, models.RoleManager) == nil
+	if reversal.Amount > restaurant.ReversalApprovalLimit && !manager {
+		reversal.Status = models.ReversalPending
+		if err := ctl.store.Reversals.Create(ctx, reversal); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the reversal"})
+			return false
+		}
+		c.JSON(http.StatusAccepted, reversal)
+		return true
+	}
+
+	reversal.Status = models.ReversalCompleted
+	reversal.DecidedBy, reversal.DecidedAt = reversal.RequestedBy, reversal.CreatedAt
+	performErr := ctl.performReversal(ctx, &reversal)
+	if errors.Is(performErr, errReversalConflict) {
+		reversalFailure(c, performErr)
+		return false
+	}
+	if performErr != nil {
+		// the gateway failed, which is kept on record like the refund itself
+		reversal.Status, reversal.Message = models.ReversalFailed, performErr.Error()
+	}
+
+	if err := ctl.store.Reversals.Create(ctx, reversal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the reversal"})
+		return performErr == nil
+	}
+	if performErr != nil {
+		reversalFailure(c, performErr)
+		return false
+	}
+
+	c.JSON(http.StatusCreated, reversal)
+	return true
+}
+
+// releaseRefund gives back what was reserved on the payment for reversal,
+// when it is a refund that will not be given.
+func (ctl *Controller) releaseRefund(ctx context.Context, reversal models.Reversal) {
+	if reversal.Type != models.ReversalRefund {
+		return
+	}
+	if err := ctl.store.Invoices.ReleaseRefund(ctx, reversal.RestaurantId, reversal.InvoiceId, reversal.PaymentId, reversal.Amount); err != nil {
+		log.Println("could not release the refund "+reversal.ReversalId+":", err)
+	}
+}
+
+// performReversal voids or refunds what reversal is for, checking again that
+// it still can be.
+func (ctl *Controller) performReversal(ctx context.Context, reversal *models.Reversal) error {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	switch reversal.Type {
+	case models.ReversalVoidItem:
+		orderItem, err := ctl.store.OrderItems.Get(ctx, reversal.RestaurantId, reversal.OrderItemId)
+		if err != nil {
+			return err
+		}
+		if err := ctl.voidableItem(ctx, orderItem); err != nil {
+			return err
+		}
+
+		voided, err := ctl.store.OrderItems.SetStatus(ctx, orderItem.RestaurantId, orderItem.OrderItemId, orderItem.CurrentStatus(), models.OrderItemVoided, now)
+		if err != nil {
+			return err
+		}
+		if !voided {
+			return fmt.Errorf("order item %w: its status changed meanwhile, please reload it", errReversalConflict)
+		}
+
+		orderItem.Status = models.OrderItemVoided
+		orderItem.StatusUpdatedAt = now
+		orderItem.UpdatedAt = now
+		ctl.publishKitchenEvent(ctx, models.KitchenItemVoided, orderItem)
+
+	case models.ReversalVoidInvoice:
+		invoice, err := ctl.store.Invoices.Get(ctx, reversal.RestaurantId, reversal.InvoiceId)
+		if err != nil {
+			return err
+		}
+		if err := ctl.voidableInvoice(ctx, invoice); err != nil {
+			return err
+		}
+
+		voided, err := ctl.store.Invoices.Void(ctx, invoice.RestaurantId, invoice.InvoiceId, len(invoice.Payments), invoice.Credited, now)
+		if err != nil {
+			return err
+		}
+		if !voided {
+			return fmt.Errorf("invoice %w: it was paid, credited or voided meanwhile, please reload it", errReversalConflict)
+		}
+		status := models.InvoiceVoided
+		invoice.PaymentStatus = &status
+		invoice.UpdatedAt = now
+
+		// the invoice keeps its number, the credit note takes it off the books
+		if invoice.InvoiceNumber != "" && invoice.Owed() > 0 {
+			reason := "invoice voided: " + reversal.ReasonCode
+			if reversal.Note != "" {
+				reason += ", " + reversal.Note
+			}
+			if _, err := ctl.issueCreditNote(ctx, invoice, invoice.Owed(), reason, reversal.RequestedBy); err != nil {
+				return err
+			}
+		}
+
+	case models.ReversalRefund:
+		if reversal.Method == models.PaymentCard {
+			result, err := ctl.gateway.Refund(ctx, reversal.TransactionId, reversal.Amount)
+			if err != nil {
+				return err
+			}
+			reversal.Message = result.Message
+		}
+	}
+
+	reversal.UpdatedAt = now
+	return nil
+}
+
+// voidableItem returns why orderItem cannot be voided, if it cannot.
+func (ctl *Controller) voidableItem(ctx context.Context, orderItem models.OrderItem) error {
+	if orderItem.CurrentStatus() == models.OrderItemVoided {
+		return fmt.Errorf("order item %w: it is already voided", errReversalConflict)
+	}
+
+	order, err := ctl.store.Orders.Get(ctx, orderItem.RestaurantId, orderItem.OrderId)
+	if err != nil && err != repository.ErrNotFound {
+		return err
+	}
+	if err == nil && order.Closed() {
+		return fmt.Errorf("order item %w: the order is %s, refund its invoice instead", errReversalConflict, order.CurrentStatus())
+	}
+
+	_, err = ctl.store.Invoices.GetByOrder(ctx, orderItem.RestaurantId, orderItem.OrderId)
+	if err == nil {
+		return fmt.Errorf("order item %w: the order has been invoiced, void or refund the invoice instead", errReversalConflict)
+	}
+	if err != repository.ErrNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// voidableInvoice returns why invoice cannot be voided, if it cannot.
+func (ctl *Controller) voidableInvoice(ctx context.Context, invoice models.Invoice) error {
+	if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided {
+		return fmt.Errorf("invoice %w: it is already voided", errReversalConflict)
+	}
+
+	reversals, err := ctl.store.Reversals.ListByInvoice(ctx, invoice.RestaurantId, invoice.InvoiceId)
+	if err != nil {
+		return err
+	}
+	if invoice.AmountPaid() > models.Refunded(reversals, "") {
+		return fmt.Errorf("invoice %w: refund its payments before voiding it", errReversalConflict)
+	}
+
+	return nil
+}
+
+// reversalInPath fetches the reversal named in the path, answering the
+// request itself when it cannot.
+func (ctl *Controller) reversalInPath(ctx context.Context, c *gin.Context) (models.Reversal, bool) {
+	reversal, err := ctl.store.Reversals.Get(ctx, helpers.GetTenant(c), c.Param("reversal_id"))
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reversal not found"})
+		return reversal, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the reversal"})
+		return reversal, false
+	}
+
+	return reversal, true
+}
+
+// decideReversal moves the reversal named in the path from waiting for
+// approval to status, answering the request itself when it cannot.
+func (ctl *Controller) decideReversal(ctx context.Context, c *gin.Context, status string) (models.Reversal, bool) {
+	reversal, ok := ctl.reversalInPath(ctx, c)
+	if !ok {
+		return reversal, false
+	}
+	if reversal.Status != models.ReversalPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "reversal is " + reversal.Status + " and no longer waits for approval"})
+		return reversal, false
+	}
+
+	decidedBy := helpers.GetActor(c)
+	at, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	decided, err := ctl.store.Reversals.Decide(ctx, reversal.RestaurantId, reversal.ReversalId, status, decidedBy, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while deciding on the reversal"})
+		return reversal, false
+	}
+	if !decided {
+		c.JSON(http.StatusConflict, gin.H{"error": "reversal was decided on meanwhile, please reload it"})
+		return reversal, false
+	}
+
+	reversal.Status, reversal.DecidedBy, reversal.DecidedAt, reversal.UpdatedAt = status, decidedBy, at, at
+	return reversal, true
+}
+
+// reversalFailure answers a void or refund that could not be carried out.
+func reversalFailure(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errReversalConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, helpers.ErrGatewayTimeout):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "payment gateway did not answer in time, the card was not refunded"})
+	case errors.Is(err, helpers.ErrUnknownTransaction), errors.Is(err, helpers.ErrTransactionState), errors.Is(err, helpers.ErrAmountExceedsCapture):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "payment gateway refused the refund: " + err.Error()})
+	case err == repository.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "what the reversal is for no longer exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while carrying out the reversal"})
+	}
+}
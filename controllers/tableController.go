@@ -2,33 +2,23 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var tableCollection *mongo.Collection = database.OpenCollection(database.Client, "tables")
-
-func GetTables() gin.HandlerFunc {
+func (ctl *Controller) GetTables() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
-		result, err := tableCollection.Find(context.TODO(), bson.M{})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while finding tables"})
-			return
-		}
-		var allTables []bson.M
-		err = result.All(ctx, &allTables)
+		allTables, err := ctl.store.Tables.List(ctx, helpers.GetTenant(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching tables"})
 			return
@@ -38,15 +28,18 @@ func GetTables() gin.HandlerFunc {
 	}
 }
 
-func GetTable() gin.HandlerFunc {
+func (ctl *Controller) GetTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		tableId := c.Param("table_id")
-		var table models.Table
 
-		err := tableCollection.FindOne(ctx, bson.M{"table_id": tableId}).Decode(&table)
+		table, err := ctl.store.Tables.Get(ctx, helpers.GetTenant(c), tableId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching table"})
 			return
@@ -56,9 +49,9 @@ func GetTable() gin.HandlerFunc {
 	}
 }
 
-func CreateTable() gin.HandlerFunc {
+func (ctl *Controller) CreateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var table models.Table
 
 		defer cancel()
@@ -79,21 +72,20 @@ func CreateTable() gin.HandlerFunc {
 
 		table.ID = primitive.NewObjectID()
 		table.TableId = table.ID.Hex()
+		table.RestaurantId = helpers.GetTenant(c)
 
-		resultTable, err := tableCollection.InsertOne(ctx, table)
-		if err != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the table %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := ctl.store.Tables.Create(ctx, table); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the table"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, resultTable)
+		c.JSON(http.StatusCreated, table)
 	}
 }
 
-func UpdateTable() gin.HandlerFunc {
+func (ctl *Controller) UpdateTable() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		var table models.Table
 		defer cancel()
 
@@ -103,38 +95,29 @@ func UpdateTable() gin.HandlerFunc {
 			return
 		}
 
-		var updatedObj primitive.D
+		storedTable, err := ctl.store.Tables.Get(ctx, helpers.GetTenant(c), tableId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "table not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching table"})
+			return
+		}
 
 		if table.NumberOfGuests != nil {
-			updatedObj = append(updatedObj, bson.E{Key: "number_of_guests", Value: table.NumberOfGuests})
+			storedTable.NumberOfGuests = table.NumberOfGuests
 		}
 		if table.TableNumber != nil {
-			updatedObj = append(updatedObj, bson.E{Key: "table_number", Value: table.TableNumber})
+			storedTable.TableNumber = table.TableNumber
 		}
 
-		table.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-		updatedObj = append(updatedObj, bson.E{Key: "updated_at", Value: table.UpdatedAt})
+		storedTable.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 
-		upsert := true
-		opt := options.UpdateOptions{
-			Upsert: &upsert,
-		}
-		filter := bson.M{"table_id": tableId}
-
-		result, err := tableCollection.UpdateOne(
-			ctx,
-			filter,
-			bson.D{
-				{"$set", updatedObj},
-			},
-			&opt,
-		)
-
-		if err != nil {
-			msg := fmt.Sprintf("error ocurred while updating the table %s", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := ctl.store.Tables.Update(ctx, storedTable); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the table"})
 			return
 		}
-		c.JSON(http.StatusOK, result)
+		c.JSON(http.StatusOK, storedTable)
 	}
 }
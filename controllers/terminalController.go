@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (ctl *Controller) GetTerminals() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allTerminals, err := ctl.store.Terminals.List(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching terminals"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allTerminals)
+	}
+}
+
+// CreateTerminal registers a shared POS terminal. The terminal key is only
+// returned once; the terminal sends it with every PIN login.
+func (ctl *Controller) CreateTerminal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var terminal models.Terminal
+		if err := c.BindJSON(&terminal); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(terminal); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		terminalKey := helpers.NewSecret()
+		terminal.KeyHash = helpers.HashSecret(terminalKey)
+		terminal.ActiveUserId = ""
+		terminal.ActiveTokenId = ""
+		terminal.ActiveUntil = nil
+		terminal.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		terminal.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		terminal.ID = primitive.NewObjectID()
+		terminal.TerminalId = terminal.ID.Hex()
+		terminal.RestaurantId = helpers.GetTenant(c)
+
+		if err := ctl.store.Terminals.Create(ctx, terminal); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the terminal"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"terminal": terminal, "terminal_key": terminalKey})
+	}
+}
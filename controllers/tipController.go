@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PaymentTip struct {
+	Tip *models.Money `json:"tip" validate:"required,min=0"`
+}
+
+// ServerTips is what one server was tipped during a shift and what they hand
+// on to the tip pools. Orders taken before servers were recorded are listed
+// under an empty ServerId.
+type ServerTips struct {
+	ServerId string       `json:"server_id"`
+	Name     string       `json:"name"`
+	CardTips models.Money `json:"card_tips"`
+	CashTips models.Money `json:"cash_tips"`
+	Gratuity models.Money `json:"gratuity"`
+	Tips     models.Money `json:"tips"`
+	TipOut   models.Money `json:"tip_out"`
+	Net      models.Money `json:"net"`
+}
+
+// TipPool is what the servers tipped out to the staff of a role.
+type TipPool struct {
+	Role   string       `json:"role"`
+	Rate   float64      `json:"rate"`
+	Amount models.Money `json:"amount"`
+}
+
+type ShiftTips struct {
+	Shift    models.Shift `json:"shift"`
+	Currency string       `json:"currency"`
+	Servers  []ServerTips `json:"servers"`
+	Pools    []TipPool    `json:"pools"`
+	Tips     models.Money `json:"tips"`
+	TipOut   models.Money `json:"tip_out"`
+}
+
+// SetPaymentTip changes the tip of a payment, as when the guest writes it on
+// the card slip after paying. Card payments are adjusted at the gateway. Tips
+// can only change until the shift the payment was taken in is closed.
+func (ctl *Controller) SetPaymentTip() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request PaymentTip
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		invoice, ok := ctl.invoiceInPath(ctx, c)
+		if !ok {
+			return
+		}
+		if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided {
+			c.JSON(http.StatusConflict, gin.H{"error": "invoice has been voided"})
+			return
+		}
+		index := -1
+		for i, payment := range invoice.Payments {
+			if payment.PaymentId == c.Param("payment_id") {
+				index = i
+			}
+		}
+		if index < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found on the invoice"})
+			return
+		}
+		payment := invoice.Payments[index]
+
+		lastShift, err := ctl.store.Shifts.Last(ctx, invoice.RestaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the shift"})
+			return
+		}
+		if err == nil && payment.CreatedAt.Before(lastShift.EndedAt) {
+			c.JSON(http.StatusConflict, gin.H{"error": "the shift the payment was taken in has been closed, its tips can no longer change"})
+			return
+		}
+
+		if payment.Method == models.PaymentCard {
+			if _, err := ctl.gateway.Adjust(ctx, payment.TransactionId, payment.Amount+*request.Tip); err != nil {
+				if errors.Is(err, helpers.ErrGatewayTimeout) {
+					c.JSON(http.StatusGatewayTimeout, gin.H{"error": "payment gateway did not answer in time, the tip was not changed"})
+					return
+				}
+				c.JSON(http.StatusBadGateway, gin.H{"error": "payment gateway refused the tip: " + err.Error()})
+				return
+			}
+		}
+
+		if err := ctl.store.Invoices.SetPaymentTip(ctx, invoice.RestaurantId, invoice.InvoiceId, payment.PaymentId, *request.Tip); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while recording the tip"})
+			return
+		}
+
+		invoice.Payments[index].Tip = *request.Tip
+		c.JSON(http.StatusOK, invoice)
+	}
+}
+
+func (ctl *Controller) GetShifts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		allShifts, err := ctl.store.Shifts.List(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the shifts"})
+			return
+		}
+
+		c.JSON(http.StatusOK, allShifts)
+	}
+}
+
+// CloseShift ends the shift running since the previous one was closed, and
+// with it the card batch, after which its tips can no longer change.
+func (ctl *Controller) CloseShift() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		shift, err := ctl.openShift(ctx, helpers.GetTenant(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the shift"})
+			return
+		}
+
+		// shifts end to the nanosecond, rounded for Mongo, so that payments
+		// taken in the second the shift closes still fall into it
+		shift.EndedAt = time.Now().UTC().Truncate(time.Millisecond)
+		shift.CreatedAt = shift.EndedAt
+		shift.ClosedBy = helpers.GetActor(c)
+		shift.ID = primitive.NewObjectID()
+		shift.ShiftId = shift.ID.Hex()
+
+		closed, err := ctl.store.Shifts.Close(ctx, shift)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while closing the shift"})
+			return
+		}
+		if !closed {
+			c.JSON(http.StatusConflict, gin.H{"error": "the shift was closed meanwhile"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, shift)
+	}
+}
+
+// GetShiftTips reports the tips of a shift by server, with what each of them
+// tips out to the pools set in the restaurant TipOutRates. The shift "current"
+// is the one still running. Gratuity counts in the shift its invoice was
+// settled in.
+func (ctl *Controller) GetShiftTips() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		restaurantId := helpers.GetTenant(c)
+		shift, err := ctl.store.Shifts.Get(ctx, restaurantId, c.Param("shift_id"))
+		if c.Param("shift_id") == "current" {
+			shift, err = ctl.openShift(ctx, restaurantId)
+		}
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "shift not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the shift"})
+			return
+		}
+
+		restaurant, err := ctl.store.Restaurants.Get(ctx, restaurantId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error occurred while fetching restaurant"})
+			return
+		}
+
+		report, err := ctl.shiftTips(ctx, restaurant, shift)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while totalling the tips"})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// openShift is the shift running since the last one was closed.
+func (ctl *Controller) openShift(ctx context.Context, restaurantId string) (models.Shift, error) {
+	shift := models.Shift{RestaurantId: restaurantId}
+
+	lastShift, err := ctl.store.Shifts.Last(ctx, restaurantId)
+	if err == repository.ErrNotFound {
+		return shift, nil
+	}
+	if err != nil {
+		return shift, err
+	}
+
+	shift.StartedAt = lastShift.EndedAt
+	return shift, nil
+}
+
+func (ctl *Controller) shiftTips(ctx context.Context, restaurant models.Restaurant, shift models.Shift) (ShiftTips, error) {
+	report := ShiftTips{Shift: shift, Currency: restaurant.CurrencyCode(), Servers: []ServerTips{}, Pools: []TipPool{}}
+	period := repository.Period{From: shift.StartedAt, To: shift.EndedAt}
+
+	invoices, err := ctl.store.Invoices.ListPaidIn(ctx, shift.RestaurantId, period)
+	if err != nil {
+		return report, err
+	}
+
+	byServer := map[string]*ServerTips{}
+	serverOf := map[string]string{}
+	for _, invoice := range invoices {
+		if invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided {
+			continue
+		}
+
+		serverId, known := serverOf[invoice.OrderId]
+		if !known {
+			order, err := ctl.store.Orders.Get(ctx, shift.RestaurantId, invoice.OrderId)
+			if err != nil && err != repository.ErrNotFound {
+				return report, err
+			}
+			serverId, serverOf[invoice.OrderId] = order.ServerId, order.ServerId
+		}
+		if byServer[serverId] == nil {
+			byServer[serverId] = &ServerTips{ServerId: serverId}
+		}
+		server := byServer[serverId]
+
+		for _, payment := range invoice.Payments {
+			if !period.Contains(payment.CreatedAt) {
+				continue
+			}
+			if payment.Method == models.PaymentCard {
+				server.CardTips += payment.Tip
+			} else {
+				server.CashTips += payment.Tip
+			}
+		}
+
+		settled := len(invoice.Payments) > 0 && period.Contains(invoice.Payments[len(invoice.Payments)-1].CreatedAt)
+		if invoice.Totals != nil && invoice.Balance() <= 0 && settled {
+			server.Gratuity += invoice.Totals.Gratuity
+		}
+	}
+
+	roles := []string{}
+	for role := range restaurant.TipOutRates {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	pools := map[string]*TipPool{}
+	for _, role := range roles {
+		pools[role] = &TipPool{Role: role, Rate: restaurant.TipOutRates[role]}
+	}
+
+	for serverId, server := range byServer {
+		server.Name = "Unassigned"
+		if serverId != "" {
+			user, err := ctl.store.Users.Get(ctx, serverId)
+			if err != nil && err != repository.ErrNotFound {
+				return report, err
+			}
+			if err == nil {
+				server.Name = strings.TrimSpace(models.StringOf(user.FirstName) + " " + models.StringOf(user.LastName))
+			}
+		}
+
+		server.Tips = server.CardTips + server.CashTips + server.Gratuity
+		for _, role := range roles {
+			out := server.Tips.Percent(pools[role].Rate)
+			pools[role].Amount += out
+			server.TipOut += out
+		}
+		server.Net = server.Tips - server.TipOut
+
+		report.Tips += server.Tips
+		report.TipOut += server.TipOut
+		report.Servers = append(report.Servers, *server)
+	}
+	sort.Slice(report.Servers, func(a, b int) bool { return report.Servers[a].Name < report.Servers[b].Name })
+	for _, role := range roles {
+		report.Pools = append(report.Pools, *pools[role])
+	}
+
+	return report, nil
+}
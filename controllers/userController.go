@@ -2,27 +2,24 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"net/http"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
 	"restaurant-management-system/helpers"
 	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "users")
-
-func GetUsers() gin.HandlerFunc {
+func (ctl *Controller) GetUsers() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		recordsPerPage, err := strconv.Atoi(c.Query("recordsPerPage"))
@@ -36,68 +33,50 @@ func GetUsers() gin.HandlerFunc {
 		}
 
 		startIndex := (page - 1) * recordsPerPage
-		startIndex, err = strconv.Atoi(c.Query("startIndex"))
-		if err != nil {
-			startIndex = 0
-		}
-
-		matchStage := bson.D{
-			{
-				"$match", bson.D{},
-			}}
-
-		projectStage := bson.D{
-			{
-				"$project", bson.D{
-					{"_id", 0},
-					{"total_Count", 1},
-					{"user_items", bson.D{
-						{"$slice", []interface{}{"$data", startIndex, recordsPerPage}},
-					}},
-				},
-			},
-		}
-
-		AggregationResult, err := userCollection.Aggregate(ctx, mongo.Pipeline{
-			matchStage,
-			projectStage,
-		})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the users"})
-			return
+		if index, err := strconv.Atoi(c.Query("startIndex")); err == nil && index >= 0 {
+			startIndex = index
 		}
 
-		var allUsers []models.User
-
-		if err = AggregationResult.All(ctx, &allUsers); err != nil {
+		allUsers, total, err := ctl.store.Users.List(ctx, helpers.GetTenant(c), repository.Page{Skip: startIndex, Limit: recordsPerPage})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while listing the users"})
 			return
 		}
 
-		c.JSON(http.StatusOK, allUsers[0])
+		for i := range allUsers {
+			allUsers[i] = allUsers[i].Public()
+		}
+		c.JSON(http.StatusOK, gin.H{"total_count": total, "user_items": allUsers})
 	}
 }
 
-func GetUser() gin.HandlerFunc {
+func (ctl *Controller) GetUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 
 		userId := c.Param("user_id")
-		var user models.User
+		if err := helpers.MatchUserRoleToUid(c, userId, models.RoleAdmin, models.RoleManager); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 
-		err := userCollection.FindOne(ctx, bson.M{"user_id": userId}).Decode(&user)
-		if err != nil {
+		user, err := ctl.store.Users.Get(ctx, userId)
+		if err != nil && err != repository.ErrNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
 			return
 		}
-		c.JSON(http.StatusOK, user)
+		if err == repository.ErrNotFound || (userId != c.GetString("uid") && user.RestaurantId != helpers.GetTenant(c)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusOK, user.Public())
 	}
 }
 
-func SignUp() gin.HandlerFunc {
+func (ctl *Controller) SignUp() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 		var user models.User
 
@@ -115,14 +94,13 @@ func SignUp() gin.HandlerFunc {
 		}
 
 		//You'll check if email has already been taken
-		count, err := userCollection.CountDocuments(ctx, bson.M{"email": user.Email})
-		if err != nil {
+		_, err := ctl.store.Users.GetByEmail(ctx, *user.Email)
+		if err != nil && err != repository.ErrNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking if email is already taken"})
-			log.Panic(err)
 			return
 		}
-		if count > 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "email is already taken"})
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "email is already taken"})
 			return
 		}
 
@@ -131,17 +109,41 @@ func SignUp() gin.HandlerFunc {
 		user.Password = &password
 
 		//You'll also check if the phone has already been used by another user
-		count, err = userCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
-		if err != nil {
+		_, err = ctl.store.Users.GetByPhone(ctx, *user.Phone)
+		if err != nil && err != repository.ErrNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking if phone is already taken"})
-			log.Panic(err)
 			return
 		}
-		if count > 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "phone number is already taken"})
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "phone number is already taken"})
 			return
 		}
 
+		//The very first account bootstraps the system as the OWNER of every location, everybody
+		//else joins an existing restaurant as WAITER until an admin promotes them through
+		//PATCH /users/:user_id/role
+		count, err := ctl.store.Users.Count(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while counting the users"})
+			return
+		}
+		role := models.RoleWaiter
+		if count == 0 {
+			role = models.RoleOwner
+			user.RestaurantId = ""
+		} else {
+			_, err := ctl.store.Restaurants.Get(ctx, user.RestaurantId)
+			if err != nil && err != repository.ErrNotFound {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking the restaurant"})
+				return
+			}
+			if user.RestaurantId == "" || err == repository.ErrNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "a valid restaurant_id is required"})
+				return
+			}
+		}
+		user.Role = &role
+
 		//Create some extra details for the user object - created_at, updated_at, id
 		user.CreatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
 		user.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
@@ -149,7 +151,7 @@ func SignUp() gin.HandlerFunc {
 		user.UserId = user.ID.Hex()
 
 		//Generate Token and Refresh Token
-		token, refreshToken, tokenErr := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserId)
+		token, refreshToken, tokenErr := helpers.GenerateAllTokens(*user.Email, *user.FirstName, *user.LastName, user.UserId, *user.Role, user.RestaurantId, "", user.SessionEpoch)
 		if tokenErr != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while generating the token"})
 			return
@@ -159,60 +161,326 @@ func SignUp() gin.HandlerFunc {
 		user.RefreshToken = &refreshToken
 
 		//Inserts the data into the database
-		resultInsertionNumber, insertErr := userCollection.InsertOne(ctx, user)
-		if insertErr != nil {
-			msg := fmt.Sprintf("error ocurred while inserting the user %s", insertErr)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		if err := ctl.store.Users.Create(ctx, user); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while inserting the user"})
 			return
 		}
 
-		//Return the data
-		c.JSON(http.StatusCreated, resultInsertionNumber)
+		//Return the data, with the tokens but not the password hash
+		created := user.Public()
+		created.Token, created.RefreshToken = &token, &refreshToken
+		c.JSON(http.StatusCreated, created)
 	}
 }
 
-func LogIn() gin.HandlerFunc {
+// dummyPasswordHash is compared against when the e-mail is unknown so a
+// missing account takes as long to reject as a wrong password. It is hashed
+// with the configured cost so both paths do the same amount of work.
+var dummyPasswordHash = sync.OnceValue(func() string {
+	return HashPassword("not-a-real-password")
+})
+
+func (ctl *Controller) LogIn() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
 		defer cancel()
 		var user models.User
-		var foundUser models.User
 
 		//Convert the login data from postman which is in JSON to Golang Readable format
 		if err := c.BindJSON(&user); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		//Find a user with the email, and if the user even exists
-		err := userCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
+		if user.Email == nil || user.Password == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+			return
+		}
+
+		//Refuse straight away while the account or the caller's IP is locked out
+		accountKey := helpers.AccountAttemptKey(*user.Email)
+		ipKey := helpers.IPAttemptKey(c.ClientIP())
+		lockedUntil, err := helpers.LoginLockedUntil(ctx, ctl.store.LoginAttempts, accountKey, ipKey)
 		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking sign-in attempts"})
+			return
+		}
+		if !lockedUntil.IsZero() {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(lockedUntil).Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed sign-in attempts, try again later"})
+			return
+		}
+
+		//Find a user with the email, and if the user even exists
+		storedPassword := dummyPasswordHash()
+		foundUser, err := ctl.store.Users.GetByEmail(ctx, *user.Email)
+		if err != nil && err != repository.ErrNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
 			return
 		}
+		if err == nil {
+			storedPassword = *foundUser.Password
+		}
 
-		//Then you will verify the password
-		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
-		if !passwordIsValid {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+		//Then you will verify the password. Unknown e-mails and wrong passwords get the same answer
+		passwordIsValid, _ := VerifyPassword(*user.Password, storedPassword)
+		if err != nil || !passwordIsValid {
+			if recordErr := helpers.RecordLoginFailure(ctx, ctl.store.LoginAttempts, accountKey, ipKey, config.Get().LoginMaxFailures); recordErr != nil {
+				log.Println(recordErr)
+			}
+			if recordErr := helpers.RecordLoginFailure(ctx, ctl.store.LoginAttempts, ipKey, "", config.Get().LoginIPMaxFailures); recordErr != nil {
+				log.Println(recordErr)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid email or password"})
 			return
 		}
 
+		if err := helpers.ClearLoginFailures(ctx, ctl.store.LoginAttempts, accountKey); err != nil {
+			log.Println(err)
+		}
+
 		//If the password is correct, then you will generate a token and refresh token
-		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserId)
+		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserId, UserRole(foundUser), foundUser.RestaurantId, "", foundUser.SessionEpoch)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while generating the token"})
 			return
 		}
-		//Return the token and refresh token
-		helpers.UpdateAllTokens(token, refreshToken, foundUser.UserId)
+		//Store the new pair so the refresh token can be exchanged later
+		if err := ctl.store.Users.SetTokens(ctx, foundUser.UserId, token, refreshToken); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while storing the token"})
+			return
+		}
+
+		//Return the user with the token and refresh token
+		signedIn := foundUser.Public()
+		signedIn.Token, signedIn.RefreshToken = &token, &refreshToken
+		c.JSON(http.StatusOK, signedIn)
+	}
+}
+
+// UnlockUser lifts a sign-in lockout on the user's account.
+func (ctl *Controller) UnlockUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		userId := c.Param("user_id")
+		foundUser, err := ctl.store.Users.Get(ctx, userId)
+		if err == repository.ErrNotFound || (err == nil && foundUser.RestaurantId != helpers.GetTenant(c)) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+			return
+		}
 
-		//If the password is incorrect, then return an error
-		c.JSON(http.StatusOK, foundUser)
+		if err := helpers.UnlockLogin(ctx, ctl.store.LoginAttempts, helpers.AccountAttemptKey(*foundUser.Email)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while unlocking the user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
 	}
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// RefreshToken exchanges a valid refresh token for a new access/refresh pair.
+// Every exchange rotates the refresh token; presenting one that has already
+// been rotated is treated as theft and ends the user's session.
+func (ctl *Controller) RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		var request RefreshRequest
+		if err := c.BindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if validationErr := validate.Struct(request); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": validationErr.Error()})
+			return
+		}
+
+		claims, msg := helpers.ValidateRefreshToken(request.RefreshToken)
+		if msg != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": msg})
+			return
+		}
+
+		foundUser, err := ctl.store.Users.Get(ctx, claims.Uid)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+
+		if foundUser.RefreshToken == nil || *foundUser.RefreshToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "session has ended, please sign in again"})
+			return
+		}
+
+		token, refreshToken, err := helpers.GenerateAllTokens(*foundUser.Email, *foundUser.FirstName, *foundUser.LastName, foundUser.UserId, UserRole(foundUser), foundUser.RestaurantId, "", foundUser.SessionEpoch)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while generating the token"})
+			return
+		}
+
+		rotated, err := ctl.store.Users.RotateTokens(ctx, foundUser.UserId, request.RefreshToken, token, refreshToken)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while storing the token"})
+			return
+		}
+		if !rotated {
+			//The token is genuine but no longer the current one, so somebody is replaying it
+			if err := ctl.store.Users.SetTokens(ctx, foundUser.UserId, "", ""); err != nil {
+				log.Println(err)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token has already been used, please sign in again"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refreshToken})
+	}
+}
+
+func (ctl *Controller) UpdateUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		userId := c.Param("user_id")
+		var user models.User
+
+		if err := c.BindJSON(&user); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if user.Role == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role is required"})
+			return
+		}
+
+		if validationErr := validate.Var(*user.Role, "eq=OWNER|eq=ADMIN|eq=MANAGER|eq=WAITER|eq=COOK|eq=CASHIER"); validationErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role " + *user.Role})
+			return
+		}
+
+		if *user.Role == models.RoleOwner && c.GetString("role") != models.RoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only an owner can grant the OWNER role"})
+			return
+		}
+
+		if userId == c.GetString("uid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot change your own role"})
+			return
+		}
+
+		foundUser, err := ctl.store.Users.Get(ctx, userId)
+		if err != nil && err != repository.ErrNotFound {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+			return
+		}
+		if err == repository.ErrNotFound || foundUser.RestaurantId != helpers.GetTenant(c) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+
+		foundUser.Role = user.Role
+		foundUser.UpdatedAt, _ = time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+		if err := ctl.store.Users.Update(ctx, foundUser); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error ocurred while updating the user role"})
+			return
+		}
+		// the tokens of the user carry the role they were issued with
+		if err := helpers.RevokeAllUserTokens(ctx, ctl.store, foundUser.UserId); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while ending the sessions of the user"})
+			return
+		}
+
+		c.JSON(http.StatusOK, foundUser.Public())
+	}
+}
+
+// Logout revokes the access token used for the request and discards the
+// stored refresh token so the session cannot be renewed.
+func (ctl *Controller) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		uid := c.GetString("uid")
+		expiresAt := time.Unix(c.GetInt64("token_expires_at"), 0)
+
+		if err := helpers.RevokeToken(ctx, ctl.store, c.GetString("token_id"), uid, expiresAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while revoking the token"})
+			return
+		}
+
+		if err := ctl.store.Users.SetTokens(ctx, uid, "", ""); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while revoking the refresh token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "signed out"})
+	}
+}
+
+// RevokeAllSessions immediately invalidates every token the user holds. Users
+// may end their own sessions; admins and managers may end anybody's.
+func (ctl *Controller) RevokeAllSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		userId := c.Param("user_id")
+		if err := helpers.MatchUserRoleToUid(c, userId, models.RoleAdmin, models.RoleManager); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+
+		if userId != c.GetString("uid") {
+			foundUser, err := ctl.store.Users.Get(ctx, userId)
+			if err != nil && err != repository.ErrNotFound {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the user"})
+				return
+			}
+			if err == repository.ErrNotFound || foundUser.RestaurantId != helpers.GetTenant(c) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+				return
+			}
+		}
+
+		err := helpers.RevokeAllUserTokens(ctx, ctl.store, userId)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while revoking the sessions"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "all sessions revoked"})
+	}
+}
+
+// UserRole returns the role stored on the user, falling back to WAITER for
+// accounts created before roles existed.
+func UserRole(user models.User) string {
+	if user.Role == nil || *user.Role == "" {
+		return models.RoleWaiter
+	}
+
+	return *user.Role
+}
+
 func HashPassword(password string) string {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), config.Get().BcryptCost)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -225,7 +493,7 @@ func VerifyPassword(userPassword string, providedPassword string) (bool, string)
 	msg := ""
 	check := true
 	if bcryptErr != nil {
-		msg = fmt.Sprintf("Password is incorrect")
+		msg = "Password is incorrect"
 		check = false
 	}
 
@@ -6,17 +6,14 @@ import (
 
 	"context"
 	"fmt"
-	"log"
 	"time"
 )
 
-func DBInstance() *mongo.Client {
-	MongoDb := "mongodb://localhost:27017"
-	fmt.Print(MongoDb)
-
-	client, err := mongo.NewClient(options.Client().ApplyURI(MongoDb))
+// DBInstance connects to the MongoDB server at uri.
+func DBInstance(uri string) (*mongo.Client, error) {
+	client, err := mongo.NewClient(options.Client().ApplyURI(uri))
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -25,18 +22,10 @@ func DBInstance() *mongo.Client {
 
 	err = client.Connect(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	fmt.Println("Connected to MongoDB!")
 
-	return client
-}
-
-var Client *mongo.Client = DBInstance()
-
-func OpenCollection(client *mongo.Client, collectionName string) *mongo.Collection {
-	var collection *mongo.Collection = client.Database("restaurant").Collection(collectionName)
-
-	return collection
+	return client, nil
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+type createdDevice struct {
+	Device    models.Device `json:"device"`
+	DeviceKey string        `json:"device_key"`
+}
+
+func TestDeviceKeysAreLimitedToTheirScopes(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	soup := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Soup", "4.5")
+	_, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup)
+
+	var created createdDevice
+	newDevice := map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrdersRead}}
+	s.expect(s.do(http.MethodPost, "/devices", newDevice, manager), http.StatusCreated, &created)
+	screen := headers{"X-Device-Key": created.DeviceKey}
+
+	s.expect(s.do(http.MethodGet, "/orders", nil, screen), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/orderItems/"+items[0].OrderItemId, nil, screen), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "S"}, screen), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodGet, "/invoice", nil, screen), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodGet, "/foods", nil, screen), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodGet, "/orders", nil, headers{"X-Device-Key": "not-a-key"}), http.StatusUnauthorized, nil)
+
+	var devices []models.Device
+	s.expect(s.do(http.MethodGet, "/devices", nil, manager), http.StatusOK, &devices)
+	if len(devices) != 1 || devices[0].KeyHash != "" {
+		t.Fatalf("expected 1 device without its key hash, got %+v", devices)
+	}
+}
+
+func TestRotateAndRevokeDeviceKeys(t *testing.T) {
+	s := newTestServer(t)
+	_, manager := s.staff(models.RoleManager)
+
+	var created, rotated createdDevice
+	newDevice := map[string]interface{}{"name": "Receipts", "type": models.DeviceReceiptPrinter, "scopes": []string{models.ScopeInvoicesRead}}
+	s.expect(s.do(http.MethodPost, "/devices", newDevice, manager), http.StatusCreated, &created)
+
+	s.expect(s.do(http.MethodPost, "/devices/"+created.Device.DeviceId+"/rotate", nil, manager), http.StatusOK, &rotated)
+	s.expect(s.do(http.MethodGet, "/invoice", nil, headers{"X-Device-Key": created.DeviceKey}), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodGet, "/invoice", nil, headers{"X-Device-Key": rotated.DeviceKey}), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPost, "/devices/"+created.Device.DeviceId+"/revoke", nil, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/invoice", nil, headers{"X-Device-Key": rotated.DeviceKey}), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/devices/"+created.Device.DeviceId+"/rotate", nil, manager), http.StatusNotFound, nil)
+}
+
+func TestCreateDeviceValidation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Grill screen", "type": "TOASTER", "scopes": []string{models.ScopeOrdersRead}}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{"users:write"}}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{}}, manager), http.StatusBadRequest, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodGet, "/devices", nil, waiter), http.StatusForbidden, nil)
+}
+
+func TestDevicesOfOtherRestaurantsCannotBeManaged(t *testing.T) {
+	s := newTestServer(t)
+	_, manager := s.staff(models.RoleManager)
+	otherManager := s.headersFor(s.seedRestaurant("Elsewhere").RestaurantId, models.RoleManager)
+
+	var created createdDevice
+	newDevice := map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrdersRead}}
+	s.expect(s.do(http.MethodPost, "/devices", newDevice, otherManager), http.StatusCreated, &created)
+
+	s.expect(s.do(http.MethodPost, "/devices/"+created.Device.DeviceId+"/rotate", nil, manager), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPost, "/devices/"+created.Device.DeviceId+"/revoke", nil, manager), http.StatusNotFound, nil)
+}
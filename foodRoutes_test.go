@@ -0,0 +1,177 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestFoodLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+
+	var created models.Food
+	newFood := map[string]interface{}{"name": "Pancakes", "price": 7.456, "food_image": "https://example.com/pancakes.png", "menu_id": menu.MenuId}
+	s.expect(s.do(http.MethodPost, "/foods", newFood, manager), http.StatusCreated, &created)
+	if *created.Price != money("7.46") || created.RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected a price of 7.46 in %s, got %v in %s", restaurant.RestaurantId, *created.Price, created.RestaurantId)
+	}
+
+	var fetched models.Food
+	s.expect(s.do(http.MethodGet, "/foods/"+created.FoodId, nil, manager), http.StatusOK, &fetched)
+	if *fetched.Name != "Pancakes" {
+		t.Fatalf("expected Pancakes, got %s", *fetched.Name)
+	}
+
+	var updated models.Food
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"price": "8"}, manager), http.StatusOK, &updated)
+	if *updated.Price != money("8.00") || *updated.Name != "Pancakes" {
+		t.Fatalf("expected only the price to change, got %s at %v", *updated.Name, *updated.Price)
+	}
+}
+
+func TestGetFoodsPaginates(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	for _, name := range []string{"Soup", "Salad", "Steak"} {
+		s.seedFood(restaurant.RestaurantId, menu.MenuId, name, "10")
+	}
+
+	var page struct {
+		TotalCount int           `json:"total_count"`
+		FoodItems  []models.Food `json:"food_items"`
+	}
+	s.expect(s.do(http.MethodGet, "/foods?recordPerPage=2&page=2", nil, waiter), http.StatusOK, &page)
+	if page.TotalCount != 3 || len(page.FoodItems) != 1 {
+		t.Fatalf("expected 1 of 3 foods, got %d of %d", len(page.FoodItems), page.TotalCount)
+	}
+}
+
+func TestCreateFoodValidation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	otherMenu := s.seedMenu(s.seedRestaurant("Elsewhere").RestaurantId)
+
+	s.expect(s.do(http.MethodPost, "/foods", "{", manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/foods", map[string]interface{}{"name": "P", "price": 1, "food_image": "img", "menu_id": menu.MenuId}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/foods", map[string]interface{}{"name": "Pancakes", "price": 1, "food_image": "img", "menu_id": otherMenu.MenuId}, manager), http.StatusBadRequest, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodPost, "/foods", map[string]interface{}{"name": "Pancakes", "price": 1, "food_image": "img", "menu_id": menu.MenuId}, waiter), http.StatusForbidden, nil)
+}
+
+func TestFoodsAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, manager := s.staff(models.RoleManager)
+	other := s.seedRestaurant("Elsewhere")
+	food := s.seedFood(other.RestaurantId, s.seedMenu(other.RestaurantId).MenuId, "Soup", "5")
+
+	s.expect(s.do(http.MethodGet, "/foods/"+food.FoodId, nil, manager), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/foods/"+food.FoodId, map[string]interface{}{"price": 1}, manager), http.StatusNotFound, nil)
+}
+
+func TestFoodStations(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+
+	var created models.Food
+	newFood := map[string]interface{}{"name": "Mojito", "price": 9, "food_image": "https://example.com/mojito.png", "menu_id": menu.MenuId, "station": models.StationBar}
+	s.expect(s.do(http.MethodPost, "/foods", newFood, manager), http.StatusCreated, &created)
+	if *created.Station != models.StationBar {
+		t.Fatalf("expected the BAR station, got %v", created.Station)
+	}
+
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]string{"station": "WOK"}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]string{"station": models.StationPastry}, manager), http.StatusOK, &created)
+	if *created.Station != models.StationPastry {
+		t.Fatalf("expected the PASTRY station, got %v", created.Station)
+	}
+}
+
+func TestFoodModifierGroups(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+
+	doneness := map[string]interface{}{"name": "Doneness", "required": true, "max_selections": 1, "options": []map[string]interface{}{{"name": "Rare"}, {"name": "Well done"}}}
+	newFood := map[string]interface{}{"name": "Steak", "price": 20, "food_image": "img", "menu_id": menu.MenuId, "modifier_groups": []interface{}{doneness}}
+	var created models.Food
+	s.expect(s.do(http.MethodPost, "/foods", newFood, manager), http.StatusCreated, &created)
+	if len(created.ModifierGroups) != 1 || created.ModifierGroups[0].GroupId == "" || created.ModifierGroups[0].Options[1].OptionId == "" {
+		t.Fatalf("expected the group and its options to get ids, got %+v", created.ModifierGroups)
+	}
+
+	groups := created.ModifierGroups
+	groups[0].Options = append(groups[0].Options, models.ModifierOption{Name: "Medium"})
+	var updated models.Food
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"modifier_groups": groups}, manager), http.StatusOK, &updated)
+	if updated.ModifierGroups[0].GroupId != groups[0].GroupId || updated.ModifierGroups[0].Options[0].OptionId != groups[0].Options[0].OptionId || len(updated.ModifierGroups[0].Options) != 3 {
+		t.Fatalf("expected the group to keep its ids and gain an option, got %+v", updated.ModifierGroups)
+	}
+
+	tooMany := map[string]interface{}{"name": "Extras", "max_selections": 3, "options": []map[string]interface{}{{"name": "Bacon"}}}
+	unsatisfiable := map[string]interface{}{"name": "Sides", "min_selections": 2, "max_selections": 1, "options": []map[string]interface{}{{"name": "Fries"}, {"name": "Salad"}}}
+	unnamed := map[string]interface{}{"name": "Sauces", "max_selections": 1, "options": []map[string]interface{}{{"name": ""}}}
+	for _, group := range []interface{}{tooMany, unsatisfiable, unnamed} {
+		s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"modifier_groups": []interface{}{group}}, manager), http.StatusBadRequest, nil)
+	}
+}
+
+func TestFoodSizeVariants(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+
+	variants := []map[string]interface{}{{"size": models.SizeSmall, "price": "3.499"}, {"size": models.SizeLarge, "price": 5}}
+	var created models.Food
+	s.expect(s.do(http.MethodPost, "/foods", map[string]interface{}{"name": "Fries", "price": 4, "food_image": "img", "menu_id": menu.MenuId, "variants": variants}, manager), http.StatusCreated, &created)
+	if len(created.Variants) != 2 || created.Variants[0].Price != money("3.50") {
+		t.Fatalf("expected two variants with the small one at 3.50, got %+v", created.Variants)
+	}
+
+	twice := []map[string]interface{}{{"size": models.SizeSmall, "price": 3}, {"size": models.SizeSmall, "price": 4}}
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"variants": twice}, manager), http.StatusBadRequest, nil)
+	unknown := []map[string]interface{}{{"size": "XXL", "price": 3}}
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"variants": unknown}, manager), http.StatusBadRequest, nil)
+	negative := []map[string]interface{}{{"size": models.SizeMedium, "price": -1}}
+	s.expect(s.do(http.MethodPatch, "/foods/"+created.FoodId, map[string]interface{}{"variants": negative}, manager), http.StatusBadRequest, nil)
+}
+
+func TestFoodPricesAreExactDecimals(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+
+	response := s.do(http.MethodPost, "/foods", map[string]interface{}{"name": "Tea", "price": "0.10", "food_image": "img", "menu_id": menu.MenuId}, manager)
+	var tea models.Food
+	s.expect(response, http.StatusCreated, &tea)
+	if !strings.Contains(response.Body.String(), `"price":"0.10"`) {
+		t.Fatalf("expected the price as a decimal string, got %s", response.Body.String())
+	}
+
+	for _, price := range []interface{}{"ten", "1e2", 1e21, "1.2.3"} {
+		s.expect(s.do(http.MethodPatch, "/foods/"+tea.FoodId, map[string]interface{}{"price": price}, manager), http.StatusBadRequest, nil)
+	}
+
+	coffee := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Coffee", "0.20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, tea, coffee)
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.Total.String() != "0.30" || totals.Currency != models.DefaultCurrency {
+		t.Fatalf("expected exactly 0.30 USD, got %s %s", totals.Total, totals.Currency)
+	}
+
+	owner := s.headersFor("", models.RoleOwner)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]string{"currency": "EURO"}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]string{"currency": "EUR"}, owner), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.Currency != "EUR" {
+		t.Fatalf("expected the totals in EUR, got %s", totals.Currency)
+	}
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"errors"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CheckUserRole returns an error unless the authenticated caller holds one of
+// roles. Owners run every location and pass every role check.
+func CheckUserRole(c *gin.Context, roles ...string) error {
+	role := c.GetString("role")
+	if role == models.RoleOwner {
+		return nil
+	}
+	for _, allowed := range roles {
+		if role == allowed {
+			return nil
+		}
+	}
+
+	return errors.New("you are not authorized to access this resource")
+}
+
+// MatchUserRoleToUid lets a caller access their own user record, or any record
+// when they hold one of the given roles.
+func MatchUserRoleToUid(c *gin.Context, userId string, roles ...string) error {
+	if c.GetString("uid") == userId {
+		return nil
+	}
+
+	return CheckUserRole(c, roles...)
+}
+
+// CheckDeviceScope returns an error unless the authenticated device was
+// granted scope.
+func CheckDeviceScope(c *gin.Context, scope string) error {
+	for _, granted := range c.GetStringSlice("scopes") {
+		if granted == scope {
+			return nil
+		}
+	}
+
+	return errors.New("device is not allowed to access this resource")
+}
+
+// GetActor identifies who made the request, for recording who changed what:
+// the signed-in user, or the device for requests made with a device key.
+func GetActor(c *gin.Context) string {
+	if deviceId := c.GetString("device_id"); deviceId != "" {
+		return "device:" + deviceId
+	}
+
+	return c.GetString("uid")
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+)
+
+// NewDeviceKey returns a key of the form "<device_id>.<secret>" and the hash
+// to store for it. The id prefix lets the key be looked up without scanning.
+func NewDeviceKey(deviceId string) (key string, keyHash string) {
+	key = deviceId + "." + NewSecret()
+	return key, HashSecret(key)
+}
+
+// ValidateDeviceKey returns the active device the key belongs to.
+func ValidateDeviceKey(ctx context.Context, devices repository.DeviceRepository, key string) (device *models.Device, msg string) {
+	deviceId, _, found := strings.Cut(key, ".")
+	if !found || deviceId == "" {
+		return nil, "Invalid device key"
+	}
+
+	foundDevice, err := devices.Get(ctx, deviceId)
+	if err == repository.ErrNotFound {
+		return nil, "Invalid device key"
+	}
+	if err != nil {
+		return nil, "error occurred while checking the device key"
+	}
+
+	if subtle.ConstantTimeCompare([]byte(foundDevice.KeyHash), []byte(HashSecret(key))) != 1 {
+		return nil, "Invalid device key"
+	}
+	if foundDevice.RevokedAt != nil {
+		return nil, "Device has been revoked"
+	}
+
+	return &foundDevice, ""
+}
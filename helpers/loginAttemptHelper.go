@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"restaurant-management-system/config"
+	"restaurant-management-system/repository"
+)
+
+// An account is locked after login_max_failures consecutive failures and an IP
+// after login_ip_max_failures, which is higher because a whole restaurant may
+// share one address. Every further failure doubles the lock, starting at
+// login_lockout_base and capped at login_lockout_max. Failures older than
+// login_failure_window are forgotten.
+
+func AccountAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func IPAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginLockedUntil returns the latest lock among keys, or the zero time when
+// none of them is locked.
+func LoginLockedUntil(ctx context.Context, attempts repository.LoginAttemptRepository, keys ...string) (time.Time, error) {
+	found, err := attempts.GetMany(ctx, keys)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var lockedUntil time.Time
+	for _, attempt := range found {
+		if attempt.LockedUntil != nil && attempt.LockedUntil.After(lockedUntil) {
+			lockedUntil = *attempt.LockedUntil
+		}
+	}
+	if lockedUntil.Before(time.Now()) {
+		return time.Time{}, nil
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordLoginFailure counts a failed sign-in against the key, made from the
+// source key unless it is empty, and locks the key once maxFailures is
+// reached.
+func RecordLoginFailure(ctx context.Context, attempts repository.LoginAttemptRepository, key string, source string, maxFailures int) error {
+	now := time.Now()
+	attempt, err := attempts.RecordFailure(ctx, key, source, now, now.Add(-config.Get().LoginFailureWindow))
+	if err != nil {
+		return err
+	}
+	if attempt.Failures < maxFailures {
+		return nil
+	}
+
+	return attempts.Lock(ctx, key, now.Add(lockoutDuration(attempt.Failures-maxFailures)))
+}
+
+// ClearLoginFailures forgets every failure recorded against the keys, which
+// also lifts any lock on them.
+func ClearLoginFailures(ctx context.Context, attempts repository.LoginAttemptRepository, keys ...string) error {
+	return attempts.Delete(ctx, keys)
+}
+
+// UnlockLogin clears the failures recorded against key and against the
+// sources they were made from, so that an unlocked account is not kept out
+// by the lock on its IP.
+func UnlockLogin(ctx context.Context, attempts repository.LoginAttemptRepository, key string) error {
+	attempt, err := attempts.Get(ctx, key)
+	if err != nil && err != repository.ErrNotFound {
+		return err
+	}
+
+	return attempts.Delete(ctx, append([]string{key}, attempt.Sources...))
+}
+
+func lockoutDuration(extraFailures int) time.Duration {
+	lockout := config.Get().LoginLockoutBase
+	for i := 0; i < extraFailures && lockout < config.Get().LoginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > config.Get().LoginLockoutMax {
+		lockout = config.Get().LoginLockoutMax
+	}
+
+	return lockout
+}
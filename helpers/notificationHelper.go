@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"restaurant-management-system/config"
+	"sync"
+	"time"
+)
+
+type Notification struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages such as password reset links to staff. Pass an
+// e-mail or SMS backed implementation to the controllers in production.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogNotifier writes notifications to the application log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("notification to %s: %s\n%s", notification.To, notification.Subject, notification.Body)
+	return nil
+}
+
+// FileNotifier appends notifications to a file, one block per message.
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (n *FileNotifier) Notify(ctx context.Context, notification Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "[%s] to: %s\nsubject: %s\n%s\n\n", time.Now().Format(time.RFC3339), notification.To, notification.Subject, notification.Body)
+	return err
+}
+
+// NewNotifier picks the notifier selected with the notifier and
+// notifier_file settings.
+func NewNotifier(cfg *config.Config) Notifier {
+	if cfg.Notifier == "file" {
+		return &FileNotifier{Path: cfg.NotifierFile}
+	}
+
+	return LogNotifier{}
+}
@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"restaurant-management-system/config"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidResetToken = errors.New("reset token is invalid or has expired")
+
+// CreatePasswordReset stores a new single-use reset for the user and returns
+// the plain token. Only its hash is persisted, and any reset the user still
+// had outstanding is cancelled.
+func CreatePasswordReset(ctx context.Context, resets repository.PasswordResetRepository, userId string) (string, error) {
+	now, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	if err := resets.CancelOpen(ctx, userId, now); err != nil {
+		return "", err
+	}
+
+	token := NewSecret()
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserId:    userId,
+		TokenHash: HashSecret(token),
+		ExpiresAt: now.Add(config.Get().PasswordResetTTL),
+		CreatedAt: now,
+	}
+	reset.ResetId = reset.ID.Hex()
+
+	if err := resets.Create(ctx, reset); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ConsumePasswordReset marks the reset matching token as used and returns the
+// user it belongs to. A token can only ever be consumed once.
+func ConsumePasswordReset(ctx context.Context, resets repository.PasswordResetRepository, token string) (string, error) {
+	reset, err := resets.Consume(ctx, HashSecret(token), time.Now())
+	if err == repository.ErrNotFound {
+		return "", ErrInvalidResetToken
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return reset.UserId, nil
+}
@@ -0,0 +1,293 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"restaurant-management-system/config"
+	"restaurant-management-system/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	GatewayApproved = "APPROVED"
+	GatewayDeclined = "DECLINED"
+)
+
+var (
+	// ErrGatewayTimeout is returned when the processor did not answer in
+	// time. Whether the card was charged is then unknown, so callers void
+	// what they started.
+	ErrGatewayTimeout       = errors.New("payment gateway timed out")
+	ErrUnknownTransaction   = errors.New("unknown transaction")
+	ErrTransactionState     = errors.New("transaction cannot do that in its current state")
+	ErrAmountExceedsCapture = errors.New("amount exceeds what was captured")
+)
+
+// CardCharge is a card payment to authorize. Reference identifies the
+// payment on the caller's side: authorizing the same reference twice gives
+// back the first authorization instead of charging again.
+type CardCharge struct {
+	Amount    models.Money
+	Currency  string
+	CardToken string
+	Reference string
+}
+
+// GatewayResult is what the processor answered.
+type GatewayResult struct {
+	TransactionId string
+	Status        string
+	Brand         string
+	Last4         string
+	DeclineCode   string
+	Message       string
+}
+
+// PaymentGateway takes card payments through a processor. Capture and Void
+// are idempotent: repeating them on a transaction already in that state
+// answers as the first call did.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, charge CardCharge) (GatewayResult, error)
+	Capture(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error)
+	Void(ctx context.Context, transactionId string) (GatewayResult, error)
+	Refund(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error)
+	// Adjust changes what a captured transaction settles for, as tips added
+	// after the card was charged do, until the batch it is in closes.
+	Adjust(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error)
+}
+
+// SimulatorScenario is how the simulated processor answers a card token.
+type SimulatorScenario struct {
+	// DeclineCode declines the authorization when set.
+	DeclineCode string
+	// TimeoutOn makes "authorize" or "capture" hang until Timeout passes.
+	TimeoutOn string
+}
+
+// DefaultSimulatorScenarios are the test cards every simulator knows. Any
+// other token is approved.
+func DefaultSimulatorScenarios() map[string]SimulatorScenario {
+	return map[string]SimulatorScenario{
+		"tok_insufficient_funds": {DeclineCode: "insufficient_funds"},
+		"tok_expired_card":       {DeclineCode: "expired_card"},
+		"tok_do_not_honor":       {DeclineCode: "do_not_honor"},
+		"tok_timeout":            {TimeoutOn: "authorize"},
+		"tok_capture_timeout":    {TimeoutOn: "capture"},
+	}
+}
+
+const (
+	simulatedAuthorized = "authorized"
+	simulatedCaptured   = "captured"
+	simulatedVoided     = "voided"
+)
+
+type simulatedTransaction struct {
+	result     GatewayResult
+	state      string
+	authorized models.Money
+	captured   models.Money
+	refunded   models.Money
+	scenario   SimulatorScenario
+}
+
+// SimulatedGateway is a processor in process memory, so payments can be
+// taken without a real one. Test card tokens pick its answer.
+type SimulatedGateway struct {
+	Scenarios map[string]SimulatorScenario
+	Timeout   time.Duration
+
+	mu           sync.Mutex
+	transactions map[string]*simulatedTransaction
+	references   map[string]string
+	sequence     int
+}
+
+func NewSimulatedGateway(scenarios map[string]SimulatorScenario, timeout time.Duration) *SimulatedGateway {
+	return &SimulatedGateway{
+		Scenarios:    scenarios,
+		Timeout:      timeout,
+		transactions: map[string]*simulatedTransaction{},
+		references:   map[string]string{},
+	}
+}
+
+func (g *SimulatedGateway) Authorize(ctx context.Context, charge CardCharge) (GatewayResult, error) {
+	scenario := g.Scenarios[charge.CardToken]
+	if scenario.TimeoutOn == "authorize" {
+		return GatewayResult{}, g.hang(ctx)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transactionId, seen := g.references[charge.Reference]; seen && charge.Reference != "" {
+		return g.transactions[transactionId].result, nil
+	}
+
+	g.sequence++
+	transaction := &simulatedTransaction{scenario: scenario, authorized: charge.Amount, state: simulatedAuthorized}
+	transaction.result = GatewayResult{
+		TransactionId: fmt.Sprintf("sim_%06d", g.sequence),
+		Status:        GatewayApproved,
+		Brand:         cardBrand(charge.CardToken),
+		Last4:         "4242",
+		Message:       "approved",
+	}
+	if scenario.DeclineCode != "" {
+		transaction.state = ""
+		transaction.result.Status = GatewayDeclined
+		transaction.result.DeclineCode = scenario.DeclineCode
+		transaction.result.Message = "declined: " + strings.ReplaceAll(scenario.DeclineCode, "_", " ")
+	}
+
+	g.transactions[transaction.result.TransactionId] = transaction
+	if charge.Reference != "" {
+		g.references[charge.Reference] = transaction.result.TransactionId
+	}
+
+	return transaction.result, nil
+}
+
+func (g *SimulatedGateway) Capture(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error) {
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return GatewayResult{}, err
+	}
+	if transaction.scenario.TimeoutOn == "capture" {
+		return GatewayResult{}, g.hang(ctx)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case transaction.state == simulatedCaptured && transaction.captured == amount:
+		return transaction.result, nil
+	case transaction.state != simulatedAuthorized:
+		return GatewayResult{}, ErrTransactionState
+	case amount > transaction.authorized:
+		return GatewayResult{}, errors.New("amount exceeds the authorization")
+	}
+
+	transaction.captured = amount
+	transaction.state = simulatedCaptured
+	return transaction.result, nil
+}
+
+func (g *SimulatedGateway) Void(ctx context.Context, transactionId string) (GatewayResult, error) {
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return GatewayResult{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transaction.state == simulatedVoided {
+		return transaction.result, nil
+	}
+	if transaction.state != simulatedAuthorized && (transaction.state != simulatedCaptured || transaction.refunded > 0) {
+		return GatewayResult{}, ErrTransactionState
+	}
+	transaction.captured = 0
+	transaction.state = simulatedVoided
+	return transaction.result, nil
+}
+
+func (g *SimulatedGateway) Refund(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error) {
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return GatewayResult{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transaction.state != simulatedCaptured {
+		return GatewayResult{}, ErrTransactionState
+	}
+	if transaction.refunded+amount > transaction.captured {
+		return GatewayResult{}, ErrAmountExceedsCapture
+	}
+
+	transaction.refunded += amount
+	return transaction.result, nil
+}
+
+func (g *SimulatedGateway) Adjust(ctx context.Context, transactionId string, amount models.Money) (GatewayResult, error) {
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return GatewayResult{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if transaction.state != simulatedCaptured || transaction.refunded > 0 {
+		return GatewayResult{}, ErrTransactionState
+	}
+
+	transaction.captured = amount
+	return transaction.result, nil
+}
+
+// Captured is what the transaction settles for, less its refunds.
+func (g *SimulatedGateway) Captured(transactionId string) models.Money {
+	transaction, err := g.transaction(transactionId)
+	if err != nil {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return transaction.captured - transaction.refunded
+}
+
+func (g *SimulatedGateway) transaction(transactionId string) (*simulatedTransaction, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transaction, found := g.transactions[transactionId]
+	if !found {
+		return nil, ErrUnknownTransaction
+	}
+	return transaction, nil
+}
+
+// hang waits like a processor that never answers, until Timeout passes or
+// the caller gives up.
+func (g *SimulatedGateway) hang(ctx context.Context) error {
+	select {
+	case <-time.After(g.Timeout):
+	case <-ctx.Done():
+	}
+	return ErrGatewayTimeout
+}
+
+func cardBrand(token string) string {
+	switch {
+	case strings.Contains(token, "mastercard"):
+		return "MASTERCARD"
+	case strings.Contains(token, "amex"):
+		return "AMEX"
+	default:
+		return "VISA"
+	}
+}
+
+// NewPaymentGateway builds the gateway selected with the payment_gateway
+// setting. The simulator also declines the cards listed in
+// payment_simulator_declines, as token:code pairs separated by commas.
+func NewPaymentGateway(cfg *config.Config) PaymentGateway {
+	scenarios := DefaultSimulatorScenarios()
+	for token, code := range cfg.SimulatorDeclines() {
+		scenarios[token] = SimulatorScenario{DeclineCode: code}
+	}
+
+	return NewSimulatedGateway(scenarios, cfg.PaymentSimulatorTimeout)
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Receipts are printed on 80mm rolls: one page as long as the receipt, in
+// Courier so that columns laid out with spaces stay aligned.
+const (
+	pdfPageWidth = 226.0
+	pdfMargin    = 14.0
+	pdfFontSize  = 8.0
+	pdfLeading   = 10.0
+)
+
+// TextPDF lays lines out one under the other on a single page and returns the
+// PDF document. Characters outside Latin-1 are printed as question marks.
+func TextPDF(lines []string) []byte {
+	height := 2*pdfMargin + pdfLeading*float64(len(lines))
+
+	// every line moves down a line before it is shown, so text starts a
+	// line above the first one
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %.1f Tf\n%.1f TL\n%.1f %.1f Td\n", pdfFontSize, pdfLeading, pdfMargin, height-pdfMargin-pdfFontSize+pdfLeading)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.1f %.1f] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>", pdfPageWidth, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, object := range objects {
+		offsets = append(offsets, document.Len())
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return document.Bytes()
+}
+
+// pdfString escapes text for a PDF string literal in WinAnsiEncoding.
+func pdfString(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r == '\t':
+			escaped.WriteString("    ")
+		case r < 0x20 || r > 0xff:
+			escaped.WriteByte('?')
+		default:
+			escaped.WriteByte(byte(r))
+		}
+	}
+	return escaped.String()
+}
@@ -0,0 +1,172 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"restaurant-management-system/models"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+)
+
+// ReceiptWidth is how many characters fit on a line of a printed receipt.
+const ReceiptWidth = 40
+
+// DefaultReceiptHTML is the layout of HTML receipts for restaurants without
+// one of their own.
+const DefaultReceiptHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.InvoiceNumber}}</title>
+<style>
+body { font-family: sans-serif; max-width: 24em; margin: 1em auto; }
+header, footer { text-align: center; }
+table { width: 100%; border-collapse: collapse; }
+td.amount { text-align: right; }
+tr.total td { font-weight: bold; border-top: 1px solid; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.RestaurantName}}</h1>
+<p>{{.RestaurantAddress}}{{if .RestaurantPhone}}<br>{{.RestaurantPhone}}{{end}}</p>
+</header>
+{{if .Voided}}<p><strong>VOID</strong></p>{{end}}
+<p>Invoice {{.InvoiceNumber}}<br>{{.IssuedAt.Format "2006-01-02 15:04"}}{{if .TableNumber}}<br>Table {{.TableNumber}}{{end}}</p>
+<table>
+{{range .Lines}}<tr><td>{{.Quantity}} x {{.Name}}{{if .Size}} ({{.Size}}){{end}}</td><td class="amount">{{.Amount}}</td></tr>
+{{end}}<tr><td>Subtotal</td><td class="amount">{{.Subtotal}}</td></tr>
+{{range .Discounts}}<tr><td>{{.Name}}</td><td class="amount">-{{.Amount}}</td></tr>
+{{end}}{{if .ServiceCharge}}<tr><td>Service charge {{.ServiceChargeRate}}%</td><td class="amount">{{.ServiceCharge}}</td></tr>
+{{end}}{{if .Gratuity}}<tr><td>Gratuity {{.GratuityRate}}%</td><td class="amount">{{.Gratuity}}</td></tr>
+{{end}}{{range .Taxes}}<tr><td>Tax {{.Rate}}%{{if .Category}} {{.Category}}{{end}} on {{.Taxable}}</td><td class="amount">{{.Tax}}</td></tr>
+{{end}}{{if .Rounding}}<tr><td>Rounding</td><td class="amount">{{.Rounding}}</td></tr>
+{{end}}<tr class="total"><td>Total {{.Currency}}</td><td class="amount">{{.Total}}</td></tr>
+{{if .Credited}}<tr><td>Credited</td><td class="amount">-{{.Credited}}</td></tr>
+{{end}}{{range .Payments}}<tr><td>Paid {{.Method}}</td><td class="amount">{{.Amount}}</td></tr>
+{{if .Tip}}<tr><td>Tip</td><td class="amount">{{.Tip}}</td></tr>
+{{end}}{{end}}<tr><td>Balance</td><td class="amount">{{.Balance}}</td></tr>
+{{if .TipLine}}<tr><td>Tip</td><td class="amount">__________</td></tr>
+<tr><td>Total</td><td class="amount">__________</td></tr>
+{{end}}</table>
+{{if .Footer}}<footer><p>{{.Footer}}</p></footer>{{end}}
+</body>
+</html>
+`
+
+// DefaultReceiptText is the layout of printed receipts for restaurants
+// without one of their own. Besides the template built-ins it can use center
+// to center a line, row to set a label and an amount apart, and rule for a
+// line of dashes.
+const DefaultReceiptText = `{{center .RestaurantName}}
+{{center .RestaurantAddress}}
+{{if .RestaurantPhone}}{{center .RestaurantPhone}}
+{{end}}{{rule}}
+{{if .Voided}}{{center "*** VOID ***"}}
+{{end}}{{row "Invoice" .InvoiceNumber}}
+{{row "Date" (.IssuedAt.Format "2006-01-02 15:04")}}
+{{if .TableNumber}}{{row "Table" .TableNumber}}
+{{end}}{{rule}}
+{{range .Lines}}{{row (printf "%d x %s" .Quantity .Name) .Amount}}
+{{end}}{{rule}}
+{{row "Subtotal" .Subtotal}}
+{{range .Discounts}}{{row .Name (printf "-%s" .Amount)}}
+{{end}}{{if .ServiceCharge}}{{row (printf "Service charge %g%%" .ServiceChargeRate) .ServiceCharge}}
+{{end}}{{if .Gratuity}}{{row (printf "Gratuity %g%%" .GratuityRate) .Gratuity}}
+{{end}}{{range .Taxes}}{{row (printf "Tax %g%% %s" .Rate .Category) .Tax}}
+{{end}}{{if .Rounding}}{{row "Rounding" .Rounding}}
+{{end}}{{row (printf "TOTAL %s" .Currency) .Total}}
+{{if .Credited}}{{row "Credited" (printf "-%s" .Credited)}}
+{{end}}{{range .Payments}}{{row (printf "Paid %s" .Method) .Amount}}
+{{if .Tip}}{{row "  Tip" .Tip}}
+{{end}}{{end}}{{row "Balance" .Balance}}
+{{if .TipLine}}
+{{row "Tip" "__________"}}
+
+{{row "Total" "__________"}}
+{{end}}{{if .Footer}}{{rule}}
+{{center .Footer}}
+{{end}}`
+
+var receiptFuncs = texttemplate.FuncMap{
+	"center": func(text string) string {
+		if pad := (ReceiptWidth - utf8.RuneCountInString(text)) / 2; pad > 0 {
+			return strings.Repeat(" ", pad) + text
+		}
+		return text
+	},
+	"row": func(label string, value interface{}) string {
+		text := fmt.Sprint(value)
+		pad := ReceiptWidth - utf8.RuneCountInString(label) - utf8.RuneCountInString(text)
+		if pad < 1 {
+			pad = 1
+		}
+		return label + strings.Repeat(" ", pad) + text
+	},
+	"rule": func() string { return strings.Repeat("-", ReceiptWidth) },
+}
+
+// ReceiptTemplates returns the HTML and printed layouts of receipts set in
+// settings, or the built-in ones where none is set.
+func ReceiptTemplates(settings *models.ReceiptSettings) (string, string) {
+	html, text := DefaultReceiptHTML, DefaultReceiptText
+	if settings != nil && settings.HTMLTemplate != "" {
+		html = settings.HTMLTemplate
+	}
+	if settings != nil && settings.TextTemplate != "" {
+		text = settings.TextTemplate
+	}
+	return html, text
+}
+
+// CheckReceiptTemplates returns why the receipt layouts in settings cannot
+// be used, if they cannot, by rendering a sample receipt with them.
+func CheckReceiptTemplates(settings *models.ReceiptSettings) error {
+	html, text := ReceiptTemplates(settings)
+	sample := models.Receipt{
+		Lines:    []models.ReceiptLine{{Name: "Sample", Quantity: 1}},
+		Taxes:    []models.TaxTotals{{}},
+		Payments: []models.Payment{{Method: models.PaymentCash}},
+		TipLine:  true,
+	}
+
+	if _, err := RenderReceiptHTML(html, sample); err != nil {
+		return fmt.Errorf("html receipt template: %w", err)
+	}
+	if _, err := RenderReceiptPDF(text, sample); err != nil {
+		return fmt.Errorf("text receipt template: %w", err)
+	}
+	return nil
+}
+
+// RenderReceiptHTML executes the HTML receipt layout layout on receipt.
+// Everything taken from the receipt is escaped.
+func RenderReceiptHTML(layout string, receipt models.Receipt) ([]byte, error) {
+	tmpl, err := htmltemplate.New("receipt").Option("missingkey=error").Parse(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, receipt); err != nil {
+		return nil, err
+	}
+	return rendered.Bytes(), nil
+}
+
+// RenderReceiptPDF executes the printed receipt layout layout on receipt and
+// prints the lines it makes to a PDF.
+func RenderReceiptPDF(layout string, receipt models.Receipt) ([]byte, error) {
+	tmpl, err := texttemplate.New("receipt").Funcs(receiptFuncs).Option("missingkey=error").Parse(layout)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, receipt); err != nil {
+		return nil, err
+	}
+	return TextPDF(strings.Split(strings.TrimRight(rendered.String(), "\n"), "\n")), nil
+}
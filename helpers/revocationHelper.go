@@ -0,0 +1,52 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RevokeToken adds the token to the revocation list until it expires.
+func RevokeToken(ctx context.Context, store *repository.Store, tokenId string, userId string, expiresAt time.Time) error {
+	revokedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	return store.RevokedTokens.Add(ctx, models.RevokedToken{
+		ID:        primitive.NewObjectID(),
+		TokenId:   tokenId,
+		UserId:    userId,
+		ExpiresAt: expiresAt,
+		RevokedAt: revokedAt,
+	})
+}
+
+// RevokeAllUserTokens ends every session of the user: all tokens issued up to
+// now stop being accepted and the stored refresh token is discarded.
+func RevokeAllUserTokens(ctx context.Context, store *repository.Store, userId string) error {
+	return store.Users.RevokeSessions(ctx, userId)
+}
+
+// IsTokenRevoked reports whether the token was revoked on its own or through
+// a revoke-all on its user.
+func IsTokenRevoked(ctx context.Context, store *repository.Store, claims *SignedDetails) (bool, error) {
+	revoked, err := store.RevokedTokens.Exists(ctx, claims.Id)
+	if err != nil {
+		return false, err
+	}
+	if revoked {
+		return true, nil
+	}
+
+	user, err := store.Users.Get(ctx, claims.Uid)
+	if err == repository.ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return claims.SessionEpoch < user.SessionEpoch, nil
+}
@@ -0,0 +1,31 @@
+package helpers
+
+import "sync"
+
+// Signal wakes everybody waiting on it whenever Notify is called. Take the
+// channel from Wait before checking for news, so a Notify in between is not
+// missed.
+type Signal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+func NewSignal() *Signal {
+	return &Signal{ch: make(chan struct{})}
+}
+
+// Wait returns a channel that is closed by the next Notify.
+func (s *Signal) Wait() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ch
+}
+
+func (s *Signal) Notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	close(s.ch)
+	s.ch = make(chan struct{})
+}
@@ -0,0 +1,12 @@
+package helpers
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// GetTenant returns the restaurant the request acts on. Authentication sets it
+// from the token, or from the X-Restaurant-Id header for owners. Every
+// repository call that reads or writes restaurant data must be given it.
+func GetTenant(c *gin.Context) string {
+	return c.GetString("restaurant_id")
+}
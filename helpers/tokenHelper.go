@@ -1,55 +1,81 @@
 package helpers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 
-	"github.com/golang-jwt/jwt"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
-
-	"context"
 	"log"
-	"os"
-	"restaurant-management-system/database"
+	"restaurant-management-system/config"
 	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
 )
 
 type SignedDetails struct {
-	Email     string
-	FirstName string
-	LastName  string
-	Uid       string
+	Email        string
+	FirstName    string
+	LastName     string
+	Uid          string
+	Role         string
+	RestaurantId string
+	TerminalId   string
+	SessionEpoch int
+	TokenType    string
 	jwt.StandardClaims
 }
 
-var userCollection *mongo.Collection = database.OpenCollection(database.Client, "user")
-var SECRET_KEY string = os.Getenv("SECRET_KEY")
+// GenerateAllTokens issues an access and a refresh token for the user. When
+// terminalId is set the access token is bound to that shared terminal and
+// only lives for the configured PIN token TTL. sessionEpoch is the current
+// SessionEpoch of the user.
+func GenerateAllTokens(email string, fistName string, lastName string, uid string, role string, restaurantId string, terminalId string, sessionEpoch int) (signedToken, refreshToken string, err error) {
+	now := time.Now().Local()
+
+	accessTTL := config.Get().AccessTokenTTL
+	if terminalId != "" {
+		accessTTL = config.Get().PinTokenTTL
+	}
 
-func GenerateAllTokens(email string, fistName string, lastName string, uid string) (signedToken, refreshToken string, err error) {
 	claims := &SignedDetails{
-		Email:     email,
-		FirstName: fistName,
-		LastName:  lastName,
-		Uid:       uid,
+		Email:        email,
+		FirstName:    fistName,
+		LastName:     lastName,
+		Uid:          uid,
+		Role:         role,
+		RestaurantId: restaurantId,
+		TerminalId:   terminalId,
+		SessionEpoch: sessionEpoch,
+		TokenType:    AccessTokenType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
+			Id:        newTokenId(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(accessTTL).Unix(),
 		},
 	}
 
 	refreshClaims := &SignedDetails{
+		Uid:       uid,
+		TokenType: RefreshTokenType,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Local().Add(time.Hour * time.Duration(24)).Unix(),
+			Id:        newTokenId(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(config.Get().RefreshTokenTTL).Unix(),
 		},
 	}
 
-	signedToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	signedToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(config.Get().SecretKey))
 	if err != nil {
 		log.Panic(err)
 		return "", "", err
 	}
-	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
+	refreshToken, err = jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(config.Get().SecretKey))
 	if err != nil {
 		log.Panic(err)
 		return "", "", err
@@ -58,59 +84,62 @@ func GenerateAllTokens(email string, fistName string, lastName string, uid strin
 	return signedToken, refreshToken, err
 }
 
-func UpdateAllTokens(signedToken string, signedRefreshToken string, userId string) {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-
-	var updateObj = primitive.D{}
-
-	updateObj = append(updateObj, primitive.E{Key: "signed_token", Value: signedToken})
-	updateObj = append(updateObj, primitive.E{Key: "signed_refresh_token", Value: signedRefreshToken})
-
-	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-	updateObj = append(updateObj, primitive.E{Key: "updated_at", Value: updatedAt})
-	filter := bson.M{"user_id": userId}
-	upsert := true
-	opt := options.UpdateOptions{
-		Upsert: &upsert,
-	}
-
-	_, err := userCollection.UpdateOne(
-		ctx,
-		filter,
-		bson.D{
-			primitive.E{Key: "$set", Value: updateObj},
-		},
-		&opt,
-	)
-	if err != nil {
-		log.Panic(err)
-		return
+func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
+	return validateToken(signedToken, AccessTokenType)
+}
 
-	}
+func ValidateRefreshToken(signedToken string) (claims *SignedDetails, msg string) {
+	return validateToken(signedToken, RefreshTokenType)
 }
 
-func ValidateToken(signedToken string) (claims *SignedDetails, msg string) {
+func validateToken(signedToken string, tokenType string) (claims *SignedDetails, msg string) {
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&SignedDetails{},
 		func(token *jwt.Token) (interface{}, error) {
-			return []byte(SECRET_KEY), nil
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+			}
+			return []byte(config.Get().SecretKey), nil
 		},
 	)
+	if err != nil {
+		return nil, err.Error()
+	}
 
 	claims, ok := token.Claims.(*SignedDetails)
-	if !ok {
-		msg = fmt.Sprintf("Invalid token claims")
-		msg = err.Error()
-		return nil, msg
+	if !ok || !token.Valid {
+		return nil, "Invalid token claims"
 	}
 
 	if claims.ExpiresAt < time.Now().Local().Unix() {
-		msg = fmt.Sprintf("Token expired")
-		msg = err.Error()
-		return nil, msg
+		return nil, "Token expired"
+	}
+
+	if claims.TokenType != tokenType {
+		return nil, fmt.Sprintf("Token is not a %s token", tokenType)
 	}
 
 	return claims, msg
 }
+
+// NewSecret returns a random, URL safe secret suitable for keys and one-time codes.
+func NewSecret() string {
+	return newTokenId() + newTokenId()
+}
+
+// HashSecret is used to store high entropy secrets such as reset tokens and
+// terminal keys; passwords and PINs go through bcrypt instead.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func newTokenId() string {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		log.Panic(err)
+	}
+
+	return hex.EncodeToString(bytes)
+}
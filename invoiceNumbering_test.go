@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestInvoicesAreNumberedWithoutGapsPerLocation(t *testing.T) {
+	s := newTestServer(t)
+	downtown, manager := s.staff(models.RoleManager)
+	owner := s.headersFor("", models.RoleOwner)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+downtown.RestaurantId, map[string]string{"invoice_prefix": "DT-1"}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+downtown.RestaurantId, map[string]interface{}{"invoice_prefix": "DT", "fiscal_year_start_month": 13}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+downtown.RestaurantId, map[string]string{"invoice_prefix": "DT"}, owner), http.StatusOK, nil)
+	steak := s.seedFood(downtown.RestaurantId, s.seedMenu(downtown.RestaurantId).MenuId, "Steak", "20")
+	table := s.seedTable(downtown.RestaurantId, 1)
+
+	const invoices = 25
+	orders := []models.Order{}
+	for i := 0; i < invoices; i++ {
+		order, _ := s.seedOrder(downtown.RestaurantId, table.TableId, steak)
+		orders = append(orders, order)
+	}
+
+	responses := make([]*httptest.ResponseRecorder, invoices)
+	var wg sync.WaitGroup
+	for i, order := range orders {
+		wg.Add(1)
+		go func(i int, orderId string) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, "/invoice", map[string]string{"order_id": orderId}, manager)
+		}(i, order.OrderId)
+	}
+	wg.Wait()
+
+	year := downtown.FiscalYear(time.Now())
+	issued := map[string]bool{}
+	for _, response := range responses {
+		var invoice models.Invoice
+		s.expect(response, http.StatusCreated, &invoice)
+		issued[invoice.InvoiceNumber] = true
+	}
+	for sequence := 1; sequence <= invoices; sequence++ {
+		if number := models.DocumentNumber("DT", year, sequence); !issued[number] {
+			t.Fatalf("expected %s among the invoices issued at once, got %v", number, issued)
+		}
+	}
+
+	uptown := s.seedRestaurant("Uptown")
+	order, _ := s.seedOrder(uptown.RestaurantId, s.seedTable(uptown.RestaurantId, 1).TableId, s.seedFood(uptown.RestaurantId, s.seedMenu(uptown.RestaurantId).MenuId, "Soup", "6"))
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, s.headersFor(uptown.RestaurantId, models.RoleCashier)), http.StatusCreated, &invoice)
+	if invoice.InvoiceNumber != models.DocumentNumber(models.DefaultInvoicePrefix, year, 1) {
+		t.Fatalf("expected every location to number its own invoices, got %s", invoice.InvoiceNumber)
+	}
+
+	april := models.Restaurant{FiscalYearStartMonth: 4, TimeZone: "Europe/London"}
+	if april.FiscalYear(time.Date(2026, 3, 31, 22, 0, 0, 0, time.UTC)) != 2025 || april.FiscalYear(time.Date(2026, 3, 31, 23, 30, 0, 0, time.UTC)) != 2026 {
+		t.Fatal("expected fiscal years to start in April, local time")
+	}
+}
+
+func TestIssuedInvoicesAreCorrectedByCreditNotes(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	cashier := s.headersFor(restaurant.RestaurantId, models.RoleCashier)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak, soup := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "6")
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	first, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+	second, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, soup)
+
+	var invoice, voided models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": first.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": second.OrderId}, cashier), http.StatusCreated, &voided)
+
+	path := "/invoice/" + invoice.InvoiceId
+	s.expect(s.do(http.MethodPatch, path, map[string]string{"order_id": second.OrderId}, cashier), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPatch, path, map[string]string{"invoice_number": "INV-1999-000001"}, cashier), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPatch, path, map[string]string{"payment_method": "CARD"}, cashier), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPost, path+"/credit-notes", map[string]string{"amount": "5", "reason": "Cold steak"}, cashier), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, path+"/credit-notes", map[string]string{"amount": "5"}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, path+"/credit-notes", map[string]string{"amount": "25", "reason": "Cold steak"}, manager), http.StatusBadRequest, nil)
+	var creditNote models.CreditNote
+	s.expect(s.do(http.MethodPost, path+"/credit-notes", map[string]string{"amount": "5", "reason": "Cold steak"}, manager), http.StatusCreated, &creditNote)
+	year := restaurant.FiscalYear(time.Now())
+	if creditNote.CreditNoteNumber != models.DocumentNumber(models.DefaultCreditNotePrefix, year, 1) || creditNote.InvoiceNumber != invoice.InvoiceNumber {
+		t.Fatalf("expected the first credit note against %s, got %+v", invoice.InvoiceNumber, creditNote)
+	}
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, path, nil, cashier), http.StatusOK, &view)
+	if view.InvoiceNumber != invoice.InvoiceNumber || view.AmountCredited != money("5") || view.Balance != money("15") || len(view.CreditNotes) != 1 {
+		t.Fatalf("expected the invoice to be credited 5, got %+v", view)
+	}
+	s.expect(s.do(http.MethodPost, path+"/payments", map[string]string{"method": "CASH", "amount": "15"}, cashier), http.StatusCreated, &invoice)
+	if *invoice.PaymentStatus != models.InvoicePaid {
+		t.Fatalf("expected the invoice to be paid once what is left is paid, got %s", *invoice.PaymentStatus)
+	}
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+voided.InvoiceId+"/void", map[string]string{"reason_code": "WALKOUT"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+voided.InvoiceId+"/credit-notes", map[string]string{"reason": "Again"}, manager), http.StatusConflict, nil)
+
+	var creditNotes []models.CreditNote
+	s.expect(s.do(http.MethodGet, "/credit-notes", nil, cashier), http.StatusOK, &creditNotes)
+	if len(creditNotes) != 2 || creditNotes[1].InvoiceId != voided.InvoiceId || creditNotes[1].Amount != money("6") || creditNotes[1].Sequence != 2 {
+		t.Fatalf("expected voiding to credit the whole invoice, got %+v", creditNotes)
+	}
+	s.expect(s.do(http.MethodGet, "/credit-notes/"+creditNotes[1].CreditNoteId, nil, cashier), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/credit-notes/unknown", nil, cashier), http.StatusNotFound, nil)
+
+	stored, err := s.store.Invoices.Get(context.Background(), restaurant.RestaurantId, voided.InvoiceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := json.Marshal(stored); stored.InvoiceNumber != voided.InvoiceNumber || stored.Credited != money("6") {
+		t.Fatalf("expected the voided invoice to keep its number, got %s", body)
+	}
+}
+
+func TestOrdersAreInvoicedOnce(t *testing.T) {
+	invoicesOrdersOnce(t, newTestServer(t))
+}
+
+// invoicesOrdersOnce invoices one order many times at once: only one invoice
+// is issued until it is voided.
+func invoicesOrdersOnce(t *testing.T, s *testServer) {
+	restaurant, manager := s.staff(models.RoleManager)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	const attempts = 10
+	responses := make([]*httptest.ResponseRecorder, attempts)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, manager)
+		}(i)
+	}
+	wg.Wait()
+
+	var invoice models.Invoice
+	issued := 0
+	for _, response := range responses {
+		if response.Code == http.StatusCreated {
+			s.expect(response, http.StatusCreated, &invoice)
+			issued++
+			continue
+		}
+		s.expect(response, http.StatusConflict, nil)
+	}
+	if issued != 1 {
+		t.Fatalf("expected the order to be invoiced once, got %d invoices", issued)
+	}
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]string{"reason_code": "WALKOUT"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, manager), http.StatusCreated, nil)
+}
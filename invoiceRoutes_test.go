@@ -0,0 +1,219 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestInvoiceLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 5).TableId, soup, steak)
+
+	var created models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &created)
+	if *created.PaymentStatus != "PENDING" {
+		t.Fatalf("expected a PENDING invoice, got %s", *created.PaymentStatus)
+	}
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, "/invoice/"+created.InvoiceId, nil, cashier), http.StatusOK, &view)
+	if view.PaymentDue != money("24.50") || view.PaymentMethod != "null" {
+		t.Fatalf("expected 24.50 due with no payment method, got %v / %s", view.PaymentDue, view.PaymentMethod)
+	}
+
+	s.expect(s.do(http.MethodPatch, "/invoice/"+created.InvoiceId, map[string]string{"payment_status": "PAID"}, cashier), http.StatusConflict, nil)
+	card := headers{"token": cashier["token"], "Idempotency-Key": "lifecycle"}
+	s.expect(s.do(http.MethodPost, "/invoice/"+created.InvoiceId+"/pay", map[string]string{"split": "ITEMS", "card_token": "tok_visa"}, card), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+created.InvoiceId+"/pay", map[string]string{"amount": "24.50", "card_token": "tok_visa"}, card), http.StatusCreated, nil)
+
+	var paid models.Invoice
+	s.expect(s.do(http.MethodPatch, "/invoice/"+created.InvoiceId, map[string]string{"payment_method": "CARD", "payment_status": "PAID"}, cashier), http.StatusOK, &paid)
+	if *paid.PaymentMethod != "CARD" || *paid.PaymentStatus != "PAID" {
+		t.Fatalf("expected a PAID card invoice, got %+v", paid)
+	}
+
+	var invoices []models.Invoice
+	s.expect(s.do(http.MethodGet, "/invoice", nil, cashier), http.StatusOK, &invoices)
+	if len(invoices) != 1 {
+		t.Fatalf("expected 1 invoice, got %d", len(invoices))
+	}
+}
+
+func TestInvoicesAreOnlyVoidedAsTheyWereRead(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var read models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &read)
+	s.expect(s.do(http.MethodPost, "/invoice/"+read.InvoiceId+"/payments", map[string]string{"amount": "5", "method": models.PaymentCash}, cashier), http.StatusCreated, nil)
+
+	voided, err := s.store.Invoices.Void(t.Context(), restaurant.RestaurantId, read.InvoiceId, len(read.Payments), read.Credited, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voided {
+		t.Fatal("expected an invoice paid since it was read to stay as it is")
+	}
+
+	s.expect(s.do(http.MethodPatch, "/invoice/"+read.InvoiceId, map[string]string{"payment_method": "CASH"}, cashier), http.StatusOK, nil)
+	stored, err := s.store.Invoices.Get(t.Context(), restaurant.RestaurantId, read.InvoiceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Payments) != 1 || *stored.PaymentStatus == models.InvoiceVoided {
+		t.Fatalf("expected the payment kept and the invoice not voided, got %+v", stored)
+	}
+}
+
+func TestInvoiceValidation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 5).TableId)
+
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": "missing"}, cashier), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId, "payment_status": "LATE"}, cashier), http.StatusBadRequest, nil)
+
+	var created models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &created)
+	s.expect(s.do(http.MethodPatch, "/invoice/"+created.InvoiceId, map[string]string{"payment_method": "CHEQUE"}, cashier), http.StatusBadRequest, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodPatch, "/invoice/"+created.InvoiceId, map[string]string{"payment_status": "PAID"}, waiter), http.StatusForbidden, nil)
+	cook := s.headersFor(restaurant.RestaurantId, models.RoleCook)
+	s.expect(s.do(http.MethodGet, "/invoice", nil, cook), http.StatusForbidden, nil)
+}
+
+func TestInvoicesAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, cashier := s.staff(models.RoleCashier)
+	other := s.seedRestaurant("Elsewhere")
+	order, _ := s.seedOrder(other.RestaurantId, s.seedTable(other.RestaurantId, 1).TableId)
+	otherCashier := s.headersFor(other.RestaurantId, models.RoleCashier)
+
+	var created models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, otherCashier), http.StatusCreated, &created)
+
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodGet, "/invoice/"+created.InvoiceId, nil, cashier), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/invoice/"+created.InvoiceId, map[string]string{"payment_status": "PAID"}, cashier), http.StatusNotFound, nil)
+}
+
+func TestSplitBillEvenly(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "5")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup, soup)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH"}, cashier), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]interface{}{"method": "CASH", "amount": "1", "split": "EVEN", "ways": 3}, cashier), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]interface{}{"method": "CASH", "split": "EVEN", "ways": 1}, cashier), http.StatusBadRequest, nil)
+
+	statuses := []string{models.InvoicePartiallyPaid, models.InvoicePartiallyPaid, models.InvoicePaid}
+	amounts := []string{"3.33", "3.34", "3.33"}
+	for i := range statuses {
+		var paid models.Invoice
+		s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]interface{}{"method": "CASH", "split": "EVEN", "ways": 3}, cashier), http.StatusCreated, &paid)
+		if *paid.PaymentStatus != statuses[i] || paid.Payments[i].Amount != money(amounts[i]) {
+			t.Fatalf("expected share %d to be %s and leave the invoice %s, got %+v", i+1, amounts[i], statuses[i], paid)
+		}
+	}
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]interface{}{"method": "CASH", "split": "EVEN", "ways": 3}, cashier), http.StatusConflict, nil)
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, cashier), http.StatusOK, &view)
+	if len(view.Payments) != 3 || view.AmountPaid != money("10") || view.Balance != 0 || *view.PaymentStatus != models.InvoicePaid {
+		t.Fatalf("expected 10 paid in 3 shares, got %+v", view)
+	}
+}
+
+func TestSplitBillBySeatAndItems(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	owner := s.headersFor("", models.RoleOwner)
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak, soup, wine := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Wine", "8")
+	order, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak, soup, wine)
+
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"pricing": map[string]interface{}{"default_tax_rate": 10}}, owner), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]int{"seat": 0}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]int{"seat": 101}, waiter), http.StatusBadRequest, nil)
+	for _, orderItem := range items[:2] {
+		s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItem.OrderItemId, map[string]int{"seat": 1}, waiter), http.StatusOK, nil)
+	}
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[2].OrderItemId, map[string]int{"seat": 2}, waiter), http.StatusOK, nil)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	if invoice.Totals.Total != money("35.75") {
+		t.Fatalf("expected 32.50 plus 10%% tax, got %v", invoice.Totals.Total)
+	}
+	pay := func(payment map[string]interface{}, status int) models.Invoice {
+		t.Helper()
+
+		var paid models.Invoice
+		s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", payment, cashier), status, &paid)
+		return paid
+	}
+
+	pay(map[string]interface{}{"method": "CASH", "split": "SEAT", "seat": 3}, http.StatusBadRequest)
+	paid := pay(map[string]interface{}{"method": "CASH", "split": "SEAT", "seat": 1}, http.StatusCreated)
+	if paid.Payments[0].Amount != money("26.95") || len(paid.Payments[0].OrderItemIds) != 2 || *paid.PaymentStatus != models.InvoicePartiallyPaid {
+		t.Fatalf("expected seat 1 to pay 24.50 plus tax for its 2 items, got %+v", paid.Payments)
+	}
+	pay(map[string]interface{}{"method": "CASH", "split": "SEAT", "seat": 1}, http.StatusConflict)
+	pay(map[string]interface{}{"method": "CASH", "split": "ITEMS", "order_item_ids": []string{items[1].OrderItemId}}, http.StatusConflict)
+	pay(map[string]interface{}{"method": "CASH", "split": "ITEMS", "order_item_ids": []string{"missing"}}, http.StatusBadRequest)
+	pay(map[string]interface{}{"method": "CASH", "amount": "9"}, http.StatusBadRequest)
+	pay(map[string]interface{}{"method": "CASH", "amount": "5"}, http.StatusCreated)
+
+	paid = pay(map[string]interface{}{"method": "CASH", "split": "ITEMS", "order_item_ids": []string{items[2].OrderItemId}}, http.StatusCreated)
+	if paid.Payments[2].Amount != money("3.80") || *paid.PaymentStatus != models.InvoicePaid {
+		t.Fatalf("expected the wine to settle the remaining 3.80, got %+v", paid)
+	}
+}
+
+func TestItemSplitsAddUpToTheInvoiceTotal(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	owner := s.headersFor("", models.RoleOwner)
+	mint := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Mint", "0.14")
+	order, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, mint, mint, mint)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"pricing": map[string]interface{}{"default_tax_rate": 10}}, owner), http.StatusOK, nil)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	if invoice.Totals.Tax != money("0.04") || invoice.Totals.Total != money("0.46") {
+		t.Fatalf("expected 0.42 plus 0.04 in tax, got %+v", invoice.Totals)
+	}
+
+	// taxed line by line, each mint would cost 0.15 and 0.01 would be left
+	// over once all three were paid for
+	paid := money("0")
+	for _, orderItem := range items {
+		var invoiceNow models.Invoice
+		s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]interface{}{"method": "CASH", "split": "ITEMS", "order_item_ids": []string{orderItem.OrderItemId}}, cashier), http.StatusCreated, &invoiceNow)
+		payment := invoiceNow.Payments[len(invoiceNow.Payments)-1]
+		if payment.Amount < money("0.15") || payment.Amount > money("0.16") {
+			t.Fatalf("expected every mint to pay its third of 0.46, got %v", payment.Amount)
+		}
+		paid += payment.Amount
+		invoice = invoiceNow
+	}
+	if paid != money("0.46") || *invoice.PaymentStatus != models.InvoicePaid {
+		t.Fatalf("expected the items to pay the 0.46 invoiced, got %v and %s", paid, *invoice.PaymentStatus)
+	}
+}
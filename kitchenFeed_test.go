@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"restaurant-management-system/models"
+)
+
+type feedEvent struct {
+	id    string
+	name  string
+	event models.KitchenEvent
+}
+
+// openFeed connects to the kitchen feed over a real HTTP server and returns
+// the events as they arrive. The connection closes with the test.
+func (s *testServer) openFeed(query string, requestHeaders headers) <-chan feedEvent {
+	s.t.Helper()
+
+	server := httptest.NewServer(s.router)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/kitchen/feed"+query, nil)
+	for key, value := range requestHeaders {
+		request.Header.Set(key, value)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if response.StatusCode != http.StatusOK {
+		s.t.Fatalf("expected the feed to open, got %d", response.StatusCode)
+	}
+
+	events := make(chan feedEvent, 100)
+	go func() {
+		defer response.Body.Close()
+		defer close(events)
+
+		var current feedEvent
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.event)
+			case line == "" && current.id != "":
+				events <- current
+				current = feedEvent{}
+			}
+		}
+	}()
+
+	return events
+}
+
+func (s *testServer) nextEvent(events <-chan feedEvent) feedEvent {
+	s.t.Helper()
+
+	select {
+	case event, open := <-events:
+		if !open {
+			s.t.Fatal("the feed closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("no kitchen event arrived")
+	}
+	return feedEvent{}
+}
+
+func (s *testServer) expectNoEvent(events <-chan feedEvent) {
+	s.t.Helper()
+
+	select {
+	case event := <-events:
+		s.t.Fatalf("expected no more events, got %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestKitchenFeedStreamsItemChanges(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	steak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	feed := s.openFeed("", waiter)
+
+	pack := map[string]interface{}{"TableId": table.TableId, "OrderItems": []map[string]interface{}{{"size": "M", "unit_price": 20, "food_id": steak.FoodId}}}
+	var created struct {
+		Order      models.Order       `json:"order"`
+		OrderItems []models.OrderItem `json:"order_items"`
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusCreated, &created)
+	orderItemId := created.OrderItems[0].OrderItemId
+
+	event := s.nextEvent(feed)
+	if event.name != models.KitchenItemCreated || event.event.OrderItem.OrderItemId != orderItemId || event.id != "1" {
+		t.Fatalf("expected the new item as event 1, got %+v", event)
+	}
+
+	s.expect(s.do(http.MethodPost, "/orderItems/"+orderItemId+"/bump", nil, waiter), http.StatusOK, nil)
+	event = s.nextEvent(feed)
+	if event.name != models.KitchenItemStatusChanged || event.event.OrderItem.Status != models.OrderItemCooking {
+		t.Fatalf("expected the item to be cooking, got %+v", event)
+	}
+
+	s.expect(s.do(http.MethodPost, "/orders/"+created.Order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusOK, nil)
+	event = s.nextEvent(feed)
+	if event.name != models.KitchenItemVoided || event.event.OrderItem.OrderItemId != orderItemId {
+		t.Fatalf("expected the item to be voided, got %+v", event)
+	}
+}
+
+func TestKitchenFeedFiltersByStationAndOrder(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cook := s.staff(models.RoleCook)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20", models.StationGrill)
+	fries := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Fries", "3", models.StationFryer)
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	_, first := s.seedOrder(restaurant.RestaurantId, table.TableId, steak, fries)
+	_, second := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+
+	grill := s.openFeed("?station="+models.StationGrill, cook)
+	secondOrder := s.openFeed("?order_id="+second[0].OrderId, cook)
+
+	for _, orderItem := range []models.OrderItem{first[1], first[0], second[0]} {
+		s.expect(s.do(http.MethodPost, "/orderItems/"+orderItem.OrderItemId+"/bump", nil, cook), http.StatusOK, nil)
+	}
+
+	if event := s.nextEvent(grill); event.event.OrderItem.OrderItemId != first[0].OrderItemId {
+		t.Fatalf("expected the first steak on the grill feed, got %+v", event)
+	}
+	if event := s.nextEvent(grill); event.event.OrderItem.OrderItemId != second[0].OrderItemId {
+		t.Fatalf("expected the second steak on the grill feed, got %+v", event)
+	}
+	s.expectNoEvent(grill)
+
+	if event := s.nextEvent(secondOrder); event.event.OrderItem.OrderItemId != second[0].OrderItemId {
+		t.Fatalf("expected only the second order, got %+v", event)
+	}
+	s.expectNoEvent(secondOrder)
+}
+
+func TestKitchenFeedResumesAfterTheLastSeenEvent(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cook := s.staff(models.RoleCook)
+	steak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	_, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+	path := "/orderItems/" + items[0].OrderItemId + "/bump"
+
+	s.expect(s.do(http.MethodPost, path, nil, cook), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, path, nil, cook), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, path, nil, cook), http.StatusOK, nil)
+
+	resumed := s.openFeed("", headers{"token": cook["token"], "Last-Event-ID": "1"})
+	for _, status := range []string{models.OrderItemReady, models.OrderItemServed} {
+		if event := s.nextEvent(resumed); event.event.OrderItem.Status != status {
+			t.Fatalf("expected the missed %s event, got %+v", status, event)
+		}
+	}
+	s.expectNoEvent(resumed)
+
+	fresh := s.openFeed("", cook)
+	s.expectNoEvent(fresh)
+
+	s.expect(s.do(http.MethodGet, "/kitchen/feed?last_event_id=soon", nil, cook), http.StatusBadRequest, nil)
+}
+
+func TestKitchenFeedIsScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	other := s.seedRestaurant("Elsewhere")
+	steak := s.seedStationFood(other.RestaurantId, s.seedMenu(other.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	_, items := s.seedOrder(other.RestaurantId, s.seedTable(other.RestaurantId, 1).TableId, steak)
+	otherCook := s.headersFor(other.RestaurantId, models.RoleCook)
+
+	var screen createdDevice
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrdersRead}}, manager), http.StatusCreated, &screen)
+	feed := s.openFeed("?last_event_id=0", headers{"X-Device-Key": screen.DeviceKey})
+
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[0].OrderItemId+"/bump", nil, otherCook), http.StatusOK, nil)
+	s.expectNoEvent(feed)
+
+	ownSteak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	_, own := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, ownSteak)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+own[0].OrderItemId+"/bump", nil, manager), http.StatusOK, nil)
+	if event := s.nextEvent(feed); event.event.OrderItem.OrderItemId != own[0].OrderItemId || event.id != "1" {
+		t.Fatalf("expected our own first event, got %+v", event)
+	}
+}
@@ -1,39 +1,73 @@
 package main
 
 import (
-	"os"
+	"context"
+	"log"
+	"restaurant-management-system/config"
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/helpers"
 	"restaurant-management-system/middleware"
+	"restaurant-management-system/repository"
+	"restaurant-management-system/repository/mongodb"
 	"restaurant-management-system/routes"
+	"time"
 
 	"restaurant-management-system/database"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-var foodCollection *mongo.Collection = database.OpenCollection(database.Client, "foods")
-
 func main() {
-	port := os.Getenv("PORT")
+	cfg := config.Get()
 
-	if port == "" {
-		port = "8000"
+	client, err := database.DBInstance(cfg.MongoURI)
+	if err != nil {
+		log.Fatal(err)
 	}
+	db := client.Database(cfg.MongoDatabase)
 
-	router := gin.New()
-	router.Use(gin.Logger())
-	routes.UserRoutes(router)
-	router.Use(middleware.Authentication())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := mongodb.EnsureIndexes(ctx, db); err != nil {
+		log.Fatal("could not create the indexes: ", err)
+	}
+	if err := mongodb.Migrate(ctx, db); err != nil {
+		log.Fatal("could not migrate the database: ", err)
+	}
+	cancel()
 
-	routes.FoodRoutes(router)
-	routes.MenuRoutes(router)
-	routes.TableRoutes(router)
-	routes.OrderRoutes(router)
-	routes.OrderItemRoutes(router)
-	routes.InvoiceRoutes(router)
+	router := setupRouter(mongodb.NewStore(db), helpers.NewNotifier(cfg), helpers.NewPaymentGateway(cfg))
 
-	err := router.Run(":" + port)
+	err = router.Run(":" + cfg.Port)
 	if err != nil {
 		return
 	}
 }
+
+// setupRouter wires every route against store, so the same router can be
+// served from Mongo or from memory.
+func setupRouter(store *repository.Store, notifier helpers.Notifier, gateway helpers.PaymentGateway) *gin.Engine {
+	ctl := controllers.New(store, notifier, gateway)
+	authenticate := middleware.Authentication(store)
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	routes.UserRoutes(router, ctl, authenticate)
+	router.Use(authenticate)
+	routes.RestaurantRoutes(router, ctl)
+	router.Use(middleware.RequireTenant())
+
+	routes.FoodRoutes(router, ctl)
+	routes.MenuRoutes(router, ctl)
+	routes.TableRoutes(router, ctl)
+	routes.OrderRoutes(router, ctl)
+	routes.OrderItemRoutes(router, ctl)
+	routes.InvoiceRoutes(router, ctl)
+	routes.TerminalRoutes(router, ctl)
+	routes.DeviceRoutes(router, ctl)
+	routes.PromotionRoutes(router, ctl)
+	routes.ReversalRoutes(router, ctl)
+	routes.ShiftRoutes(router, ctl)
+	routes.CreditNoteRoutes(router, ctl)
+
+	return router
+}
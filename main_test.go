@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"restaurant-management-system/config"
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/database"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"restaurant-management-system/repository/memory"
+	"restaurant-management-system/repository/mongodb"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testPassword = "secret-password"
+
+func TestMain(m *testing.M) {
+	cfg := config.Defaults()
+	cfg.SecretKey = "test-secret"
+	cfg.BcryptCost = bcrypt.MinCost
+	cfg.PinBcryptCost = bcrypt.MinCost
+	config.Use(&cfg)
+
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// testServer is the router from main.go running against a fresh in-memory
+// store, with helpers to seed fixtures and send requests.
+type testServer struct {
+	t        *testing.T
+	router   *gin.Engine
+	store    *repository.Store
+	notifier *recordingNotifier
+	gateway  *helpers.SimulatedGateway
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	store := memory.NewStore()
+	notifier := &recordingNotifier{}
+
+	gateway := helpers.NewSimulatedGateway(helpers.DefaultSimulatorScenarios(), 50*time.Millisecond)
+
+	return &testServer{t: t, router: setupRouter(store, notifier, gateway), store: store, notifier: notifier, gateway: gateway}
+}
+
+// newMongoTestServer is newTestServer against a throwaway database on the
+// MongoDB server at TEST_MONGODB_URI. Without one the test is skipped.
+func newMongoTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	uri := os.Getenv("TEST_MONGODB_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGODB_URI is not set")
+	}
+	client, err := database.DBInstance(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database("restaurant_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	})
+	if err := mongodb.EnsureIndexes(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t)
+	s.store = mongodb.NewStore(db)
+	s.router = setupRouter(s.store, s.notifier, s.gateway)
+	return s
+}
+
+type headers map[string]string
+
+// do sends a request with body encoded as JSON, unless it is already a string.
+func (s *testServer) do(method string, path string, body interface{}, requestHeaders headers) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var payload []byte
+	switch body := body.(type) {
+	case nil:
+	case string:
+		payload = []byte(body)
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+
+	request := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range requestHeaders {
+		request.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// expect fails the test unless the response has the given status, and
+// decodes the body into into when it is not nil.
+func (s *testServer) expect(response *httptest.ResponseRecorder, status int, into interface{}) {
+	s.t.Helper()
+
+	if response.Code != status {
+		s.t.Fatalf("expected status %d, got %d: %s", status, response.Code, response.Body.String())
+	}
+	if into != nil {
+		if err := json.Unmarshal(response.Body.Bytes(), into); err != nil {
+			s.t.Fatalf("decoding %s: %v", response.Body.String(), err)
+		}
+	}
+}
+
+func (s *testServer) seedRestaurant(name string) models.Restaurant {
+	s.t.Helper()
+
+	address := "1 Main Street"
+	restaurant := models.Restaurant{ID: primitive.NewObjectID(), Name: &name, Address: &address, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	restaurant.RestaurantId = restaurant.ID.Hex()
+	if err := s.store.Restaurants.Create(context.Background(), restaurant); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return restaurant
+}
+
+// seedUser stores a user with testPassword and returns it together with an
+// access token for it.
+func (s *testServer) seedUser(restaurantId string, role string) (models.User, string) {
+	s.t.Helper()
+
+	id := primitive.NewObjectID()
+	firstName, lastName := "Test", strings.ToLower(role)
+	email := id.Hex() + "@example.com"
+	phone := id.Hex()
+	password := controllers.HashPassword(testPassword)
+	user := models.User{
+		ID:           id,
+		FirstName:    &firstName,
+		LastName:     &lastName,
+		Email:        &email,
+		Password:     &password,
+		Phone:        &phone,
+		Role:         &role,
+		CreatedAt:    time.Now().Add(-time.Hour),
+		UpdatedAt:    time.Now().Add(-time.Hour),
+		UserId:       id.Hex(),
+		RestaurantId: restaurantId,
+	}
+	if err := s.store.Users.Create(context.Background(), user); err != nil {
+		s.t.Fatal(err)
+	}
+
+	token, refreshToken, err := helpers.GenerateAllTokens(email, firstName, lastName, user.UserId, role, restaurantId, "", user.SessionEpoch)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	if err := s.store.Users.SetTokens(context.Background(), user.UserId, token, refreshToken); err != nil {
+		s.t.Fatal(err)
+	}
+	user.Token, user.RefreshToken = &token, &refreshToken
+
+	return user, token
+}
+
+// staff seeds a restaurant and returns request headers for a user holding
+// role in it.
+func (s *testServer) staff(role string) (models.Restaurant, headers) {
+	s.t.Helper()
+
+	restaurant := s.seedRestaurant("Test Bistro")
+	_, token := s.seedUser(restaurant.RestaurantId, role)
+
+	return restaurant, headers{"token": token}
+}
+
+func (s *testServer) headersFor(restaurantId string, role string) headers {
+	s.t.Helper()
+
+	_, token := s.seedUser(restaurantId, role)
+	return headers{"token": token}
+}
+
+func (s *testServer) seedMenu(restaurantId string) models.Menu {
+	s.t.Helper()
+
+	start, end := time.Now().Add(time.Hour), time.Now().Add(30*24*time.Hour)
+	menu := models.Menu{ID: primitive.NewObjectID(), Name: "Lunch", Category: "Mains", StartDate: &start, EndDate: &end, RestaurantId: restaurantId}
+	menu.MenuId = menu.ID.Hex()
+	if err := s.store.Menus.Create(context.Background(), menu); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return menu
+}
+
+func (s *testServer) seedFood(restaurantId string, menuId string, name string, price string) models.Food {
+	s.t.Helper()
+
+	return s.seedStationFood(restaurantId, menuId, name, price, "")
+}
+
+// seedStationFood stores a food prepared at station, or at none when station
+// is empty.
+func (s *testServer) seedStationFood(restaurantId string, menuId string, name string, amount string, station string) models.Food {
+	s.t.Helper()
+
+	image, price := "https://example.com/"+name+".png", money(amount)
+	food := models.Food{ID: primitive.NewObjectID(), Name: &name, Price: &price, FoodImage: &image, MenuId: &menuId, RestaurantId: restaurantId}
+	if station != "" {
+		food.Station = &station
+	}
+	food.FoodId = food.ID.Hex()
+	if err := s.store.Foods.Create(context.Background(), food); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return food
+}
+
+// money reads an amount written out in a test, such as "4.50".
+func money(amount string) models.Money {
+	parsed, err := models.ParseMoney(amount)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func (s *testServer) seedTable(restaurantId string, number int) models.Table {
+	s.t.Helper()
+
+	guests := 4
+	table := models.Table{ID: primitive.NewObjectID(), NumberOfGuests: &guests, TableNumber: &number, RestaurantId: restaurantId}
+	table.TableId = table.ID.Hex()
+	if err := s.store.Tables.Create(context.Background(), table); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return table
+}
+
+// seedOrder stores an order at table with one item per food.
+func (s *testServer) seedOrder(restaurantId string, tableId string, foods ...models.Food) (models.Order, []models.OrderItem) {
+	s.t.Helper()
+
+	order := models.Order{ID: primitive.NewObjectID(), OrderDate: time.Now(), CreatedAt: time.Now(), TableId: &tableId, RestaurantId: restaurantId}
+	order.OrderId = order.ID.Hex()
+	if err := s.store.Orders.Create(context.Background(), order); err != nil {
+		s.t.Fatal(err)
+	}
+
+	orderItems := []models.OrderItem{}
+	for _, food := range foods {
+		size, quantity, foodId := "M", 1, food.FoodId
+		orderItem := models.OrderItem{ID: primitive.NewObjectID(), Size: &size, Quantity: &quantity, UnitPrice: food.Price, FoodId: &foodId, OrderId: order.OrderId, RestaurantId: restaurantId, CreatedAt: time.Now()}
+		if food.Station != nil {
+			orderItem.Station = *food.Station
+		}
+		orderItem.OrderItemId = orderItem.ID.Hex()
+		orderItems = append(orderItems, orderItem)
+	}
+	if err := s.store.OrderItems.CreateMany(context.Background(), orderItems); err != nil {
+		s.t.Fatal(err)
+	}
+
+	return order, orderItems
+}
+
+// recordingNotifier keeps the notifications instead of delivering them.
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications []helpers.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification helpers.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func (n *recordingNotifier) last() (helpers.Notification, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.notifications) == 0 {
+		return helpers.Notification{}, false
+	}
+	return n.notifications[len(n.notifications)-1], true
+}
+
+func TestEveryProtectedRouteRequiresAToken(t *testing.T) {
+	s := newTestServer(t)
+	public := map[string]bool{
+		"POST /users/signup":          true,
+		"POST /users/signin":          true,
+		"POST /users/refresh":         true,
+		"POST /users/pin-login":       true,
+		"POST /users/password/forgot": true,
+		"POST /users/password/reset":  true,
+	}
+
+	for _, route := range s.router.Routes() {
+		name := route.Method + " " + route.Path
+		if public[name] {
+			continue
+		}
+
+		t.Run(name, func(t *testing.T) {
+			response := s.do(route.Method, route.Path, nil, nil)
+			if response.Code != http.StatusUnauthorized {
+				t.Fatalf("expected 401 without a token, got %d", response.Code)
+			}
+
+			response = s.do(route.Method, route.Path, nil, headers{"token": "not-a-jwt"})
+			if response.Code != http.StatusUnauthorized {
+				t.Fatalf("expected 401 with a malformed token, got %d", response.Code)
+			}
+		})
+	}
+}
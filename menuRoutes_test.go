@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"restaurant-management-system/models"
+)
+
+func TestMenuLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	start, end := time.Now().Add(time.Hour), time.Now().Add(48*time.Hour)
+
+	var created models.Menu
+	s.expect(s.do(http.MethodPost, "/menus", map[string]interface{}{"name": "Brunch", "category": "Weekend", "start_date": start, "end_date": end}, manager), http.StatusCreated, &created)
+	if created.RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected the menu in %s, got %s", restaurant.RestaurantId, created.RestaurantId)
+	}
+
+	var menus []models.Menu
+	s.expect(s.do(http.MethodGet, "/menus", nil, manager), http.StatusOK, &menus)
+	if len(menus) != 1 {
+		t.Fatalf("expected 1 menu, got %d", len(menus))
+	}
+
+	s.expect(s.do(http.MethodGet, "/menus/"+created.MenuId, nil, manager), http.StatusOK, nil)
+
+	var updated models.Menu
+	s.expect(s.do(http.MethodPatch, "/menus/"+created.MenuId, map[string]interface{}{"name": "Late brunch"}, manager), http.StatusOK, &updated)
+	if updated.Name != "Late brunch" || updated.Category != "Weekend" {
+		t.Fatalf("expected only the name to change, got %s / %s", updated.Name, updated.Category)
+	}
+}
+
+func TestUpdateMenuValidatesTheDateRange(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	s.expect(s.do(http.MethodPatch, "/menus/"+menu.MenuId, map[string]interface{}{"start_date": future}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/menus/"+menu.MenuId, map[string]interface{}{"start_date": past, "end_date": future}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/menus/"+menu.MenuId, map[string]interface{}{"start_date": future, "end_date": future.Add(time.Hour)}, manager), http.StatusOK, nil)
+}
+
+func TestCreateMenuValidation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+
+	s.expect(s.do(http.MethodPost, "/menus", map[string]interface{}{"name": "Brunch"}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/menus", "[]", manager), http.StatusBadRequest, nil)
+
+	cook := s.headersFor(restaurant.RestaurantId, models.RoleCook)
+	s.expect(s.do(http.MethodGet, "/menus", nil, cook), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/menus", map[string]interface{}{"name": "Brunch", "category": "Weekend"}, cook), http.StatusForbidden, nil)
+}
+
+func TestMenusAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(s.seedRestaurant("Elsewhere").RestaurantId)
+
+	s.expect(s.do(http.MethodGet, "/menus/"+menu.MenuId, nil, manager), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/menus/"+menu.MenuId, map[string]interface{}{"name": "Mine now"}, manager), http.StatusNotFound, nil)
+}
@@ -1,25 +1,63 @@
 package middleware
 
 import (
-	"fmt"
+	"context"
 	"net/http"
+	"restaurant-management-system/config"
 	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Authentication() gin.HandlerFunc {
+// Authentication accepts either a user JWT in the token header or, for kitchen
+// screens and printers, a device key in the X-Device-Key header. It also
+// decides which restaurant the request is scoped to.
+func Authentication(store *repository.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		var ctx, cancel = context.WithTimeout(context.Background(), config.Get().RequestTimeout)
+		defer cancel()
+
+		if deviceKey := c.Request.Header.Get("X-Device-Key"); deviceKey != "" {
+			authenticateDevice(ctx, c, store, deviceKey)
+			return
+		}
+
 		clientToken := c.Request.Header.Get("token")
 		if clientToken == "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("No token found in header")})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No token found in header"})
 			c.Abort()
 			return
 		}
 
 		claims, err := helpers.ValidateToken(clientToken)
 		if err != "" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+			c.Abort()
+			return
+		}
+
+		revoked, revokedErr := helpers.IsTokenRevoked(ctx, store, claims)
+		if revokedErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while checking the token"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		restaurantId, restaurantErr := restaurantFor(ctx, c, store, claims)
+		if restaurantErr == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "restaurant not found"})
+			c.Abort()
+			return
+		}
+		if restaurantErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "error occurred while fetching the restaurant"})
 			c.Abort()
 			return
 		}
@@ -28,6 +66,57 @@ func Authentication() gin.HandlerFunc {
 		c.Set("first_name", claims.FirstName)
 		c.Set("last_name", claims.LastName)
 		c.Set("uid", claims.Uid)
+		c.Set("role", claims.Role)
+		c.Set("restaurant_id", restaurantId)
+		c.Set("terminal_id", claims.TerminalId)
+		c.Set("token_id", claims.Id)
+		c.Set("token_expires_at", claims.ExpiresAt)
+
+		c.Next()
+	}
+}
+
+func authenticateDevice(ctx context.Context, c *gin.Context, store *repository.Store, deviceKey string) {
+	device, err := helpers.ValidateDeviceKey(ctx, store.Devices, deviceKey)
+	if err != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+		c.Abort()
+		return
+	}
+
+	c.Set("role", models.RoleDevice)
+	c.Set("device_id", device.DeviceId)
+	c.Set("restaurant_id", device.RestaurantId)
+	c.Set("scopes", device.Scopes)
+
+	c.Next()
+}
+
+// restaurantFor returns the restaurant from the token. Owners are not tied to
+// one location and pick the one they work on with the X-Restaurant-Id header,
+// which must name an existing restaurant.
+func restaurantFor(ctx context.Context, c *gin.Context, store *repository.Store, claims *helpers.SignedDetails) (string, error) {
+	restaurantId := c.Request.Header.Get("X-Restaurant-Id")
+	if claims.Role != models.RoleOwner || restaurantId == "" {
+		return claims.RestaurantId, nil
+	}
+	if _, err := store.Restaurants.Get(ctx, restaurantId); err != nil {
+		return "", err
+	}
+
+	return restaurantId, nil
+}
+
+// RequireTenant refuses requests that are not scoped to a restaurant, as
+// those of owners who did not pick one are not. It must run after
+// Authentication.
+func RequireTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if helpers.GetTenant(c) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "X-Restaurant-Id header is required"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
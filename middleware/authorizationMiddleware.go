@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize only lets the request through when the role carried in the token
+// is one of roles. It must run after Authentication. Devices are always
+// refused; routes they may call use AuthorizeScope instead.
+func Authorize(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := helpers.CheckUserRole(c, roles...); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthorizeScope behaves like Authorize for users and additionally admits
+// devices that were granted scope.
+func AuthorizeScope(scope string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var err error
+		if c.GetString("role") == models.RoleDevice {
+			err = helpers.CheckDeviceScope(c, scope)
+		} else {
+			err = helpers.CheckUserRole(c, roles...)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
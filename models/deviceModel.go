@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RoleDevice is the role given to requests authenticated with a device key.
+// It is never assigned to a user.
+const RoleDevice = "DEVICE"
+
+const (
+	DeviceKitchenDisplay = "KITCHEN_DISPLAY"
+	DeviceReceiptPrinter = "RECEIPT_PRINTER"
+)
+
+const (
+	ScopeOrdersRead             = "orders:read"
+	ScopeOrderItemsUpdateStatus = "order_items:update_status"
+	ScopeInvoicesRead           = "invoices:read"
+)
+
+type Device struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Name         *string            `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Type         *string            `bson:"type" json:"type" validate:"required,eq=KITCHEN_DISPLAY|eq=RECEIPT_PRINTER"`
+	Scopes       []string           `bson:"scopes" json:"scopes" validate:"required,min=1,dive,eq=orders:read|eq=order_items:update_status|eq=invoices:read"`
+	KeyHash      string             `bson:"key_hash" json:"-"`
+	RevokedAt    *time.Time         `bson:"revoked_at" json:"revoked_at"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	DeviceId     string             `bson:"device_id" json:"device_id"`
+	RestaurantId string             `bson:"restaurant_id" json:"restaurant_id"`
+}
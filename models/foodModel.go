@@ -6,13 +6,28 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	StationGrill  = "GRILL"
+	StationFryer  = "FRYER"
+	StationBar    = "BAR"
+	StationPastry = "PASTRY"
+)
+
+// Stations lists the kitchen stations a food can be prepared at.
+var Stations = []string{StationGrill, StationFryer, StationBar, StationPastry}
+
 type Food struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Name      *string            `json:"name" validate:"required,min=2,max=100"`
-	Price     *float64           `json:"price" validate:"required,min=0,max=100000"`
-	FoodImage *string            `json:"food_image" validate:"required,min=2,max=1000"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	FoodId    string             `json:"food_id"`
-	MenuId    *string            `json:"menu_id" validate:"required"`
+	ID             primitive.ObjectID `bson:"_id"`
+	Name           *string            `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Price          *Money             `bson:"price" json:"price" validate:"required,min=0,max=10000000"`
+	FoodImage      *string            `bson:"food_image" json:"food_image" validate:"required,min=2,max=1000"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	FoodId         string             `bson:"food_id" json:"food_id"`
+	MenuId         *string            `bson:"menu_id" json:"menu_id" validate:"required"`
+	Station        *string            `bson:"station" json:"station" validate:"omitempty,eq=GRILL|eq=FRYER|eq=BAR|eq=PASTRY"`
+	Variants       []FoodVariant      `bson:"variants" json:"variants" validate:"dive"`
+	Category       *string            `bson:"category" json:"category" validate:"omitempty,min=2,max=50"`
+	ModifierGroups []ModifierGroup    `bson:"modifier_groups" json:"modifier_groups" validate:"dive"`
+	RestaurantId   string             `bson:"restaurant_id" json:"restaurant_id"`
 }
@@ -1,18 +1,66 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	InvoicePending       = "PENDING"
+	InvoicePartiallyPaid = "PARTIALLY_PAID"
+	InvoicePaid          = "PAID"
+	InvoiceVoided        = "VOIDED"
+)
+
+const (
+	DefaultInvoicePrefix    = "INV"
+	DefaultCreditNotePrefix = "CN"
+)
+
+// Invoice is what an order is billed with. InvoiceNumber is the number it was
+// issued under, in sequence within its restaurant and fiscal year. Once
+// issued, an invoice is corrected by credit notes only, which Credited sums.
 type Invoice struct {
 	ID             primitive.ObjectID `bson:"_id"`
-	InvoiceId      string             `json:"invoice_id"`
-	OrderId        string             `json:"order_id"`
-	PaymentMethod  *string            `json:"payment_method" validate:"eq=CASH|eq=CARD!|eq="`
-	PaymentStatus  *string            `json:"payment_status" validate:"required,eq=PENDING|eq=PAID"`
-	PaymentDueDate time.Time          `json:"payment_due_date"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
+	InvoiceId      string             `bson:"invoice_id" json:"invoice_id"`
+	InvoiceNumber  string             `bson:"invoice_number" json:"invoice_number"`
+	FiscalYear     int                `bson:"fiscal_year" json:"fiscal_year"`
+	Sequence       int                `bson:"sequence" json:"sequence"`
+	Credited       Money              `bson:"credited" json:"credited"`
+	OrderId        string             `bson:"order_id" json:"order_id"`
+	PaymentMethod  *string            `bson:"payment_method" json:"payment_method" validate:"omitempty,eq=CASH|eq=CARD"`
+	PaymentStatus  *string            `bson:"payment_status" json:"payment_status" validate:"required,eq=PENDING|eq=PARTIALLY_PAID|eq=PAID|eq=VOIDED"`
+	PaymentDueDate time.Time          `bson:"payment_due_date" json:"payment_due_date"`
+	Totals         *OrderTotals       `bson:"totals" json:"totals"`
+	Payments       []Payment          `bson:"payments" json:"payments"`
+	// CardTransactions are every card payment attempt, declined or not.
+	CardTransactions []CardTransaction `bson:"card_transactions" json:"card_transactions"`
+	CreatedAt        time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time         `bson:"updated_at" json:"updated_at"`
+	RestaurantId     string            `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// CreditNote corrects an issued invoice by crediting Amount back. It is
+// numbered like invoices are, in a sequence of its own.
+type CreditNote struct {
+	ID               primitive.ObjectID `bson:"_id"`
+	CreditNoteId     string             `bson:"credit_note_id" json:"credit_note_id"`
+	CreditNoteNumber string             `bson:"credit_note_number" json:"credit_note_number"`
+	FiscalYear       int                `bson:"fiscal_year" json:"fiscal_year"`
+	Sequence         int                `bson:"sequence" json:"sequence"`
+	InvoiceId        string             `bson:"invoice_id" json:"invoice_id"`
+	InvoiceNumber    string             `bson:"invoice_number" json:"invoice_number"`
+	Amount           Money              `bson:"amount" json:"amount" validate:"gt=0"`
+	Reason           string             `bson:"reason" json:"reason" validate:"required,min=2,max=500"`
+	IssuedBy         string             `bson:"issued_by" json:"issued_by"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	RestaurantId     string             `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// DocumentNumber is how invoice and credit note numbers read, such as
+// INV-2026-000042.
+func DocumentNumber(prefix string, fiscalYear int, sequence int) string {
+	return fmt.Sprintf("%s-%d-%06d", prefix, fiscalYear, sequence)
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	KitchenItemCreated       = "order_item.created"
+	KitchenItemStatusChanged = "order_item.status_changed"
+	KitchenItemVoided        = "order_item.voided"
+)
+
+// KitchenEvent records a change kitchen screens need to see. Sequence counts
+// up per restaurant and is the event ID screens resume the feed from.
+type KitchenEvent struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	Sequence     int64              `bson:"sequence" json:"sequence"`
+	Type         string             `bson:"type" json:"type"`
+	OrderItem    OrderItem          `bson:"order_item" json:"order_item"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt    time.Time          `bson:"expires_at" json:"-"`
+	RestaurantId string             `bson:"restaurant_id" json:"restaurant_id"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LoginAttempt struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Key           string             `bson:"key" json:"key"`
+	Failures      int                `bson:"failures" json:"failures"`
+	LockedUntil   *time.Time         `bson:"locked_until" json:"locked_until"`
+	LastFailureAt time.Time          `bson:"last_failure_at" json:"last_failure_at"`
+	// Sources are the keys of the IPs the failures came from, which are
+	// cleared too when the account is unlocked.
+	Sources []string `bson:"sources" json:"sources"`
+}
@@ -7,12 +7,13 @@ import (
 )
 
 type Menu struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Name      string             `json:"name" validate:"required,min=2,max=100"`
-	Category  string             `json:"category" validate:"required"`
-	StartDate *time.Time         `json:"start_date" validate:"required"`
-	EndDate   *time.Time         `json:"end_date" validate:"required"`
-	CreatedAt time.Time          `json:"created_at" validate:"required"`
-	UpdatedAt time.Time          `json:"updated_at" validate:"required"`
-	MenuId    string             `json:"menu_id"`
+	ID           primitive.ObjectID `bson:"_id"`
+	Name         string             `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Category     string             `bson:"category" json:"category" validate:"required"`
+	StartDate    *time.Time         `bson:"start_date" json:"start_date" validate:"required"`
+	EndDate      *time.Time         `bson:"end_date" json:"end_date" validate:"required"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	MenuId       string             `bson:"menu_id" json:"menu_id"`
+	RestaurantId string             `bson:"restaurant_id" json:"restaurant_id"`
 }
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModifierGroup is a choice offered with a food, such as "Doneness" or
+// "Extras". Guests pick between MinSelections and MaxSelections of its
+// options; a required group needs at least one.
+type ModifierGroup struct {
+	GroupId       string           `bson:"group_id" json:"group_id"`
+	Name          string           `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Required      bool             `bson:"required" json:"required"`
+	MinSelections int              `bson:"min_selections" json:"min_selections" validate:"min=0"`
+	MaxSelections int              `bson:"max_selections" json:"max_selections" validate:"min=1"`
+	Options       []ModifierOption `bson:"options" json:"options" validate:"required,min=1,dive"`
+}
+
+type ModifierOption struct {
+	OptionId   string `bson:"option_id" json:"option_id"`
+	Name       string `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	PriceDelta Money  `bson:"price_delta" json:"price_delta" validate:"min=-10000000,max=10000000"`
+}
+
+// ModifierChoice is an option picked for an order item.
+type ModifierChoice struct {
+	GroupId  string
+	OptionId string
+}
+
+// SelectedModifier is a choice as it was offered when the item was ordered,
+// so later menu changes do not alter the order. Clients only send the ids;
+// the rest is filled in from the food.
+type SelectedModifier struct {
+	GroupId    string `bson:"group_id" json:"group_id"`
+	OptionId   string `bson:"option_id" json:"option_id"`
+	GroupName  string `bson:"group_name" json:"group_name"`
+	OptionName string `bson:"option_name" json:"option_name"`
+	PriceDelta Money  `bson:"price_delta" json:"price_delta"`
+}
+
+func (group ModifierGroup) minimum() int {
+	if group.Required && group.MinSelections < 1 {
+		return 1
+	}
+	return group.MinSelections
+}
+
+// PrepareModifierGroups checks that every group can be satisfied and gives
+// new groups and options their ids. Groups and options sent back with their
+// ids keep them, so orders can keep referring to them.
+func (food *Food) PrepareModifierGroups() error {
+	groupIds := map[string]bool{}
+	for i := range food.ModifierGroups {
+		group := &food.ModifierGroups[i]
+		if group.minimum() > group.MaxSelections {
+			return fmt.Errorf("modifier group %s needs more selections than it allows", group.Name)
+		}
+		if group.MaxSelections > len(group.Options) {
+			return fmt.Errorf("modifier group %s allows more selections than it has options", group.Name)
+		}
+
+		if group.GroupId == "" {
+			group.GroupId = primitive.NewObjectID().Hex()
+		}
+		if groupIds[group.GroupId] {
+			return fmt.Errorf("modifier group id %s is used more than once", group.GroupId)
+		}
+		groupIds[group.GroupId] = true
+
+		optionIds := map[string]bool{}
+		for j := range group.Options {
+			option := &group.Options[j]
+			if option.OptionId == "" {
+				option.OptionId = primitive.NewObjectID().Hex()
+			}
+			if optionIds[option.OptionId] {
+				return fmt.Errorf("option id %s is used more than once in modifier group %s", option.OptionId, group.Name)
+			}
+			optionIds[option.OptionId] = true
+		}
+	}
+
+	return nil
+}
+
+// SelectModifiers checks choices against the modifier groups of the food and
+// returns them as selected modifiers.
+func (food Food) SelectModifiers(choices []ModifierChoice) ([]SelectedModifier, error) {
+	selected := []SelectedModifier{}
+	picked := map[string]int{}
+	seen := map[string]bool{}
+
+	for _, choice := range choices {
+		group, option, found := food.modifierOption(choice)
+		if !found {
+			return nil, fmt.Errorf("option %s of modifier group %s is not offered with this food", choice.OptionId, choice.GroupId)
+		}
+		if seen[choice.GroupId+"/"+choice.OptionId] {
+			return nil, fmt.Errorf("option %s is selected more than once", option.Name)
+		}
+		seen[choice.GroupId+"/"+choice.OptionId] = true
+		picked[group.GroupId]++
+
+		selected = append(selected, SelectedModifier{
+			GroupId:    group.GroupId,
+			OptionId:   option.OptionId,
+			GroupName:  group.Name,
+			OptionName: option.Name,
+			PriceDelta: option.PriceDelta,
+		})
+	}
+
+	for _, group := range food.ModifierGroups {
+		if picked[group.GroupId] < group.minimum() {
+			return nil, fmt.Errorf("modifier group %s needs at least %d selections", group.Name, group.minimum())
+		}
+		if picked[group.GroupId] > group.MaxSelections {
+			return nil, fmt.Errorf("modifier group %s allows at most %d selections", group.Name, group.MaxSelections)
+		}
+	}
+
+	return selected, nil
+}
+
+func (food Food) modifierOption(choice ModifierChoice) (ModifierGroup, ModifierOption, bool) {
+	for _, group := range food.ModifierGroups {
+		if group.GroupId != choice.GroupId {
+			continue
+		}
+		for _, option := range group.Options {
+			if option.OptionId == choice.OptionId {
+				return group, option, true
+			}
+		}
+	}
+
+	return ModifierGroup{}, ModifierOption{}, false
+}
+
+// ModifiersPrice is what the selected modifiers add to the price of the item.
+func (orderItem OrderItem) ModifiersPrice() Money {
+	total := Money(0)
+	for _, modifier := range orderItem.Modifiers {
+		total += modifier.PriceDelta
+	}
+	return total
+}
+
+// ModifierChoices turns the selected modifiers back into the choices they were
+// made from.
+func (orderItem OrderItem) ModifierChoices() []ModifierChoice {
+	choices := []ModifierChoice{}
+	for _, modifier := range orderItem.Modifiers {
+		choices = append(choices, ModifierChoice{GroupId: modifier.GroupId, OptionId: modifier.OptionId})
+	}
+	return choices
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of restaurants that have not picked one.
+const DefaultCurrency = "USD"
+
+// Money is an amount in minor units, cents, of the currency of its
+// restaurant. It is stored as an integer and sent as a decimal string such as
+// "12.34", so that adding up bills never drifts.
+type Money int64
+
+// ParseMoney reads a decimal amount such as "12.34" or "-0.5". Digits past
+// the cents are rounded half away from zero.
+func ParseMoney(amount string) (Money, error) {
+	text := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "-"), "+")
+
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !digitsOnly(whole) || !digitsOnly(fraction) || len(whole) > 15 {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+
+	cents := int64(0)
+	if whole != "" {
+		cents, _ = strconv.ParseInt(whole, 10, 64)
+	}
+	cents *= 100
+	for i := 0; i < 2; i++ {
+		cents += int64(digitAt(fraction, i)) * int64(math.Pow10(1-i))
+	}
+	if digitAt(fraction, 2) >= 5 {
+		cents++
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+func digitsOnly(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func digitAt(text string, i int) int {
+	if i >= len(text) {
+		return 0
+	}
+	return int(text[i] - '0')
+}
+
+func (money Money) String() string {
+	sign := ""
+	cents := int64(money)
+	if cents < 0 {
+		sign, cents = "-", -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+func (money Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(money.String())), nil
+}
+
+// UnmarshalJSON takes amounts as strings, or as plain JSON numbers for older
+// clients. Numbers are read from their digits, never through a float.
+func (money *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	} else if strings.ContainsAny(text, "eE") {
+		return errors.New("amounts cannot use exponents")
+	}
+
+	parsed, err := ParseMoney(text)
+	if err != nil {
+		return err
+	}
+	*money = parsed
+	return nil
+}
+
+// Times is money counted quantity times.
+func (money Money) Times(quantity int) Money {
+	return money * Money(quantity)
+}
+
+// Percent is rate percent of money, rounded to the cent.
+func (money Money) Percent(rate float64) Money {
+	return Money(math.Round(float64(money) * rate / 100))
+}
+
+// Share is the part of money that part is of whole, rounded to the cent.
+func (money Money) Share(part Money, whole Money) Money {
+	if whole == 0 {
+		return 0
+	}
+	return Money(math.Round(float64(money) * float64(part) / float64(whole)))
+}
+
+// RoundTo rounds money to the nearest multiple of increment, such as the
+// smallest coin in circulation.
+func (money Money) RoundTo(increment Money) Money {
+	if increment <= 0 {
+		return money
+	}
+	return Money(math.Round(float64(money)/float64(increment))) * increment
+}
@@ -8,9 +8,9 @@ import (
 
 type Note struct {
 	ID        primitive.ObjectID `bson:"_id"`
-	Text      string             `json:"text" validate:"required"`
-	Title     string             `json:"title" validate:"required"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	NoteId    string             `json:"note_id"`
+	Text      string             `bson:"text" json:"text" validate:"required"`
+	Title     string             `bson:"title" json:"title" validate:"required"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	NoteId    string             `bson:"note_id" json:"note_id"`
 }
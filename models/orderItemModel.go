@@ -6,13 +6,88 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	OrderItemQueued  = "QUEUED"
+	OrderItemCooking = "COOKING"
+	OrderItemReady   = "READY"
+	OrderItemServed  = "SERVED"
+	OrderItemVoided  = "VOIDED"
+)
+
+// OrderItemTransitions lists, for every kitchen status, the statuses an order
+// item may move to next. The first one is where a bump takes it.
+var OrderItemTransitions = map[string][]string{
+	OrderItemQueued:  {OrderItemCooking, OrderItemVoided},
+	OrderItemCooking: {OrderItemReady, OrderItemVoided},
+	OrderItemReady:   {OrderItemServed},
+	OrderItemServed:  {},
+	OrderItemVoided:  {},
+}
+
+// LiveOrderItemStatuses are the statuses of items still on a station queue.
+var LiveOrderItemStatuses = []string{OrderItemQueued, OrderItemCooking, OrderItemReady}
+
 type OrderItem struct {
-	ID          primitive.ObjectID `bson:"_id"`
-	Quantity    *string            `json:"quantity" validate:"required,eq=S|eq=M|eq=L|eq=XL"`
-	UnitPrice   *float64           `json:"unit_price" validate:"required"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
-	FoodId      *string            `json:"food_id" validate:"required"`
-	OrderItemId string             `json:"order_item_id"`
-	OrderId     string             `json:"order_id"`
+	ID              primitive.ObjectID `bson:"_id"`
+	Size            *string            `bson:"size" json:"size" validate:"required,eq=S|eq=M|eq=L|eq=XL"`
+	Quantity        *int               `bson:"quantity" json:"quantity" validate:"omitempty,min=1,max=1000"`
+	UnitPrice       *Money             `bson:"unit_price" json:"unit_price"`
+	Modifiers       []SelectedModifier `bson:"modifiers" json:"modifiers"`
+	Seat            *int               `bson:"seat" json:"seat" validate:"omitempty,min=1,max=100"`
+	Discount        *Discount          `bson:"discount" json:"discount"`
+	Status          string             `bson:"status" json:"status"`
+	Station         string             `bson:"station" json:"station"`
+	StatusUpdatedAt time.Time          `bson:"status_updated_at" json:"status_updated_at"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+	FoodId          *string            `bson:"food_id" json:"food_id" validate:"required"`
+	OrderItemId     string             `bson:"order_item_id" json:"order_item_id"`
+	OrderId         string             `bson:"order_id" json:"order_id"`
+	RestaurantId    string             `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// CurrentStatus is the kitchen status of the item, counting items stored
+// before statuses existed as QUEUED.
+func (orderItem OrderItem) CurrentStatus() string {
+	if orderItem.Status == "" {
+		return OrderItemQueued
+	}
+	return orderItem.Status
+}
+
+// CurrentQuantity is how many of the item were ordered, counting items stored
+// before quantities existed as one.
+func (orderItem OrderItem) CurrentQuantity() int {
+	if orderItem.Quantity == nil {
+		return 1
+	}
+	return *orderItem.Quantity
+}
+
+// LineTotal is what the item costs: its unit price with the selected
+// modifiers, times its quantity.
+func (orderItem OrderItem) LineTotal() Money {
+	unitPrice := Money(0)
+	if orderItem.UnitPrice != nil {
+		unitPrice = *orderItem.UnitPrice
+	}
+	return (unitPrice + orderItem.ModifiersPrice()).Times(orderItem.CurrentQuantity())
+}
+
+// CanTransition reports whether the item may move to status from where it is.
+func (orderItem OrderItem) CanTransition(status string) bool {
+	for _, next := range OrderItemTransitions[orderItem.CurrentStatus()] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatus is where bumping the item takes it, or "" once it is done.
+func (orderItem OrderItem) NextStatus() string {
+	if next := OrderItemTransitions[orderItem.CurrentStatus()]; len(next) > 0 {
+		return next[0]
+	}
+	return ""
 }
@@ -6,11 +6,75 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	OrderOpen          = "OPEN"
+	OrderSentToKitchen = "SENT_TO_KITCHEN"
+	OrderPreparing     = "PREPARING"
+	OrderReady         = "READY"
+	OrderServed        = "SERVED"
+	OrderPaid          = "PAID"
+	OrderCancelled     = "CANCELLED"
+)
+
+// OrderTransitions lists, for every status, the statuses an order may move to
+// next. PAID and CANCELLED close the order.
+var OrderTransitions = map[string][]string{
+	OrderOpen:          {OrderSentToKitchen, OrderCancelled},
+	OrderSentToKitchen: {OrderPreparing, OrderCancelled},
+	OrderPreparing:     {OrderReady, OrderCancelled},
+	OrderReady:         {OrderServed},
+	OrderServed:        {OrderPaid},
+	OrderPaid:          {},
+	OrderCancelled:     {},
+}
+
+type OrderStatusChange struct {
+	Status    string    `bson:"status" json:"status"`
+	ChangedAt time.Time `bson:"changed_at" json:"changed_at"`
+	ChangedBy string    `bson:"changed_by" json:"changed_by"`
+}
+
 type Order struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	OrderDate time.Time          `json:"order_date" validate:"required"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	OrderId   string             `json:"order_id"`
-	TableId   *string            `json:"table_id" validate:"required"`
+	ID            primitive.ObjectID  `bson:"_id"`
+	OrderDate     time.Time           `bson:"order_date" json:"order_date" validate:"required"`
+	Status        string              `bson:"status" json:"status"`
+	StatusHistory []OrderStatusChange `bson:"status_history" json:"status_history"`
+	Discount      *Discount           `bson:"discount" json:"discount"`
+	// Coupons are the codes redeemed on the order.
+	Coupons []string `bson:"coupons" json:"coupons"`
+	// AppliedDiscounts are the discounts the order was invoiced with.
+	AppliedDiscounts []AppliedDiscount `bson:"applied_discounts" json:"applied_discounts"`
+	CreatedAt        time.Time         `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time         `bson:"updated_at" json:"updated_at"`
+	OrderId          string            `bson:"order_id" json:"order_id"`
+	TableId          *string           `bson:"table_id" json:"table_id" validate:"required"`
+	// ServerId is the user who took the order, whom its tips go to.
+	ServerId     string `bson:"server_id" json:"server_id"`
+	RestaurantId string `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// CurrentStatus is the status of the order, counting orders stored before
+// statuses existed as OPEN.
+func (order Order) CurrentStatus() string {
+	if order.Status == "" {
+		return OrderOpen
+	}
+	return order.Status
+}
+
+// CanTransition reports whether the order may move to status from where it is.
+func (order Order) CanTransition(status string) bool {
+	for _, next := range OrderTransitions[order.CurrentStatus()] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Closed reports whether the order is paid or cancelled, after which its items
+// can no longer change.
+func (order Order) Closed() bool {
+	status := order.CurrentStatus()
+	return status == OrderPaid || status == OrderCancelled
 }
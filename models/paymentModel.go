@@ -0,0 +1,198 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	PaymentCash = "CASH"
+	PaymentCard = "CARD"
+)
+
+// How a payment was worked out: by the amount the guest gave, as their part
+// of a bill split evenly, or as the items at their seat or that they picked.
+const (
+	SplitNone  = ""
+	SplitEven  = "EVEN"
+	SplitSeat  = "SEAT"
+	SplitItems = "ITEMS"
+)
+
+// Payment is one part of an invoice paid by one guest. The tip comes on top
+// of Amount and goes to the server of the order, not towards the invoice.
+type Payment struct {
+	PaymentId    string   `bson:"payment_id" json:"payment_id"`
+	Method       string   `bson:"method" json:"method" validate:"required,eq=CASH|eq=CARD"`
+	Amount       Money    `bson:"amount" json:"amount" validate:"gt=0"`
+	Tip          Money    `bson:"tip" json:"tip" validate:"min=0"`
+	Split        string   `bson:"split" json:"split"`
+	Ways         int      `bson:"ways" json:"ways,omitempty"`
+	Seat         int      `bson:"seat" json:"seat,omitempty"`
+	OrderItemIds []string `bson:"order_item_ids" json:"order_item_ids,omitempty"`
+	// TransactionId and IdempotencyKey tie card payments to the card
+	// transaction that took them.
+	TransactionId  string    `bson:"transaction_id" json:"transaction_id,omitempty"`
+	IdempotencyKey string    `bson:"idempotency_key" json:"idempotency_key,omitempty"`
+	ReceivedBy     string    `bson:"received_by" json:"received_by"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	// Refunded is what has been refunded of the payment, or is waiting for
+	// approval to be. It is reserved before the refund is given, so that
+	// refunds made at once cannot add up to more than the payment.
+	Refunded Money `bson:"refunded" json:"refunded,omitempty"`
+}
+
+const (
+	CardCaptured = "CAPTURED"
+	CardDeclined = "DECLINED"
+	CardFailed   = "FAILED"
+	CardVoided   = "VOIDED"
+	CardRefunded = "REFUNDED"
+)
+
+// CardTransaction records one attempt to take a card payment, and how it
+// ended, whether the card was charged or not.
+type CardTransaction struct {
+	IdempotencyKey string    `bson:"idempotency_key" json:"idempotency_key"`
+	TransactionId  string    `bson:"transaction_id" json:"transaction_id"`
+	Status         string    `bson:"status" json:"status"`
+	Amount         Money     `bson:"amount" json:"amount"`
+	Brand          string    `bson:"brand" json:"brand"`
+	Last4          string    `bson:"last4" json:"last4"`
+	DeclineCode    string    `bson:"decline_code" json:"decline_code,omitempty"`
+	Message        string    `bson:"message" json:"message"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	// Void is what became of the authorization when the capture failed:
+	// VOIDED, or why it could not be voided.
+	Void string `bson:"void" json:"void,omitempty"`
+}
+
+// AmountDue is what the invoice charges in all.
+func (invoice Invoice) AmountDue() Money {
+	if invoice.Totals == nil {
+		return 0
+	}
+	return invoice.Totals.Total
+}
+
+// AmountPaid is what the payments on the invoice add up to.
+func (invoice Invoice) AmountPaid() Money {
+	paid := Money(0)
+	for _, payment := range invoice.Payments {
+		paid += payment.Amount
+	}
+	return paid
+}
+
+// Tips is what the payments on the invoice were tipped.
+func (invoice Invoice) Tips() Money {
+	tips := Money(0)
+	for _, payment := range invoice.Payments {
+		tips += payment.Tip
+	}
+	return tips
+}
+
+// Owed is what the invoice charges once its credit notes are taken off.
+func (invoice Invoice) Owed() Money {
+	return invoice.AmountDue() - invoice.Credited
+}
+
+// Balance is what is still outstanding on the invoice.
+func (invoice Invoice) Balance() Money {
+	return invoice.Owed() - invoice.AmountPaid()
+}
+
+// StatusAfterPayments is PAID once nothing is outstanding, PARTIALLY_PAID
+// while some of the invoice has been paid and PENDING before that. Voided
+// invoices stay VOIDED.
+func (invoice Invoice) StatusAfterPayments() string {
+	switch {
+	case invoice.PaymentStatus != nil && *invoice.PaymentStatus == InvoiceVoided:
+		return InvoiceVoided
+	case invoice.Balance() <= 0:
+		return InvoicePaid
+	case len(invoice.Payments) > 0:
+		return InvoicePartiallyPaid
+	default:
+		return InvoicePending
+	}
+}
+
+// PaymentWithKey finds the payment taken with idempotency key.
+func (invoice Invoice) PaymentWithKey(key string) (Payment, bool) {
+	for _, payment := range invoice.Payments {
+		if payment.IdempotencyKey == key {
+			return payment, true
+		}
+	}
+	return Payment{}, false
+}
+
+// PaidItems lists the order items already paid for on their own, by seat or
+// by item.
+func (invoice Invoice) PaidItems() []string {
+	paid := []string{}
+	for _, payment := range invoice.Payments {
+		paid = append(paid, payment.OrderItemIds...)
+	}
+	return paid
+}
+
+// EvenShare is the part of the invoice the next guest pays when it is split
+// evenly ways ways. Shares are cut so that, however the cents fall, they add
+// up to exactly what is owed.
+func (invoice Invoice) EvenShare(ways int) Money {
+	taken := 0
+	for _, payment := range invoice.Payments {
+		if payment.Split == SplitEven && payment.Ways == ways {
+			taken++
+		}
+	}
+	if taken >= ways {
+		return 0
+	}
+
+	due := invoice.Owed()
+	return due.Share(Money(taken+1), Money(ways)) - due.Share(Money(taken), Money(ways))
+}
+
+// LinesDue is the share of the total the lines of orderItemIds pay, see
+// LineShares.
+func (totals OrderTotals) LinesDue(orderItemIds []string) Money {
+	due := Money(0)
+	for orderItemId, share := range totals.LineShares() {
+		if slices.Contains(orderItemIds, orderItemId) {
+			due += share
+		}
+	}
+	return due
+}
+
+// LineShares splits the total of the order over its order items, in
+// proportion to what each line costs with its share of the service charge and
+// the gratuity, and its tax. The tax, charges and rounding of the order itself
+// are spread that way, so the shares add up to the total to the cent.
+func (totals OrderTotals) LineShares() map[string]Money {
+	weights := make([]Money, len(totals.Lines))
+	whole := Money(0)
+	for i, line := range totals.Lines {
+		weights[i] = line.Net + line.Net.Percent(totals.ServiceChargeRate) + line.Net.Percent(totals.GratuityRate) + line.Net.Percent(line.TaxRate)
+		whole += weights[i]
+	}
+
+	// each line takes what the lines up to it take of the total, less what
+	// the lines before it took, so rounding never adds up
+	shares := map[string]Money{}
+	upTo, given := Money(0), Money(0)
+	for i, line := range totals.Lines {
+		upTo += weights[i]
+		share := totals.Total.Share(upTo, whole) - given
+		if i == len(totals.Lines)-1 {
+			share = totals.Total - given
+		}
+		shares[line.OrderItemId] += share
+		given += share
+	}
+	return shares
+}
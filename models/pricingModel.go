@@ -0,0 +1,362 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	DiscountPercent = "PERCENT"
+	DiscountAmount  = "AMOUNT"
+)
+
+const (
+	AppliedManual    = "MANUAL"
+	AppliedPromotion = "PROMOTION"
+	AppliedCoupon    = "COUPON"
+)
+
+// PricingSettings are the tax, service charge and gratuity rules of a
+// restaurant. Rates are percentages. The service charge goes to the house,
+// the gratuity to the server of the order.
+type PricingSettings struct {
+	TaxRates               map[string]float64 `bson:"tax_rates" json:"tax_rates" validate:"dive,keys,min=2,max=50,endkeys,min=0,max=100"`
+	DefaultTaxRate         float64            `bson:"default_tax_rate" json:"default_tax_rate" validate:"min=0,max=100"`
+	ServiceChargeRate      float64            `bson:"service_charge_rate" json:"service_charge_rate" validate:"min=0,max=100"`
+	ServiceChargeMinGuests int                `bson:"service_charge_min_guests" json:"service_charge_min_guests" validate:"min=0"`
+	GratuityRate           float64            `bson:"gratuity_rate" json:"gratuity_rate" validate:"min=0,max=100"`
+	GratuityMinGuests      int                `bson:"gratuity_min_guests" json:"gratuity_min_guests" validate:"min=0"`
+	CashRounding           Money              `bson:"cash_rounding" json:"cash_rounding" validate:"min=0,max=100"`
+}
+
+// TaxRate is the rate charged on foods of category, or the default rate for
+// categories without one of their own.
+func (settings PricingSettings) TaxRate(category string) float64 {
+	if rate, found := settings.TaxRates[category]; found {
+		return rate
+	}
+	return settings.DefaultTaxRate
+}
+
+// Discount takes a percentage or a fixed amount off an order item or a whole
+// order. It is given by hand and records the manager who approved it.
+type Discount struct {
+	Type       string    `bson:"type" json:"type" validate:"required,eq=PERCENT|eq=AMOUNT"`
+	Percent    float64   `bson:"percent" json:"percent" validate:"required_if=Type PERCENT,min=0,max=100"`
+	Amount     Money     `bson:"amount" json:"amount" validate:"required_if=Type AMOUNT,min=0"`
+	Reason     string    `bson:"reason" json:"reason" validate:"max=200"`
+	ApprovedBy string    `bson:"approved_by" json:"approved_by"`
+	ApprovedAt time.Time `bson:"approved_at" json:"approved_at"`
+}
+
+// Of is how much the discount takes off amount, never more than amount.
+func (discount *Discount) Of(amount Money) Money {
+	if discount == nil || amount <= 0 {
+		return 0
+	}
+
+	off := discount.Amount
+	if discount.Type == DiscountPercent {
+		off = amount.Percent(discount.Percent)
+	}
+	return min(off, amount)
+}
+
+type LineTotals struct {
+	OrderItemId   string  `bson:"order_item_id" json:"order_item_id"`
+	FoodId        string  `bson:"food_id" json:"food_id"`
+	Category      string  `bson:"category" json:"category,omitempty"`
+	Quantity      int     `bson:"quantity" json:"quantity"`
+	Gross         Money   `bson:"gross" json:"gross"`
+	Discount      Money   `bson:"discount" json:"discount"`
+	Promotions    Money   `bson:"promotions" json:"promotions"`
+	OrderDiscount Money   `bson:"order_discount" json:"order_discount"`
+	Net           Money   `bson:"net" json:"net"`
+	TaxRate       float64 `bson:"tax_rate" json:"tax_rate"`
+}
+
+type TaxTotals struct {
+	Category string  `bson:"category" json:"category,omitempty"`
+	Rate     float64 `bson:"rate" json:"rate"`
+	Taxable  Money   `bson:"taxable" json:"taxable"`
+	Tax      Money   `bson:"tax" json:"tax"`
+}
+
+// AppliedDiscount is one discount taken off an order: a manual discount of
+// an item or of the whole order, a promotion or a coupon.
+type AppliedDiscount struct {
+	Source      string `bson:"source" json:"source"`
+	OrderItemId string `bson:"order_item_id" json:"order_item_id,omitempty"`
+	PromotionId string `bson:"promotion_id" json:"promotion_id,omitempty"`
+	Code        string `bson:"code" json:"code,omitempty"`
+	Name        string `bson:"name" json:"name"`
+	ApprovedBy  string `bson:"approved_by" json:"approved_by,omitempty"`
+	Amount      Money  `bson:"amount" json:"amount"`
+}
+
+// OrderTotals is the breakdown of what an order costs.
+type OrderTotals struct {
+	OrderId           string            `bson:"order_id" json:"order_id"`
+	Currency          string            `bson:"currency" json:"currency"`
+	Lines             []LineTotals      `bson:"lines" json:"lines"`
+	Subtotal          Money             `bson:"subtotal" json:"subtotal"`
+	LineDiscounts     Money             `bson:"line_discounts" json:"line_discounts"`
+	Promotions        Money             `bson:"promotions" json:"promotions"`
+	OrderDiscount     Money             `bson:"order_discount" json:"order_discount"`
+	Net               Money             `bson:"net" json:"net"`
+	ServiceChargeRate float64           `bson:"service_charge_rate" json:"service_charge_rate"`
+	ServiceCharge     Money             `bson:"service_charge" json:"service_charge"`
+	GratuityRate      float64           `bson:"gratuity_rate" json:"gratuity_rate"`
+	Gratuity          Money             `bson:"gratuity" json:"gratuity"`
+	Taxes             []TaxTotals       `bson:"taxes" json:"taxes"`
+	Tax               Money             `bson:"tax" json:"tax"`
+	Rounding          Money             `bson:"rounding" json:"rounding"`
+	Total             Money             `bson:"total" json:"total"`
+	Discounts         []AppliedDiscount `bson:"discounts" json:"discounts"`
+}
+
+// PricingContext is everything besides the order itself that goes into its
+// price.
+type PricingContext struct {
+	Foods map[string]Food
+	Menus map[string]Menu
+	// Promotions are the automatic promotions of the restaurant and the
+	// coupons redeemed on the order.
+	Promotions []Promotion
+	Guests     int
+	Settings   PricingSettings
+	Location   *time.Location
+}
+
+// CalculateTotals prices order from its items:
+//
+//   - every line costs its LineTotal, less its own discount;
+//   - promotions come off the lines they cover that were ordered while the
+//     promotion ran, one after the other;
+//   - the order discount is shared between the lines by what they cost;
+//   - parties of at least ServiceChargeMinGuests pay the service charge on
+//     the discounted lines, and parties of at least GratuityMinGuests the
+//     gratuity;
+//   - tax is charged on the discounted lines by food category, rounded once
+//     per category, and not on the service charge or the gratuity;
+//   - the total is rounded to CashRounding when it is set.
+//
+// Voided items are left out, and percentages are rounded to the cent.
+func CalculateTotals(order Order, orderItems []OrderItem, pricing PricingContext) OrderTotals {
+	settings := pricing.Settings
+	totals := OrderTotals{OrderId: order.OrderId, Lines: []LineTotals{}, Taxes: []TaxTotals{}, Discounts: []AppliedDiscount{}}
+
+	billed := []OrderItem{}
+	for _, orderItem := range orderItems {
+		if orderItem.CurrentStatus() == OrderItemVoided {
+			continue
+		}
+
+		line := LineTotals{OrderItemId: orderItem.OrderItemId, Quantity: orderItem.CurrentQuantity()}
+		if orderItem.FoodId != nil {
+			food := pricing.Foods[*orderItem.FoodId]
+			line.FoodId = *orderItem.FoodId
+			if food.Category != nil {
+				line.Category = *food.Category
+			}
+			if orderItem.UnitPrice == nil {
+				orderItem.UnitPrice = food.Price
+			}
+		}
+		line.Gross = orderItem.LineTotal()
+		line.Discount = orderItem.Discount.Of(line.Gross)
+		line.Net = line.Gross - line.Discount
+		line.TaxRate = settings.TaxRate(line.Category)
+		if line.Discount > 0 {
+			totals.Discounts = append(totals.Discounts, manualDiscount(orderItem.Discount, orderItem.OrderItemId, line.Discount))
+		}
+
+		totals.Subtotal += line.Gross
+		totals.LineDiscounts += line.Discount
+		totals.Lines = append(totals.Lines, line)
+		billed = append(billed, orderItem)
+	}
+
+	for _, promotion := range pricing.Promotions {
+		covered := []int{}
+		for i, orderItem := range billed {
+			food := pricing.Foods[totals.Lines[i].FoodId]
+			if promotion.RunsAt(orderItem.CreatedAt, pricing.location()) && promotion.Covers(food, pricing.Menus[StringOf(food.MenuId)]) {
+				covered = append(covered, i)
+			}
+		}
+
+		amount := applyPromotion(promotion, totals.Lines, covered)
+		if amount <= 0 {
+			continue
+		}
+		totals.Promotions += amount
+
+		applied := AppliedDiscount{Source: AppliedPromotion, PromotionId: promotion.PromotionId, Name: StringOf(promotion.Name), Amount: amount}
+		if promotion.IsCoupon() {
+			applied.Source, applied.Code = AppliedCoupon, *promotion.Code
+		}
+		totals.Discounts = append(totals.Discounts, applied)
+	}
+
+	for _, line := range totals.Lines {
+		totals.Net += line.Net
+	}
+
+	totals.OrderDiscount = order.Discount.Of(totals.Net)
+	shareOrderDiscount(totals.Lines, totals.OrderDiscount, totals.Net)
+	totals.Net -= totals.OrderDiscount
+	if totals.OrderDiscount > 0 {
+		totals.Discounts = append(totals.Discounts, manualDiscount(order.Discount, "", totals.OrderDiscount))
+	}
+
+	if settings.ServiceChargeMinGuests > 0 && pricing.Guests >= settings.ServiceChargeMinGuests {
+		totals.ServiceChargeRate = settings.ServiceChargeRate
+		totals.ServiceCharge = totals.Net.Percent(settings.ServiceChargeRate)
+	}
+	if settings.GratuityMinGuests > 0 && pricing.Guests >= settings.GratuityMinGuests {
+		totals.GratuityRate = settings.GratuityRate
+		totals.Gratuity = totals.Net.Percent(settings.GratuityRate)
+	}
+
+	taxable := map[string]Money{}
+	for _, line := range totals.Lines {
+		taxable[line.Category] += line.Net
+	}
+	categories := []string{}
+	for category := range taxable {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		rate := settings.TaxRate(category)
+		tax := TaxTotals{Category: category, Rate: rate, Taxable: taxable[category]}
+		tax.Tax = tax.Taxable.Percent(rate)
+		totals.Taxes = append(totals.Taxes, tax)
+		totals.Tax += tax.Tax
+	}
+
+	total := totals.Net + totals.ServiceCharge + totals.Gratuity + totals.Tax
+	totals.Total = total.RoundTo(settings.CashRounding)
+	totals.Rounding = totals.Total - total
+
+	return totals
+}
+
+// shareOrderDiscount takes discount off the lines in proportion to what they
+// cost. The last line takes what rounding leaves over, so the shares always
+// add up to the discount.
+func shareOrderDiscount(lines []LineTotals, discount Money, net Money) {
+	for i, share := range shares(lines, allLines(lines), discount, net) {
+		lines[i].OrderDiscount = share
+		lines[i].Net -= share
+	}
+}
+
+// applyPromotion takes promotion off the covered lines and returns how much
+// it took off in all.
+func applyPromotion(promotion Promotion, lines []LineTotals, covered []int) Money {
+	off := map[int]Money{}
+
+	switch *promotion.Type {
+	case PromotionPercent:
+		for _, i := range covered {
+			off[i] = lines[i].Net.Percent(promotion.Percent)
+		}
+	case PromotionAmount:
+		net := Money(0)
+		for _, i := range covered {
+			net += lines[i].Net
+		}
+		off = shares(lines, covered, min(promotion.Amount, net), net)
+	case PromotionBuyGetFree:
+		off = freeUnits(promotion, lines, covered)
+	}
+
+	total := Money(0)
+	for i, amount := range off {
+		amount = min(amount, lines[i].Net)
+		lines[i].Promotions += amount
+		lines[i].Net -= amount
+		total += amount
+	}
+	return total
+}
+
+// freeUnits lines the covered units up from the most to the least expensive
+// and, in every group of BuyQuantity + FreeQuantity of them, gives the
+// cheapest FreeQuantity away.
+func freeUnits(promotion Promotion, lines []LineTotals, covered []int) map[int]Money {
+	type unit struct {
+		line  int
+		price Money
+	}
+
+	units := []unit{}
+	for _, i := range covered {
+		if lines[i].Quantity < 1 {
+			continue
+		}
+		price := lines[i].Net.Share(1, Money(lines[i].Quantity))
+		for n := 0; n < lines[i].Quantity; n++ {
+			units = append(units, unit{i, price})
+		}
+	}
+	sort.SliceStable(units, func(a, b int) bool { return units[a].price > units[b].price })
+
+	off := map[int]Money{}
+	group := promotion.BuyQuantity + promotion.FreeQuantity
+	for start := 0; start+group <= len(units); start += group {
+		for _, free := range units[start+promotion.BuyQuantity : start+group] {
+			off[free.line] += free.price
+		}
+	}
+	return off
+}
+
+// shares splits amount between the lines at indexes in proportion to their
+// share of net. The last one takes what rounding leaves over.
+func shares(lines []LineTotals, indexes []int, amount Money, net Money) map[int]Money {
+	split := map[int]Money{}
+	if amount <= 0 || net <= 0 {
+		return split
+	}
+
+	left := amount
+	for n, i := range indexes {
+		share := amount.Share(lines[i].Net, net)
+		if n == len(indexes)-1 || share > left {
+			share = left
+		}
+		left -= share
+		split[i] = share
+	}
+	return split
+}
+
+func allLines(lines []LineTotals) []int {
+	indexes := make([]int, len(lines))
+	for i := range lines {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+func manualDiscount(discount *Discount, orderItemId string, amount Money) AppliedDiscount {
+	return AppliedDiscount{Source: AppliedManual, OrderItemId: orderItemId, Name: discount.Reason, ApprovedBy: discount.ApprovedBy, Amount: amount}
+}
+
+func (pricing PricingContext) location() *time.Location {
+	if pricing.Location == nil {
+		return time.UTC
+	}
+	return pricing.Location
+}
+
+// StringOf is the text, or "" when it is not set.
+func StringOf(text *string) string {
+	if text == nil {
+		return ""
+	}
+	return *text
+}
@@ -0,0 +1,144 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PromotionPercent    = "PERCENT"
+	PromotionAmount     = "AMOUNT"
+	PromotionBuyGetFree = "BOGO"
+)
+
+// Promotion takes money off the items it covers: a percentage, a fixed amount
+// shared between them, or every FreeQuantity cheapest of each BuyQuantity +
+// FreeQuantity units ("2-for-1" is buy 1, get 1 free).
+//
+// Promotions without a Code apply by themselves to every item ordered while
+// they run. Promotions with one are coupons and only apply to orders the code
+// was redeemed on, at most MaxUses times when MaxUses is set.
+type Promotion struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	Name           *string            `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Type           *string            `bson:"type" json:"type" validate:"required,eq=PERCENT|eq=AMOUNT|eq=BOGO"`
+	Percent        float64            `bson:"percent" json:"percent" validate:"min=0,max=100"`
+	Amount         Money              `bson:"amount" json:"amount" validate:"min=0"`
+	BuyQuantity    int                `bson:"buy_quantity" json:"buy_quantity" validate:"min=0,max=100"`
+	FreeQuantity   int                `bson:"free_quantity" json:"free_quantity" validate:"min=0,max=100"`
+	FoodIds        []string           `bson:"food_ids" json:"food_ids"`
+	MenuCategories []string           `bson:"menu_categories" json:"menu_categories"`
+	StartsAt       *time.Time         `bson:"starts_at" json:"starts_at"`
+	EndsAt         *time.Time         `bson:"ends_at" json:"ends_at"`
+	Days           []string           `bson:"days" json:"days" validate:"dive,oneof=MONDAY TUESDAY WEDNESDAY THURSDAY FRIDAY SATURDAY SUNDAY"`
+	StartTime      *string            `bson:"start_time" json:"start_time" validate:"omitempty,datetime=15:04"`
+	EndTime        *string            `bson:"end_time" json:"end_time" validate:"omitempty,datetime=15:04"`
+	Code           *string            `bson:"code" json:"code" validate:"omitempty,alphanum,min=3,max=30"`
+	MaxUses        int                `bson:"max_uses" json:"max_uses" validate:"min=0"`
+	Uses           int                `bson:"uses" json:"uses"`
+	Active         *bool              `bson:"active" json:"active"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	PromotionId    string             `bson:"promotion_id" json:"promotion_id"`
+	RestaurantId   string             `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// Prepare checks the rules that depend on each other and writes the coupon
+// code in upper case, so codes match however guests type them.
+func (promotion *Promotion) Prepare() error {
+	switch *promotion.Type {
+	case PromotionPercent:
+		if promotion.Percent <= 0 {
+			return errors.New("percentage promotions need a percent")
+		}
+	case PromotionAmount:
+		if promotion.Amount <= 0 {
+			return errors.New("fixed amount promotions need an amount")
+		}
+	case PromotionBuyGetFree:
+		if promotion.BuyQuantity < 1 || promotion.FreeQuantity < 1 {
+			return errors.New("buy-get-free promotions need a buy and a free quantity")
+		}
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return errors.New("promotions must end after they start")
+	}
+	if (promotion.StartTime == nil) != (promotion.EndTime == nil) {
+		return errors.New("promotions need both a start and an end time of day, or neither")
+	}
+
+	if promotion.Code != nil {
+		code := NormalizeCouponCode(*promotion.Code)
+		promotion.Code = &code
+		if code == "" {
+			promotion.Code = nil
+		}
+	}
+	return nil
+}
+
+// NormalizeCouponCode is code the way coupon codes are stored.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsCoupon reports whether the promotion only applies through its code.
+func (promotion Promotion) IsCoupon() bool {
+	return promotion.Code != nil && *promotion.Code != ""
+}
+
+// IsActive reports whether the promotion has not been switched off.
+func (promotion Promotion) IsActive() bool {
+	return promotion.Active == nil || *promotion.Active
+}
+
+// UsedUp reports whether a coupon has been redeemed as often as it may be.
+func (promotion Promotion) UsedUp() bool {
+	return promotion.MaxUses > 0 && promotion.Uses >= promotion.MaxUses
+}
+
+// RunsAt reports whether the promotion is on at, in the time zone location:
+// between its start and end, on one of its days and within its daily hours.
+// Daily hours that end before they start run past midnight.
+func (promotion Promotion) RunsAt(at time.Time, location *time.Location) bool {
+	if !promotion.IsActive() {
+		return false
+	}
+	if promotion.StartsAt != nil && at.Before(*promotion.StartsAt) {
+		return false
+	}
+	if promotion.EndsAt != nil && !at.Before(*promotion.EndsAt) {
+		return false
+	}
+
+	local := at.In(location)
+	if len(promotion.Days) > 0 && !slices.Contains(promotion.Days, strings.ToUpper(local.Weekday().String())) {
+		return false
+	}
+	if promotion.StartTime != nil && promotion.EndTime != nil {
+		now := local.Format("15:04")
+		start, end := *promotion.StartTime, *promotion.EndTime
+		if start <= end && (now < start || now >= end) {
+			return false
+		}
+		if start > end && now < start && now >= end {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Covers reports whether the promotion applies to food, which is on menu.
+// Promotions that name no foods and no menu categories cover everything.
+func (promotion Promotion) Covers(food Food, menu Menu) bool {
+	if len(promotion.FoodIds) == 0 && len(promotion.MenuCategories) == 0 {
+		return true
+	}
+
+	return slices.Contains(promotion.FoodIds, food.FoodId) || slices.Contains(promotion.MenuCategories, menu.Category)
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// ReceiptSettings is how a restaurant prints its receipts. Footer closes every
+// receipt. HTMLTemplate and TextTemplate replace the built-in layouts of the
+// HTML receipt and of the printed one the PDF is made from; both are Go
+// templates executed on a Receipt.
+type ReceiptSettings struct {
+	Footer       string `bson:"footer" json:"footer" validate:"max=500"`
+	HTMLTemplate string `bson:"html_template" json:"html_template" validate:"max=50000"`
+	TextTemplate string `bson:"text_template" json:"text_template" validate:"max=20000"`
+}
+
+// Receipt is what receipt templates are given: an invoice laid out for the
+// guest, with times in the time zone of the restaurant.
+type Receipt struct {
+	RestaurantName    string
+	RestaurantAddress string
+	RestaurantPhone   string
+	InvoiceNumber     string
+	IssuedAt          time.Time
+	TableNumber       int
+	Currency          string
+	Lines             []ReceiptLine
+	Subtotal          Money
+	Discounts         []AppliedDiscount
+	ServiceChargeRate float64
+	ServiceCharge     Money
+	GratuityRate      float64
+	Gratuity          Money
+	Taxes             []TaxTotals
+	Tax               Money
+	Rounding          Money
+	Total             Money
+	Credited          Money
+	Payments          []Payment
+	Paid              Money
+	Tips              Money
+	Balance           Money
+	// TipLine is set while the invoice is still open, for the guest to write
+	// a tip in.
+	TipLine bool
+	Voided  bool
+	Footer  string
+}
+
+// ReceiptLine is one item on a receipt, at its price before discounts.
+type ReceiptLine struct {
+	Name     string
+	Size     string
+	Quantity int
+	Amount   Money
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Restaurant struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Name     *string            `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Address  *string            `bson:"address" json:"address" validate:"required,min=2,max=300"`
+	Phone    *string            `bson:"phone" json:"phone"`
+	Currency string             `bson:"currency" json:"currency" validate:"omitempty,iso4217"`
+	TimeZone string             `bson:"time_zone" json:"time_zone" validate:"omitempty,timezone"`
+	Pricing  *PricingSettings   `bson:"pricing" json:"pricing"`
+	// ReversalApprovalLimit is the largest void or refund staff other than
+	// managers may give without a manager approving it.
+	ReversalApprovalLimit Money `bson:"reversal_approval_limit" json:"reversal_approval_limit" validate:"min=0"`
+	// TipOutRates are the percentages of their tips servers hand on to the
+	// tip pool of each role at the end of a shift.
+	TipOutRates map[string]float64 `bson:"tip_out_rates" json:"tip_out_rates" validate:"dive,keys,oneof=ADMIN MANAGER WAITER COOK CASHIER,endkeys,min=0,max=100"`
+	// InvoicePrefix and CreditNotePrefix start the numbers invoices and
+	// credit notes are issued under. Numbers run per fiscal year, which
+	// starts in FiscalYearStartMonth, January unless set.
+	InvoicePrefix        string           `bson:"invoice_prefix" json:"invoice_prefix" validate:"omitempty,alphanum,max=10"`
+	CreditNotePrefix     string           `bson:"credit_note_prefix" json:"credit_note_prefix" validate:"omitempty,alphanum,max=10"`
+	FiscalYearStartMonth int              `bson:"fiscal_year_start_month" json:"fiscal_year_start_month" validate:"min=0,max=12"`
+	Receipt              *ReceiptSettings `bson:"receipt" json:"receipt"`
+	CreatedAt            time.Time        `bson:"created_at" json:"created_at"`
+	UpdatedAt            time.Time        `bson:"updated_at" json:"updated_at"`
+	RestaurantId         string           `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// CurrencyCode is the ISO 4217 code of the currency the restaurant charges in.
+func (restaurant Restaurant) CurrencyCode() string {
+	if restaurant.Currency == "" {
+		return DefaultCurrency
+	}
+	return restaurant.Currency
+}
+
+// InvoiceNumberPrefix is what the numbers of invoices start with.
+func (restaurant Restaurant) InvoiceNumberPrefix() string {
+	if restaurant.InvoicePrefix == "" {
+		return DefaultInvoicePrefix
+	}
+	return restaurant.InvoicePrefix
+}
+
+// CreditNoteNumberPrefix is what the numbers of credit notes start with.
+func (restaurant Restaurant) CreditNoteNumberPrefix() string {
+	if restaurant.CreditNotePrefix == "" {
+		return DefaultCreditNotePrefix
+	}
+	return restaurant.CreditNotePrefix
+}
+
+// FiscalYear is the fiscal year at falls in, in the time zone of the
+// restaurant. Fiscal years are named after the calendar year they start in.
+func (restaurant Restaurant) FiscalYear(at time.Time) int {
+	local := at.In(restaurant.Location())
+	if restaurant.FiscalYearStartMonth > 1 && int(local.Month()) < restaurant.FiscalYearStartMonth {
+		return local.Year() - 1
+	}
+	return local.Year()
+}
+
+// Location is the time zone the restaurant keeps its hours in, UTC unless it
+// set one.
+func (restaurant Restaurant) Location() *time.Location {
+	location, err := time.LoadLocation(restaurant.TimeZone)
+	if restaurant.TimeZone == "" || err != nil {
+		return time.UTC
+	}
+	return location
+}
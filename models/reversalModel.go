@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ReversalVoidItem    = "VOID_ITEM"
+	ReversalVoidInvoice = "VOID_INVOICE"
+	ReversalRefund      = "REFUND"
+)
+
+const (
+	ReversalPending   = "PENDING_APPROVAL"
+	ReversalCompleted = "COMPLETED"
+	ReversalRejected  = "REJECTED"
+	ReversalFailed    = "FAILED"
+)
+
+// ReversalReasons are the reason codes voids and refunds are given for.
+var ReversalReasons = []string{"CUSTOMER_COMPLAINT", "WRONG_ITEM", "QUALITY", "DUPLICATE", "OVERCHARGE", "WALKOUT", "OTHER"}
+
+// ReversalReason is why a void or a refund is asked for. OTHER needs a note.
+type ReversalReason struct {
+	ReasonCode string `bson:"reason_code" json:"reason_code" validate:"required,oneof=CUSTOMER_COMPLAINT WRONG_ITEM QUALITY DUPLICATE OVERCHARGE WALKOUT OTHER"`
+	Note       string `bson:"note" json:"note" validate:"required_if=ReasonCode OTHER,max=500"`
+}
+
+// Reversal takes back an order item, an invoice or part of a payment. It is
+// recorded next to what it reverses, which itself stays as it was. Reversals
+// above the approval limit of the restaurant wait for a manager unless a
+// manager asked for them.
+type Reversal struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	ReversalId     string             `bson:"reversal_id" json:"reversal_id"`
+	Type           string             `bson:"type" json:"type"`
+	Status         string             `bson:"status" json:"status"`
+	ReversalReason `bson:",inline"`
+	Amount         Money     `bson:"amount" json:"amount"`
+	OrderId        string    `bson:"order_id" json:"order_id"`
+	OrderItemId    string    `bson:"order_item_id" json:"order_item_id,omitempty"`
+	InvoiceId      string    `bson:"invoice_id" json:"invoice_id,omitempty"`
+	PaymentId      string    `bson:"payment_id" json:"payment_id,omitempty"`
+	Method         string    `bson:"method" json:"method,omitempty"`
+	TransactionId  string    `bson:"transaction_id" json:"transaction_id,omitempty"`
+	Message        string    `bson:"message" json:"message,omitempty"`
+	RequestedBy    string    `bson:"requested_by" json:"requested_by"`
+	DecidedBy      string    `bson:"decided_by" json:"decided_by,omitempty"`
+	DecidedAt      time.Time `bson:"decided_at" json:"decided_at"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"`
+	RestaurantId   string    `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// Refunded is what the completed refunds among reversals gave back, in all or
+// for paymentId when it is set.
+func Refunded(reversals []Reversal, paymentId string) Money {
+	refunded := Money(0)
+	for _, reversal := range reversals {
+		if reversal.Type == ReversalRefund && reversal.Status == ReversalCompleted && (paymentId == "" || reversal.PaymentId == paymentId) {
+			refunded += reversal.Amount
+		}
+	}
+	return refunded
+}
+
+// Refundable is what is left to refund of payment once the refunds among
+// reversals, given or still waiting for approval, are taken off, and what has
+// been reserved for refunds on the payment itself.
+func Refundable(payment Payment, reversals []Reversal) Money {
+	left := payment.Amount
+	for _, reversal := range reversals {
+		if reversal.Type == ReversalRefund && reversal.PaymentId == payment.PaymentId && (reversal.Status == ReversalCompleted || reversal.Status == ReversalPending) {
+			left -= reversal.Amount
+		}
+	}
+	// payments refunded before refunds were reserved have nothing reserved
+	return min(left, payment.Amount-payment.Refunded)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Shift runs from the end of the previous shift until a manager closes it.
+// Closing a shift also closes its card batch: tips taken during the shift can
+// be changed until then, and no longer afterwards.
+type Shift struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	ShiftId      string             `bson:"shift_id" json:"shift_id"`
+	StartedAt    time.Time          `bson:"started_at" json:"started_at"`
+	EndedAt      time.Time          `bson:"ended_at" json:"ended_at"`
+	ClosedBy     string             `bson:"closed_by" json:"closed_by"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	RestaurantId string             `bson:"restaurant_id" json:"restaurant_id"`
+}
+
+// Open reports whether the shift has not been closed yet.
+func (shift Shift) Open() bool {
+	return shift.EndedAt.IsZero()
+}
@@ -8,9 +8,10 @@ import (
 
 type Table struct {
 	ID             primitive.ObjectID `bson:"_id"`
-	NumberOfGuests *int               `json:"number_of_guests" validate:"required"`
-	TableNumber    *int               `json:"table_number" validate:"required"`
-	CreatedAt      time.Time          `json:"created_at"`
-	UpdatedAt      time.Time          `json:"updated_at"`
-	TableId        string             `json:"table_id"`
+	NumberOfGuests *int               `bson:"number_of_guests" json:"number_of_guests" validate:"required"`
+	TableNumber    *int               `bson:"table_number" json:"table_number" validate:"required"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
+	TableId        string             `bson:"table_id" json:"table_id"`
+	RestaurantId   string             `bson:"restaurant_id" json:"restaurant_id"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Terminal struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Name          *string            `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	KeyHash       string             `bson:"key_hash" json:"-"`
+	ActiveUserId  string             `bson:"active_user_id" json:"active_user_id"`
+	ActiveTokenId string             `bson:"active_token_id" json:"-"`
+	ActiveUntil   *time.Time         `bson:"active_until" json:"active_until"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+	TerminalId    string             `bson:"terminal_id" json:"terminal_id"`
+	RestaurantId  string             `bson:"restaurant_id" json:"restaurant_id"`
+}
@@ -6,17 +6,43 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	RoleOwner   = "OWNER"
+	RoleAdmin   = "ADMIN"
+	RoleManager = "MANAGER"
+	RoleWaiter  = "WAITER"
+	RoleCook    = "COOK"
+	RoleCashier = "CASHIER"
+)
+
+// StaffRoles lists every role a signed-in user can hold. OWNER is the only one
+// that is not tied to a single restaurant.
+var StaffRoles = []string{RoleOwner, RoleAdmin, RoleManager, RoleWaiter, RoleCook, RoleCashier}
+
 type User struct {
 	ID           primitive.ObjectID `bson:"_id"`
-	FirstName    *string            `json:"first_name" validate:"required,min=2,max=100"`
-	LastName     *string            `json:"last_name" validate:"required,min=2,max=100"`
-	Email        *string            `json:"email" validate:"required,email,min=2,max=100"`
-	Password     *string            `json:"password" validate:"required,min=6,max=100"`
-	Avatar       *string            `json:"avatar"`
-	Phone        *string            `json:"phone" validate:"required"`
-	Token        *string            `json:"token"`
-	RefreshToken *string            `json:"refresh_token"`
-	CreatedAt    time.Time          `json:"created_at"`
-	UpdatedAt    time.Time          `json:"updated_at"`
-	UserId       string             `json:"user_id"`
+	FirstName    *string            `bson:"first_name" json:"first_name" validate:"required,min=2,max=100"`
+	LastName     *string            `bson:"last_name" json:"last_name" validate:"required,min=2,max=100"`
+	Email        *string            `bson:"email" json:"email" validate:"required,email,min=2,max=100"`
+	Password     *string            `bson:"password" json:"password,omitempty" validate:"required,min=6,max=100"`
+	Avatar       *string            `bson:"avatar" json:"avatar"`
+	Phone        *string            `bson:"phone" json:"phone" validate:"required"`
+	Role         *string            `bson:"role" json:"role" validate:"omitempty,eq=OWNER|eq=ADMIN|eq=MANAGER|eq=WAITER|eq=COOK|eq=CASHIER"`
+	Pin          *string            `bson:"pin,omitempty" json:"-"`
+	Token        *string            `bson:"signed_token" json:"token,omitempty"`
+	RefreshToken *string            `bson:"signed_refresh_token" json:"refresh_token,omitempty"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	UserId       string             `bson:"user_id" json:"user_id"`
+	RestaurantId string             `bson:"restaurant_id" json:"restaurant_id"`
+	// SessionEpoch goes up with every revoke-all; access tokens carry the
+	// epoch they were issued in and stop being accepted once it moves on.
+	SessionEpoch int `bson:"session_epoch" json:"-"`
+}
+
+// Public returns the user without the password hash and the stored tokens,
+// as anyone allowed to see the user may be shown it.
+func (user User) Public() User {
+	user.Password, user.Token, user.RefreshToken = nil, nil, nil
+	return user
 }
@@ -0,0 +1,69 @@
+package models
+
+import "fmt"
+
+const (
+	SizeSmall      = "S"
+	SizeMedium     = "M"
+	SizeLarge      = "L"
+	SizeExtraLarge = "XL"
+)
+
+// Sizes lists the sizes a food can be ordered in.
+var Sizes = []string{SizeSmall, SizeMedium, SizeLarge, SizeExtraLarge}
+
+// FoodVariant is the price of a food in one size.
+type FoodVariant struct {
+	Size  string `bson:"size" json:"size" validate:"required,eq=S|eq=M|eq=L|eq=XL"`
+	Price Money  `bson:"price" json:"price" validate:"min=0,max=10000000"`
+}
+
+// PrepareVariants checks that every size is priced at most once.
+func (food *Food) PrepareVariants() error {
+	sizes := map[string]bool{}
+	for _, variant := range food.Variants {
+		if sizes[variant.Size] {
+			return fmt.Errorf("size %s is priced more than once", variant.Size)
+		}
+		sizes[variant.Size] = true
+	}
+
+	return nil
+}
+
+// PriceFor is the price of the food in size. Foods without variants cost
+// their Price in every size; foods with variants are only sold in the sizes
+// they list.
+func (food Food) PriceFor(size string) (Money, error) {
+	if len(food.Variants) == 0 {
+		if food.Price == nil {
+			return 0, fmt.Errorf("food %s has no price", food.FoodId)
+		}
+		return *food.Price, nil
+	}
+
+	for _, variant := range food.Variants {
+		if variant.Size == size {
+			return variant.Price, nil
+		}
+	}
+
+	return 0, fmt.Errorf("food %s is not sold in size %s", food.FoodId, size)
+}
+
+// PriceFrom prices the item from food: the unit price of its size and the
+// modifiers it selects. Prices sent by clients are never trusted.
+func (orderItem *OrderItem) PriceFrom(food Food) error {
+	unitPrice, err := food.PriceFor(*orderItem.Size)
+	if err != nil {
+		return err
+	}
+	modifiers, err := food.SelectModifiers(orderItem.ModifierChoices())
+	if err != nil {
+		return err
+	}
+
+	orderItem.UnitPrice = &unitPrice
+	orderItem.Modifiers = modifiers
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestMongoStoresPaymentStringsAsTheyAre(t *testing.T) {
+	s := newMongoTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/pay", map[string]string{"amount": "5", "card_token": "tok_visa"}, headers{"token": cashier["token"], "Idempotency-Key": "$payments"}), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/pay", map[string]string{"amount": "5", "card_token": "tok_visa"}, headers{"token": cashier["token"], "Idempotency-Key": "$$ROOT"}), http.StatusCreated, nil)
+
+	stored, err := s.store.Invoices.Get(t.Context(), restaurant.RestaurantId, invoice.InvoiceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Payments) != 2 || stored.Payments[0].IdempotencyKey != "$payments" || stored.Payments[1].IdempotencyKey != "$$ROOT" {
+		t.Fatalf("expected the idempotency keys stored as they were sent, got %+v", stored.Payments)
+	}
+	if len(stored.CardTransactions) != 2 || stored.CardTransactions[0].IdempotencyKey != "$payments" || stored.CardTransactions[1].IdempotencyKey != "$$ROOT" {
+		t.Fatalf("expected the card transactions stored as they were sent, got %+v", stored.CardTransactions)
+	}
+}
+
+func TestMongoInvoicesOrdersOnce(t *testing.T) {
+	invoicesOrdersOnce(t, newMongoTestServer(t))
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestCreateOrderItemsOpensAnOrder(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	table := s.seedTable(restaurant.RestaurantId, 3)
+	soup := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Soup", "4.5")
+
+	pack := map[string]interface{}{
+		"TableId":    table.TableId,
+		"OrderItems": []map[string]interface{}{{"size": "L", "unit_price": 4.499, "food_id": soup.FoodId}},
+	}
+	var created struct {
+		Order      models.Order       `json:"order"`
+		OrderItems []models.OrderItem `json:"order_items"`
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusCreated, &created)
+	if len(created.OrderItems) != 1 || created.OrderItems[0].OrderId != created.Order.OrderId || *created.OrderItems[0].UnitPrice != money("4.50") {
+		t.Fatalf("expected one item of the new order at 4.50, got %+v", created.OrderItems)
+	}
+
+	var items []models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems", nil, waiter), http.StatusOK, &items)
+	if len(items) != 1 {
+		t.Fatalf("expected 1 order item, got %d", len(items))
+	}
+}
+
+func TestCreateOrderItemsValidatesEveryItem(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	table := s.seedTable(restaurant.RestaurantId, 3)
+
+	pack := map[string]interface{}{
+		"TableId":    table.TableId,
+		"OrderItems": []map[string]interface{}{{"size": "XXL", "unit_price": 1, "food_id": "food"}},
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusBadRequest, nil)
+
+	cook := s.headersFor(restaurant.RestaurantId, models.RoleCook)
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, cook), http.StatusForbidden, nil)
+}
+
+// GetOrderItem and GetOrderItemsByOrder used to be wired to each other's
+// path parameter.
+func TestGetOrderItemAndItemsByOrder(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	table := s.seedTable(restaurant.RestaurantId, 9)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20.25")
+	order, items := s.seedOrder(restaurant.RestaurantId, table.TableId, soup, steak)
+
+	var item models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems/"+items[0].OrderItemId, nil, waiter), http.StatusOK, &item)
+	if item.OrderItemId != items[0].OrderItemId || *item.FoodId != soup.FoodId {
+		t.Fatalf("expected order item %s, got %+v", items[0].OrderItemId, item)
+	}
+	s.expect(s.do(http.MethodGet, "/orderItems/"+order.OrderId, nil, waiter), http.StatusNotFound, nil)
+
+	var view controllers.OrderView
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+order.OrderId, nil, waiter), http.StatusOK, &view)
+	if view.TotalCount != 2 || view.PaymentDue != money("24.75") || *view.TableNumber != 9 {
+		t.Fatalf("expected 2 items due 24.75 at table 9, got %+v", view)
+	}
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+items[0].OrderItemId, nil, waiter), http.StatusNotFound, nil)
+
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[1].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+order.OrderId, nil, waiter), http.StatusOK, &view)
+	if view.TotalCount != 2 || view.PaymentDue != money("4.5") || view.OrderItems[0].Status != models.OrderItemQueued || view.OrderItems[1].Status != models.OrderItemVoided {
+		t.Fatalf("expected the voided steak to be listed but not due, got %+v", view)
+	}
+}
+
+func TestUpdateOrderItem(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	soup := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Soup", "4.5")
+	_, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup)
+
+	var updated models.OrderItem
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "S"}, waiter), http.StatusOK, &updated)
+	if *updated.Size != "S" || *updated.FoodId != soup.FoodId {
+		t.Fatalf("expected only the size to change, got %+v", updated)
+	}
+
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "XXL"}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, "{", waiter), http.StatusBadRequest, nil)
+
+	// the kitchen moves items along but does not change what is billed
+	var created createdDevice
+	newDevice := map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrderItemsUpdateStatus}}
+	s.expect(s.do(http.MethodPost, "/devices", newDevice, manager), http.StatusCreated, &created)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "L"}, headers{"X-Device-Key": created.DeviceKey}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "L"}, s.headersFor(restaurant.RestaurantId, models.RoleCook)), http.StatusForbidden, nil)
+
+	// edits made from a copy fetched before the item was voided keep it voided
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[0].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "L"}, waiter), http.StatusConflict, nil)
+	if err := s.store.OrderItems.Update(context.Background(), updated); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := s.store.OrderItems.Get(context.Background(), restaurant.RestaurantId, items[0].OrderItemId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.CurrentStatus() != models.OrderItemVoided {
+		t.Fatalf("expected the item to stay voided, got %s", stored.CurrentStatus())
+	}
+}
+
+func TestOrderItemsAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, waiter := s.staff(models.RoleWaiter)
+	other := s.seedRestaurant("Elsewhere")
+	soup := s.seedFood(other.RestaurantId, s.seedMenu(other.RestaurantId).MenuId, "Soup", "4.5")
+	order, items := s.seedOrder(other.RestaurantId, s.seedTable(other.RestaurantId, 1).TableId, soup)
+
+	s.expect(s.do(http.MethodGet, "/orderItems/"+items[0].OrderItemId, nil, waiter), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+order.OrderId, nil, waiter), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "S"}, waiter), http.StatusNotFound, nil)
+
+	var list []models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems", nil, waiter), http.StatusOK, &list)
+	if len(list) != 0 {
+		t.Fatalf("expected no order items, got %d", len(list))
+	}
+}
+
+func TestOrderItemsAreRoutedToTheirStation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20", models.StationGrill)
+	fries := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Fries", "3", models.StationFryer)
+
+	pack := map[string]interface{}{
+		"TableId": s.seedTable(restaurant.RestaurantId, 1).TableId,
+		"OrderItems": []map[string]interface{}{
+			{"size": "M", "unit_price": 20, "food_id": steak.FoodId},
+			{"size": "L", "unit_price": 3, "food_id": fries.FoodId},
+		},
+	}
+	var created struct {
+		OrderItems []models.OrderItem `json:"order_items"`
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusCreated, &created)
+	if created.OrderItems[0].Station != models.StationGrill || created.OrderItems[0].Status != models.OrderItemQueued {
+		t.Fatalf("expected a queued grill item, got %+v", created.OrderItems[0])
+	}
+
+	var grill []models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems-station/"+models.StationGrill, nil, waiter), http.StatusOK, &grill)
+	if len(grill) != 1 || *grill[0].FoodId != steak.FoodId {
+		t.Fatalf("expected only the steak on the grill, got %+v", grill)
+	}
+
+	pack["OrderItems"] = []map[string]interface{}{{"size": "M", "unit_price": 1, "food_id": "missing"}}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodGet, "/orderItems-station/WOK", nil, waiter), http.StatusBadRequest, nil)
+}
+
+func TestStationQueueIsLiveAndOldestFirst(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cook := s.staff(models.RoleCook)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20", models.StationGrill)
+	burger := s.seedStationFood(restaurant.RestaurantId, menu.MenuId, "Burger", "12", models.StationGrill)
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	_, first := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+	_, second := s.seedOrder(restaurant.RestaurantId, table.TableId, burger, steak)
+
+	var queue []models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems-station/"+models.StationGrill, nil, cook), http.StatusOK, &queue)
+	if len(queue) != 3 || queue[0].OrderItemId != first[0].OrderItemId || queue[1].OrderItemId != second[0].OrderItemId {
+		t.Fatalf("expected the three grill items oldest first, got %+v", queue)
+	}
+
+	for i := 0; i < 3; i++ {
+		s.expect(s.do(http.MethodPost, "/orderItems/"+first[0].OrderItemId+"/bump", nil, cook), http.StatusOK, nil)
+	}
+	// the kitchen cannot void items, which takes a reason and may take a manager
+	s.expect(s.do(http.MethodPost, "/orderItems/"+second[1].OrderItemId+"/status", map[string]string{"status": models.OrderItemVoided}, cook), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+second[1].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, cook), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+second[1].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusCreated, nil)
+
+	s.expect(s.do(http.MethodGet, "/orderItems-station/"+models.StationGrill, nil, cook), http.StatusOK, &queue)
+	if len(queue) != 1 || queue[0].OrderItemId != second[0].OrderItemId {
+		t.Fatalf("expected only the burger left, got %+v", queue)
+	}
+}
+
+func TestBumpingAnOrderItemThroughTheKitchen(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cook := s.staff(models.RoleCook)
+	steak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	_, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+	path := "/orderItems/" + items[0].OrderItemId
+
+	var bumped models.OrderItem
+	for _, status := range []string{models.OrderItemCooking, models.OrderItemReady, models.OrderItemServed} {
+		s.expect(s.do(http.MethodPost, path+"/bump", nil, cook), http.StatusOK, &bumped)
+		if bumped.Status != status || bumped.StatusUpdatedAt.IsZero() {
+			t.Fatalf("expected %s, got %s", status, bumped.Status)
+		}
+	}
+
+	s.expect(s.do(http.MethodPost, path+"/bump", nil, cook), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, path+"/status", map[string]string{"status": models.OrderItemCooking}, cook), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, path+"/status", map[string]string{"status": "BURNT"}, cook), http.StatusBadRequest, nil)
+
+	cashier := s.headersFor(restaurant.RestaurantId, models.RoleCashier)
+	s.expect(s.do(http.MethodPost, path+"/bump", nil, cashier), http.StatusForbidden, nil)
+}
+
+func TestKitchenDisplaysBumpItemsWithTheirKey(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	steak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	_, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var screen, viewer createdDevice
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Grill screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrdersRead, models.ScopeOrderItemsUpdateStatus}}, manager), http.StatusCreated, &screen)
+	s.expect(s.do(http.MethodPost, "/devices", map[string]interface{}{"name": "Pass screen", "type": models.DeviceKitchenDisplay, "scopes": []string{models.ScopeOrdersRead}}, manager), http.StatusCreated, &viewer)
+
+	s.expect(s.do(http.MethodGet, "/orderItems-station/"+models.StationGrill, nil, headers{"X-Device-Key": viewer.DeviceKey}), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[0].OrderItemId+"/bump", nil, headers{"X-Device-Key": viewer.DeviceKey}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[0].OrderItemId+"/bump", nil, headers{"X-Device-Key": screen.DeviceKey}), http.StatusOK, nil)
+}
+
+func TestCancellingAnOrderVoidsItsLiveItems(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	steak := s.seedStationFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20", models.StationGrill)
+	order, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak, steak)
+
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[0].OrderItemId+"/bump", nil, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, waiter), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusOK, nil)
+
+	var queue []models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems-station/"+models.StationGrill, nil, waiter), http.StatusOK, &queue)
+	if len(queue) != 0 {
+		t.Fatalf("expected the grill queue to be empty, got %+v", queue)
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[1].OrderItemId+"/bump", nil, waiter), http.StatusConflict, nil)
+}
+
+func TestOrderItemModifiers(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	table := s.seedTable(restaurant.RestaurantId, 4)
+	burger := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Burger", "10")
+
+	groups := []map[string]interface{}{
+		{"group_id": "doneness", "name": "Doneness", "required": true, "max_selections": 1, "options": []map[string]interface{}{{"option_id": "rare", "name": "Rare"}, {"option_id": "medium", "name": "Medium"}}},
+		{"group_id": "extras", "name": "Extras", "max_selections": 2, "options": []map[string]interface{}{{"option_id": "bacon", "name": "Bacon", "price_delta": 2}, {"option_id": "cheese", "name": "Cheese", "price_delta": 1.5}, {"option_id": "egg", "name": "Egg", "price_delta": 1}}},
+	}
+	s.expect(s.do(http.MethodPatch, "/foods/"+burger.FoodId, map[string]interface{}{"modifier_groups": groups}, manager), http.StatusOK, nil)
+
+	choose := func(choices ...string) []map[string]string {
+		modifiers := []map[string]string{}
+		for i := 0; i < len(choices); i += 2 {
+			modifiers = append(modifiers, map[string]string{"group_id": choices[i], "option_id": choices[i+1]})
+		}
+		return modifiers
+	}
+	pack := func(modifiers []map[string]string) map[string]interface{} {
+		return map[string]interface{}{"TableId": table.TableId, "OrderItems": []map[string]interface{}{{"size": "M", "unit_price": 10, "food_id": burger.FoodId, "modifiers": modifiers}}}
+	}
+
+	for name, modifiers := range map[string][]map[string]string{
+		"required group missing":  choose("extras", "bacon"),
+		"too many selections":     choose("doneness", "rare", "extras", "bacon", "extras", "cheese", "extras", "egg"),
+		"unknown option":          choose("doneness", "raw"),
+		"option of another group": choose("extras", "rare"),
+		"duplicate option":        choose("doneness", "rare", "doneness", "rare"),
+	} {
+		if response := s.do(http.MethodPost, "/orderItems", pack(modifiers), waiter); response.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", name, response.Code)
+		}
+	}
+
+	var created struct {
+		Order      models.Order       `json:"order"`
+		OrderItems []models.OrderItem `json:"order_items"`
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack(choose("doneness", "rare", "extras", "bacon", "extras", "cheese")), waiter), http.StatusCreated, &created)
+	modifiers := created.OrderItems[0].Modifiers
+	if len(modifiers) != 3 || modifiers[1].OptionName != "Bacon" || modifiers[1].PriceDelta != money("2") {
+		t.Fatalf("expected the selections to be stored with their names and prices, got %+v", modifiers)
+	}
+
+	var view controllers.OrderView
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+created.Order.OrderId, nil, waiter), http.StatusOK, &view)
+	if view.PaymentDue != money("13.50") || len(view.OrderItems[0].Modifiers) != 3 {
+		t.Fatalf("expected 13.50 due with the modifiers listed, got %+v", view)
+	}
+
+	orderItemId := created.OrderItems[0].OrderItemId
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItemId, map[string]interface{}{"modifiers": choose("extras", "egg")}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItemId, map[string]interface{}{"modifiers": choose("doneness", "medium")}, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+created.Order.OrderId, nil, waiter), http.StatusOK, &view)
+	if view.PaymentDue != money("10") {
+		t.Fatalf("expected the extras to be dropped, got %v due", view.PaymentDue)
+	}
+}
+
+func TestOrderItemsArePricedFromTheirSize(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	table := s.seedTable(restaurant.RestaurantId, 2)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	fries := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Fries", "4")
+	soup := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "6")
+	variants := []map[string]interface{}{{"size": models.SizeSmall, "price": 3}, {"size": models.SizeLarge, "price": 5}}
+	s.expect(s.do(http.MethodPatch, "/foods/"+fries.FoodId, map[string]interface{}{"variants": variants}, manager), http.StatusOK, nil)
+
+	unsold := map[string]interface{}{"TableId": table.TableId, "OrderItems": []map[string]interface{}{{"size": models.SizeMedium, "food_id": fries.FoodId}}}
+	s.expect(s.do(http.MethodPost, "/orderItems", unsold, waiter), http.StatusBadRequest, nil)
+	negative := map[string]interface{}{"TableId": table.TableId, "OrderItems": []map[string]interface{}{{"size": models.SizeLarge, "quantity": -1, "food_id": fries.FoodId}}}
+	s.expect(s.do(http.MethodPost, "/orderItems", negative, waiter), http.StatusBadRequest, nil)
+
+	pack := map[string]interface{}{"TableId": table.TableId, "OrderItems": []map[string]interface{}{
+		{"size": models.SizeLarge, "quantity": 3, "unit_price": 0.01, "food_id": fries.FoodId},
+		{"size": models.SizeExtraLarge, "food_id": soup.FoodId},
+	}}
+	var created struct {
+		Order      models.Order       `json:"order"`
+		OrderItems []models.OrderItem `json:"order_items"`
+	}
+	s.expect(s.do(http.MethodPost, "/orderItems", pack, waiter), http.StatusCreated, &created)
+	if *created.OrderItems[0].UnitPrice != money("5") || *created.OrderItems[1].UnitPrice != money("6") || *created.OrderItems[1].Quantity != 1 {
+		t.Fatalf("expected the prices of the sizes and a default quantity of one, got %+v", created.OrderItems)
+	}
+
+	var view controllers.OrderView
+	s.expect(s.do(http.MethodGet, "/orderItems-order/"+created.Order.OrderId, nil, waiter), http.StatusOK, &view)
+	if view.PaymentDue != money("21") || view.OrderItems[0].LineTotal != money("15") || view.OrderItems[0].Quantity != 3 {
+		t.Fatalf("expected 3 large fries at 15 and 21 due, got %+v", view)
+	}
+
+	orderItemId := created.OrderItems[0].OrderItemId
+	var updated models.OrderItem
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItemId, map[string]interface{}{"size": models.SizeSmall, "unit_price": 100}, waiter), http.StatusOK, &updated)
+	if *updated.UnitPrice != money("3") || *updated.Quantity != 3 {
+		t.Fatalf("expected 3 small fries at 3, got %+v", updated)
+	}
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItemId, map[string]interface{}{"size": models.SizeMedium}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+orderItemId, map[string]interface{}{"quantity": 0}, waiter), http.StatusBadRequest, nil)
+}
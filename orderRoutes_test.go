@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestOrderLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	first, second := s.seedTable(restaurant.RestaurantId, 1), s.seedTable(restaurant.RestaurantId, 2)
+
+	var created models.Order
+	s.expect(s.do(http.MethodPost, "/orders", map[string]interface{}{"order_date": time.Now(), "table_id": first.TableId}, waiter), http.StatusCreated, &created)
+	if created.OrderId == "" || created.RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected a stored order in %s, got %+v", restaurant.RestaurantId, created)
+	}
+
+	var orders []models.Order
+	s.expect(s.do(http.MethodGet, "/orders", nil, waiter), http.StatusOK, &orders)
+	if len(orders) != 1 {
+		t.Fatalf("expected 1 order, got %d", len(orders))
+	}
+
+	var moved models.Order
+	s.expect(s.do(http.MethodPatch, "/orders/"+created.OrderId, map[string]string{"table_id": second.TableId}, waiter), http.StatusOK, &moved)
+	if *moved.TableId != second.TableId {
+		t.Fatalf("expected the order at table %s, got %s", second.TableId, *moved.TableId)
+	}
+
+	var fetched models.Order
+	s.expect(s.do(http.MethodGet, "/orders/"+created.OrderId, nil, waiter), http.StatusOK, &fetched)
+	if *fetched.TableId != second.TableId {
+		t.Fatal("expected the move to be stored")
+	}
+}
+
+func TestOrdersNeedATableOfTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	foreignTable := s.seedTable(s.seedRestaurant("Elsewhere").RestaurantId, 1)
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId)
+
+	s.expect(s.do(http.MethodPost, "/orders", map[string]interface{}{"order_date": time.Now()}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/orders", map[string]interface{}{"order_date": time.Now(), "table_id": foreignTable.TableId}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+order.OrderId, map[string]string{"table_id": foreignTable.TableId}, waiter), http.StatusBadRequest, nil)
+
+	cook := s.headersFor(restaurant.RestaurantId, models.RoleCook)
+	s.expect(s.do(http.MethodGet, "/orders", nil, cook), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders", map[string]interface{}{"order_date": time.Now(), "table_id": foreignTable.TableId}, cook), http.StatusForbidden, nil)
+}
+
+func TestOrdersAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, waiter := s.staff(models.RoleWaiter)
+	other := s.seedRestaurant("Elsewhere")
+	order, _ := s.seedOrder(other.RestaurantId, s.seedTable(other.RestaurantId, 1).TableId)
+
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId, nil, waiter), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+order.OrderId, map[string]string{}, waiter), http.StatusNotFound, nil)
+}
+
+func TestOrderLifecycleTransitions(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	cashier := s.headersFor(restaurant.RestaurantId, models.RoleCashier)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var moved models.Order
+	for _, status := range []string{models.OrderSentToKitchen, models.OrderPreparing, models.OrderReady, models.OrderServed} {
+		s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": status}, waiter), http.StatusOK, &moved)
+		if moved.Status != status {
+			t.Fatalf("expected %s, got %s", status, moved.Status)
+		}
+	}
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderPaid}, waiter), http.StatusConflict, nil)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "20"}, cashier), http.StatusCreated, nil)
+
+	var fetched models.Order
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId, nil, waiter), http.StatusOK, &fetched)
+	if fetched.Status != models.OrderPaid || len(fetched.StatusHistory) != 5 || fetched.StatusHistory[4].ChangedAt.IsZero() || fetched.StatusHistory[4].ChangedBy == "" {
+		t.Fatalf("expected the order to be PAID with its invoice, got %s with %+v", fetched.Status, fetched.StatusHistory)
+	}
+
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, waiter), http.StatusConflict, nil)
+}
+
+func TestCreatedOrdersStartOpen(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	table := s.seedTable(restaurant.RestaurantId, 1)
+
+	var created models.Order
+	s.expect(s.do(http.MethodPost, "/orders", map[string]interface{}{"order_date": time.Now(), "table_id": table.TableId, "status": models.OrderPaid}, waiter), http.StatusCreated, &created)
+	if created.Status != models.OrderOpen || len(created.StatusHistory) != 1 {
+		t.Fatalf("expected an OPEN order with its opening recorded, got %s with %+v", created.Status, created.StatusHistory)
+	}
+}
+
+func TestIllegalOrderTransitionsAreRejected(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId)
+
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderServed}, waiter), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderOpen}, waiter), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": "EATEN"}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{}, waiter), http.StatusBadRequest, nil)
+
+	other := s.seedRestaurant("Elsewhere")
+	foreign, _ := s.seedOrder(other.RestaurantId, s.seedTable(other.RestaurantId, 1).TableId)
+	s.expect(s.do(http.MethodPost, "/orders/"+foreign.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, waiter), http.StatusNotFound, nil)
+}
+
+func TestClosedOrdersCannotBeEdited(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	soup := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Soup", "4.5")
+	order, items := s.seedOrder(restaurant.RestaurantId, table.TableId, soup)
+
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "S"}, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, waiter), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, s.headersFor(restaurant.RestaurantId, models.RoleManager)), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]string{"size": "L"}, waiter), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+order.OrderId, map[string]string{"table_id": table.TableId}, waiter), http.StatusConflict, nil)
+}
+
+func TestOrderTotals(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	owner := s.headersFor("", models.RoleOwner)
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	steak, wine := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Wine", "7.99")
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	order, items := s.seedOrder(restaurant.RestaurantId, table.TableId, steak, steak, wine, steak)
+
+	pricing := map[string]interface{}{"tax_rates": map[string]float64{"ALCOHOL": 20}, "default_tax_rate": 10, "service_charge_rate": 10, "service_charge_min_guests": 6, "cash_rounding": "0.05"}
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"pricing": pricing}, owner), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/foods/"+wine.FoodId, map[string]string{"category": "ALCOHOL"}, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/tables/"+table.TableId, map[string]int{"number_of_guests": 8}, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orderItems/"+items[3].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, manager), http.StatusCreated, nil)
+
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[1].OrderItemId+"/discount", map[string]interface{}{"type": models.DiscountPercent, "percent": 50}, waiter), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[1].OrderItemId+"/discount", map[string]interface{}{"type": "HALF"}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[1].OrderItemId+"/discount", map[string]interface{}{"type": models.DiscountPercent, "percent": 50, "reason": "Overcooked"}, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPut, "/orders/"+order.OrderId+"/discount", map[string]interface{}{"type": models.DiscountAmount, "amount": "3.81"}, manager), http.StatusOK, nil)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, waiter), http.StatusOK, &totals)
+	if len(totals.Lines) != 3 || totals.Subtotal != money("47.99") || totals.LineDiscounts != money("10") || totals.OrderDiscount != money("3.81") || totals.Net != money("34.18") {
+		t.Fatalf("expected 47.99 less 13.81 in discounts over 3 lines, got %+v", totals)
+	}
+	if totals.Lines[0].OrderDiscount != money("2.01") || totals.Lines[1].OrderDiscount != money("1") || totals.Lines[2].OrderDiscount != money("0.80") {
+		t.Fatalf("expected the order discount to be shared by what the lines cost, got %+v", totals.Lines)
+	}
+	if totals.ServiceCharge != money("3.42") || len(totals.Taxes) != 2 || totals.Taxes[0].Tax != money("2.70") || totals.Taxes[1].Category != "ALCOHOL" || totals.Taxes[1].Tax != money("1.44") {
+		t.Fatalf("expected a 3.42 service charge and 2.70 + 1.44 in tax, got %+v", totals)
+	}
+	if totals.Rounding != money("0.01") || totals.Total != money("41.75") {
+		t.Fatalf("expected 41.74 to be rounded to 41.75, got %v after %v", totals.Total, totals.Rounding)
+	}
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, manager), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodDelete, "/orders/"+order.OrderId+"/discount", nil, manager), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[1].OrderItemId+"/discount", map[string]interface{}{"type": models.DiscountPercent, "percent": 10, "reason": "Late"}, manager), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPatch, "/orderItems/"+items[0].OrderItemId, map[string]int{"quantity": 2}, waiter), http.StatusConflict, nil)
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, manager), http.StatusOK, &view)
+	if view.PaymentDue != money("41.75") || view.Totals.OrderDiscount != money("3.81") {
+		t.Fatalf("expected the invoice to keep the totals it was created with, got %+v", view)
+	}
+
+	s.expect(s.do(http.MethodGet, "/orders/missing/totals", nil, waiter), http.StatusNotFound, nil)
+}
+
+func TestServiceChargeIsOnlyForLargeParties(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	owner := s.headersFor("", models.RoleOwner)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	pricing := map[string]interface{}{"service_charge_rate": 12.5, "service_charge_min_guests": 6}
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"pricing": pricing}, owner), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"pricing": map[string]interface{}{"default_tax_rate": 120}}, owner), http.StatusBadRequest, nil)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, waiter), http.StatusOK, &totals)
+	if totals.ServiceCharge != 0 || totals.Total != money("20") {
+		t.Fatalf("expected a party of 4 to pay 20 without service charge, got %+v", totals)
+	}
+}
+
+func TestOrderEditsMadeFromAStaleCopyLeaveTheRestAlone(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Welcome", "type": models.PromotionPercent, "percent": 10, "code": "HELLO"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/coupons", map[string]string{"code": "HELLO"}, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPut, "/orders/"+order.OrderId+"/discount", map[string]interface{}{"type": models.DiscountAmount, "amount": "1"}, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/transition", map[string]string{"status": models.OrderCancelled}, manager), http.StatusOK, nil)
+
+	discounted, err := s.store.Orders.SetDiscount(t.Context(), restaurant.RestaurantId, order.OrderId, models.OrderOpen, nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discounted {
+		t.Fatal("expected a cancelled order to keep its discount")
+	}
+
+	var stored models.Order
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId, nil, manager), http.StatusOK, &stored)
+	if stored.CurrentStatus() != models.OrderCancelled || len(stored.Coupons) != 1 || stored.Discount == nil {
+		t.Fatalf("expected the order cancelled with its coupon and discount, got %+v", stored)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"restaurant-management-system/config"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+)
+
+func TestCardPaymentsThroughTheGateway(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	pay := func(key string, payment map[string]string, status int) models.Invoice {
+		t.Helper()
+
+		var paid models.Invoice
+		s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/pay", payment, headers{"token": cashier["token"], "Idempotency-Key": key}), status, &paid)
+		return paid
+	}
+
+	pay("", map[string]string{"amount": "10", "card_token": "tok_visa"}, http.StatusBadRequest)
+	pay("a", map[string]string{"amount": "10"}, http.StatusBadRequest)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CARD", "amount": "10"}, cashier), http.StatusBadRequest, nil)
+
+	declined := s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/pay", map[string]string{"amount": "10", "card_token": "tok_insufficient_funds"}, headers{"token": cashier["token"], "Idempotency-Key": "a"})
+	var decline map[string]string
+	s.expect(declined, http.StatusPaymentRequired, &decline)
+	if decline["decline_code"] != "insufficient_funds" {
+		t.Fatalf("expected the decline code, got %v", decline)
+	}
+	pay("a", map[string]string{"amount": "10", "card_token": "tok_visa"}, http.StatusPaymentRequired)
+
+	paid := pay("b", map[string]string{"amount": "10", "card_token": "tok_visa"}, http.StatusCreated)
+	if *paid.PaymentStatus != models.InvoicePartiallyPaid || len(paid.Payments) != 1 || paid.Payments[0].TransactionId == "" || paid.Payments[0].Method != models.PaymentCard {
+		t.Fatalf("expected a captured card payment of 10, got %+v", paid)
+	}
+	replayed := pay("b", map[string]string{"amount": "10", "card_token": "tok_visa"}, http.StatusOK)
+	if len(replayed.Payments) != 1 {
+		t.Fatalf("expected repeating the request not to charge the card again, got %+v", replayed.Payments)
+	}
+
+	pay("c", map[string]string{"amount": "5", "card_token": "tok_timeout"}, http.StatusGatewayTimeout)
+	pay("d", map[string]string{"amount": "5", "card_token": "tok_capture_timeout"}, http.StatusGatewayTimeout)
+
+	paid = pay("e", map[string]string{"amount": "14.50", "card_token": "tok_mastercard"}, http.StatusCreated)
+	if *paid.PaymentStatus != models.InvoicePaid || paid.Balance() != 0 {
+		t.Fatalf("expected the invoice to be paid, got %+v", paid)
+	}
+	pay("f", map[string]string{"amount": "1", "card_token": "tok_visa"}, http.StatusConflict)
+	pay("b", map[string]string{"amount": "10", "card_token": "tok_visa"}, http.StatusOK)
+
+	statuses := []string{}
+	for _, attempt := range paid.CardTransactions {
+		statuses = append(statuses, attempt.Status)
+	}
+	expected := []string{models.CardDeclined, models.CardCaptured, models.CardFailed, models.CardFailed, models.CardCaptured}
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected every attempt to be recorded as %v, got %v", expected, statuses)
+	}
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Fatalf("expected every attempt to be recorded as %v, got %v", expected, statuses)
+		}
+	}
+	if paid.CardTransactions[4].Brand != "MASTERCARD" {
+		t.Fatalf("expected the card brand to be recorded, got %+v", paid.CardTransactions[4])
+	}
+
+	if paid.CardTransactions[3].Void != models.CardVoided || paid.CardTransactions[2].Void != "" {
+		t.Fatalf("expected the void of the authorization that failed to capture to be recorded, got %+v", paid.CardTransactions)
+	}
+	if _, err := s.gateway.Refund(context.Background(), paid.CardTransactions[3].TransactionId, money("5")); err != helpers.ErrTransactionState {
+		t.Fatalf("expected the authorization that failed to capture to be voided, got %v", err)
+	}
+}
+
+func TestCardPaymentsRetriedWithTheSameKeyChargeOnce(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	path := "/invoice/" + invoice.InvoiceId + "/pay"
+
+	// a retry after the capture timed out is authorized afresh
+	retry := headers{"token": cashier["token"], "Idempotency-Key": "retry"}
+	s.expect(s.do(http.MethodPost, path, map[string]string{"amount": "5", "card_token": "tok_capture_timeout"}, retry), http.StatusGatewayTimeout, nil)
+	var paid models.Invoice
+	s.expect(s.do(http.MethodPost, path, map[string]string{"amount": "5", "card_token": "tok_visa"}, retry), http.StatusCreated, &paid)
+	if len(paid.Payments) != 1 || s.gateway.Captured(paid.Payments[0].TransactionId) != money("5") {
+		t.Fatalf("expected the retry to be charged 5, got %+v", paid.Payments)
+	}
+
+	// requests sent at once with the same key share one capture, kept
+	responses := make([]*httptest.ResponseRecorder, 8)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, path, map[string]string{"amount": "5", "card_token": "tok_visa"}, headers{"token": cashier["token"], "Idempotency-Key": "same"})
+		}(i)
+	}
+	wg.Wait()
+	for _, response := range responses {
+		if response.Code != http.StatusCreated && response.Code != http.StatusOK {
+			t.Fatalf("expected every request to be answered with the payment, got %d: %s", response.Code, response.Body.String())
+		}
+	}
+
+	stored, err := s.store.Invoices.Get(t.Context(), restaurant.RestaurantId, invoice.InvoiceId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment, found := stored.PaymentWithKey("same")
+	if len(stored.Payments) != 2 || !found || s.gateway.Captured(payment.TransactionId) != money("5") {
+		t.Fatalf("expected one payment of 5 kept on the card, got %+v", stored.Payments)
+	}
+}
+
+func TestSimulatorDeclinesAreConfigurable(t *testing.T) {
+	cfg, err := config.FromValues(map[string]string{"secret_key": "test", "payment_simulator_declines": "tok_stolen:stolen_card, tok_lost:lost_card"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := helpers.NewPaymentGateway(cfg).Authorize(context.Background(), helpers.CardCharge{Amount: money("1"), CardToken: "tok_lost"})
+	if err != nil || result.Status != helpers.GatewayDeclined || result.DeclineCode != "lost_card" {
+		t.Fatalf("expected tok_lost to be declined as lost_card, got %+v, %v", result, err)
+	}
+
+	if _, err := config.FromValues(map[string]string{"secret_key": "test", "payment_simulator_declines": "tok_stolen"}); err == nil {
+		t.Fatal("expected declines without a code to be rejected")
+	}
+	if _, err := config.FromValues(map[string]string{"secret_key": "test", "payment_gateway": "acme"}); err == nil {
+		t.Fatal("expected unknown gateways to be rejected")
+	}
+}
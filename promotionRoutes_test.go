@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestHappyHourBuyOneGetOneFree(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	owner := s.headersFor("", models.RoleOwner)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	beer, wine := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Beer", "5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Wine", "8")
+	steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20")
+
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]string{"time_zone": "Mars/Olympus"}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]string{"time_zone": "Asia/Tokyo"}, owner), http.StatusOK, nil)
+
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	local := time.Now().In(tokyo)
+	today, tomorrow := strings.ToUpper(local.Weekday().String()), strings.ToUpper(local.AddDate(0, 0, 1).Weekday().String())
+
+	happyHour := map[string]interface{}{
+		"name": "Happy hour", "type": models.PromotionBuyGetFree, "buy_quantity": 1, "free_quantity": 1,
+		"food_ids": []string{beer.FoodId, wine.FoodId}, "days": []string{today},
+		"start_time": local.Add(-time.Hour).Format("15:04"), "end_time": local.Add(time.Hour).Format("15:04"),
+	}
+	s.expect(s.do(http.MethodPost, "/promotions", happyHour, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Half price", "type": models.PromotionPercent, "percent": 50, "days": []string{tomorrow}}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Free beer", "type": models.PromotionBuyGetFree, "buy_quantity": 1}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Late", "type": models.PromotionPercent, "percent": 5, "start_time": "25:00", "end_time": "26:00"}, manager), http.StatusBadRequest, nil)
+
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, beer, wine, beer, steak)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.Subtotal != money("38") || totals.Promotions != money("5") || totals.Total != money("33") {
+		t.Fatalf("expected the cheaper of beer and wine to be free, got %+v", totals)
+	}
+	if len(totals.Discounts) != 1 || totals.Discounts[0].Source != models.AppliedPromotion || totals.Discounts[0].Name != "Happy hour" {
+		t.Fatalf("expected only the happy hour to apply today, got %+v", totals.Discounts)
+	}
+}
+
+func TestCouponCodes(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	cook := s.headersFor(restaurant.RestaurantId, models.RoleCook)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	first, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+	second, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+
+	coupon := map[string]interface{}{"name": "Staff meal", "type": models.PromotionPercent, "percent": 10, "code": "staff10", "max_uses": 1}
+	s.expect(s.do(http.MethodPost, "/promotions", coupon, cook), http.StatusForbidden, nil)
+
+	var promotion models.Promotion
+	s.expect(s.do(http.MethodPost, "/promotions", coupon, manager), http.StatusCreated, &promotion)
+	if *promotion.Code != "STAFF10" {
+		t.Fatalf("expected the code to be stored in upper case, got %s", *promotion.Code)
+	}
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Copy", "type": models.PromotionAmount, "amount": "1", "code": "Staff10"}, manager), http.StatusConflict, nil)
+
+	s.expect(s.do(http.MethodPost, "/orders/"+first.OrderId+"/coupons", map[string]string{"code": "NOPE"}, waiter), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+first.OrderId+"/coupons", map[string]string{"code": "staff10"}, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+first.OrderId+"/coupons", map[string]string{"code": "STAFF10"}, waiter), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+second.OrderId+"/coupons", map[string]string{"code": "STAFF10"}, waiter), http.StatusConflict, nil)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+first.OrderId+"/totals", nil, waiter), http.StatusOK, &totals)
+	if totals.Promotions != money("2") || len(totals.Discounts) != 1 || totals.Discounts[0].Source != models.AppliedCoupon || totals.Discounts[0].Code != "STAFF10" {
+		t.Fatalf("expected 10%% off through the coupon, got %+v", totals)
+	}
+	s.expect(s.do(http.MethodGet, "/orders/"+second.OrderId+"/totals", nil, waiter), http.StatusOK, &totals)
+	if totals.Promotions != 0 {
+		t.Fatalf("expected coupons to only apply to the orders they were redeemed on, got %+v", totals)
+	}
+
+	s.expect(s.do(http.MethodDelete, "/orders/"+first.OrderId+"/coupons/staff10", nil, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodDelete, "/orders/"+first.OrderId+"/coupons/staff10", nil, waiter), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+second.OrderId+"/coupons", map[string]string{"code": "STAFF10"}, waiter), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodGet, "/promotions/"+promotion.PromotionId, nil, waiter), http.StatusOK, &promotion)
+	if promotion.Uses != 1 {
+		t.Fatalf("expected the removed coupon to give its use back, got %d uses", promotion.Uses)
+	}
+
+	s.expect(s.do(http.MethodPatch, "/promotions/"+promotion.PromotionId, map[string]bool{"active": false}, manager), http.StatusOK, &promotion)
+	if promotion.Uses != 1 {
+		t.Fatalf("expected updates to keep the uses, got %d", promotion.Uses)
+	}
+	s.expect(s.do(http.MethodDelete, "/orders/"+second.OrderId+"/coupons/STAFF10", nil, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+second.OrderId+"/coupons", map[string]string{"code": "STAFF10"}, waiter), http.StatusConflict, nil)
+}
+
+func TestCouponsAppliedAtOnceAreRedeemedOnce(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var promotion models.Promotion
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Welcome", "type": models.PromotionPercent, "percent": 10, "code": "HELLO", "max_uses": 5}, manager), http.StatusCreated, &promotion)
+
+	responses := make([]*httptest.ResponseRecorder, 10)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, "/orders/"+order.OrderId+"/coupons", map[string]string{"code": "HELLO"}, manager)
+		}(i)
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, response := range responses {
+		if response.Code == http.StatusOK {
+			applied++
+			continue
+		}
+		s.expect(response, http.StatusConflict, nil)
+	}
+	s.expect(s.do(http.MethodGet, "/promotions/"+promotion.PromotionId, nil, manager), http.StatusOK, &promotion)
+	if applied != 1 || promotion.Uses != 1 {
+		t.Fatalf("expected the coupon to be applied and used once, got %d applied and %d uses", applied, promotion.Uses)
+	}
+
+	var stored models.Order
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId, nil, manager), http.StatusOK, &stored)
+	if len(stored.Coupons) != 1 {
+		t.Fatalf("expected the code on the order once, got %v", stored.Coupons)
+	}
+}
+
+func TestPromotionsByMenuCategory(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+
+	start, end := time.Now().Add(time.Hour), time.Now().Add(30*24*time.Hour)
+	drinks := models.Menu{ID: primitive.NewObjectID(), Name: "Bar", Category: "Drinks", StartDate: &start, EndDate: &end, RestaurantId: restaurant.RestaurantId}
+	drinks.MenuId = drinks.ID.Hex()
+	if err := s.store.Menus.Create(context.Background(), drinks); err != nil {
+		t.Fatal(err)
+	}
+	cola, juice := s.seedFood(restaurant.RestaurantId, drinks.MenuId, "Cola", "2"), s.seedFood(restaurant.RestaurantId, drinks.MenuId, "Juice", "4")
+
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Drinks deal", "type": models.PromotionAmount, "amount": "3", "menu_categories": []string{"Drinks"}}, manager), http.StatusCreated, nil)
+	ended := map[string]interface{}{"name": "Opening week", "type": models.PromotionPercent, "percent": 50, "starts_at": time.Now().Add(-48 * time.Hour), "ends_at": time.Now().Add(-24 * time.Hour)}
+	s.expect(s.do(http.MethodPost, "/promotions", ended, manager), http.StatusCreated, nil)
+
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, cola, juice, steak)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.Promotions != money("3") || totals.Lines[0].Promotions != money("1") || totals.Lines[1].Promotions != money("2") || totals.Lines[2].Promotions != 0 {
+		t.Fatalf("expected 3 off the drinks shared by price, got %+v", totals.Lines)
+	}
+	if totals.Total != money("23") {
+		t.Fatalf("expected the ended promotion not to apply, got %+v", totals)
+	}
+}
+
+func TestInvoiceShowsAppliedDiscounts(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	manager, token := s.seedUser(restaurant.RestaurantId, models.RoleManager)
+	managerHeaders := headers{"token": token}
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, items := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak, steak)
+
+	s.expect(s.do(http.MethodPost, "/promotions", map[string]interface{}{"name": "Welcome", "type": models.PromotionAmount, "amount": "5", "code": "WELCOME"}, managerHeaders), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/orders/"+order.OrderId+"/coupons", map[string]string{"code": "welcome"}, cashier), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[0].OrderItemId+"/discount", map[string]interface{}{"type": models.DiscountPercent, "percent": 100, "reason": "Birthday"}, cashier), http.StatusForbidden, nil)
+
+	var discounted models.OrderItem
+	s.expect(s.do(http.MethodPut, "/orderItems/"+items[0].OrderItemId+"/discount", map[string]interface{}{"type": models.DiscountPercent, "percent": 100, "reason": "Birthday"}, managerHeaders), http.StatusOK, &discounted)
+	if discounted.Discount.ApprovedBy != manager.UserId || discounted.Discount.ApprovedAt.IsZero() {
+		t.Fatalf("expected the manager to be recorded as approving the discount, got %+v", discounted.Discount)
+	}
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, cashier), http.StatusOK, &view)
+	if view.PaymentDue != money("15") || len(view.AppliedDiscounts) != 2 {
+		t.Fatalf("expected 40 less a free steak and 5 off, got %v with %+v", view.PaymentDue, view.AppliedDiscounts)
+	}
+	manual, coupon := view.AppliedDiscounts[0], view.AppliedDiscounts[1]
+	if manual.Source != models.AppliedManual || manual.Name != "Birthday" || manual.ApprovedBy != manager.UserId || manual.Amount != money("20") {
+		t.Fatalf("expected the approved birthday discount, got %+v", manual)
+	}
+	if coupon.Source != models.AppliedCoupon || coupon.Code != "WELCOME" || coupon.Amount != money("5") {
+		t.Fatalf("expected the welcome coupon, got %+v", coupon)
+	}
+
+	var stored models.Order
+	s.expect(s.do(http.MethodGet, "/orders/"+order.OrderId, nil, cashier), http.StatusOK, &stored)
+	if len(stored.AppliedDiscounts) != 2 {
+		t.Fatalf("expected the discounts to be recorded against the order, got %+v", stored.AppliedDiscounts)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestReceiptsRenderAsHTMLOrPDF(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	restaurant.Pricing = &models.PricingSettings{DefaultTaxRate: 10}
+	restaurant.Receipt = &models.ReceiptSettings{Footer: "Thanks for visiting Fish & Chips"}
+	if err := s.store.Restaurants.Update(context.Background(), restaurant); err != nil {
+		t.Fatal(err)
+	}
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 7).TableId, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "10", "tip": "2"}, cashier), http.StatusCreated, nil)
+
+	path := "/invoice/" + invoice.InvoiceId + "/receipt"
+	response := s.do(http.MethodGet, path, nil, cashier)
+	s.expect(response, http.StatusOK, nil)
+	page := response.Body.String()
+	if !strings.HasPrefix(response.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("expected an HTML receipt, got %s", response.Header().Get("Content-Type"))
+	}
+	for _, expected := range []string{"Test Bistro", invoice.InvoiceNumber, "Table 7", "1 x Steak", "Tax 10%", "22.00", "Paid CASH", "<td>Tip</td><td class=\"amount\">2.00", "12.00", "__________", "Fish &amp; Chips"} {
+		if !strings.Contains(page, expected) {
+			t.Fatalf("expected %q on the receipt, got %s", expected, page)
+		}
+	}
+
+	for _, requestHeaders := range []headers{{"token": cashier["token"], "Accept": "application/pdf"}, cashier} {
+		target := path
+		if requestHeaders["Accept"] == "" {
+			target += "?format=pdf"
+		}
+		response = s.do(http.MethodGet, target, nil, requestHeaders)
+		s.expect(response, http.StatusOK, nil)
+		document := response.Body.String()
+		if response.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(document, "%PDF-") || !strings.HasSuffix(document, "%%EOF\n") {
+			t.Fatalf("expected a PDF receipt, got %s", response.Header().Get("Content-Type"))
+		}
+		for _, expected := range []string{invoice.InvoiceNumber, "(1 x Steak", "(Balance                            12.00)"} {
+			if !strings.Contains(document, expected) {
+				t.Fatalf("expected %q in the PDF, got %s", expected, document)
+			}
+		}
+	}
+	s.expect(s.do(http.MethodGet, path+"?format=xml", nil, cashier), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodGet, "/invoice/unknown/receipt", nil, cashier), http.StatusNotFound, nil)
+
+	owner := s.headersFor("", models.RoleOwner)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"receipt": map[string]string{"html_template": "{{.Unknown}}"}}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"receipt": map[string]string{"text_template": "{{row .InvoiceNumber"}}, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]interface{}{"receipt": map[string]string{"html_template": "<p>{{.InvoiceNumber}} at {{.RestaurantName}}</p>", "footer": "See you"}}, owner), http.StatusOK, nil)
+
+	response = s.do(http.MethodGet, path, nil, cashier)
+	s.expect(response, http.StatusOK, nil)
+	if page := response.Body.String(); page != "<p>"+invoice.InvoiceNumber+" at Test Bistro</p>" {
+		t.Fatalf("expected the receipt in the layout of the restaurant, got %s", page)
+	}
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+)
+
+type RevokedTokenRepository interface {
+	// Add records the revocation; revoking the same token twice is not an error.
+	Add(ctx context.Context, token models.RevokedToken) error
+	Exists(ctx context.Context, tokenId string) (bool, error)
+}
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset models.PasswordReset) error
+	// CancelOpen marks every unused reset of the user as used.
+	CancelOpen(ctx context.Context, userId string, at time.Time) error
+	// Consume marks the unused, unexpired reset with tokenHash as used and
+	// returns it, or ErrNotFound.
+	Consume(ctx context.Context, tokenHash string, at time.Time) (models.PasswordReset, error)
+}
+
+type LoginAttemptRepository interface {
+	Get(ctx context.Context, key string) (models.LoginAttempt, error)
+	GetMany(ctx context.Context, keys []string) ([]models.LoginAttempt, error)
+	// RecordFailure counts one more failure against key at at, coming from
+	// source unless it is empty, starting the count over when the last
+	// failure came before since, and returns the attempt as it stands
+	// afterwards.
+	RecordFailure(ctx context.Context, key string, source string, at time.Time, since time.Time) (models.LoginAttempt, error)
+	// Lock locks key until until, unless it is already locked for longer.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, keys []string) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type CreditNoteRepository interface {
+	// List lists the credit notes of the restaurant in the order they were
+	// issued.
+	List(ctx context.Context, restaurantId string) ([]models.CreditNote, error)
+	ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.CreditNote, error)
+	Get(ctx context.Context, restaurantId string, creditNoteId string) (models.CreditNote, error)
+	// Issue stores creditNote under the next credit note number of its
+	// restaurant and fiscal year, as InvoiceRepository.Issue does invoices.
+	Issue(ctx context.Context, creditNote models.CreditNote, prefix string) (models.CreditNote, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type DeviceRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Device, error)
+	// Get is not scoped to a restaurant because it is used to authenticate
+	// the device in the first place.
+	Get(ctx context.Context, deviceId string) (models.Device, error)
+	Create(ctx context.Context, device models.Device) error
+	Update(ctx context.Context, device models.Device) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type FoodRepository interface {
+	List(ctx context.Context, restaurantId string, page Page) (foods []models.Food, total int, err error)
+	Get(ctx context.Context, restaurantId string, foodId string) (models.Food, error)
+	GetMany(ctx context.Context, restaurantId string, foodIds []string) ([]models.Food, error)
+	Create(ctx context.Context, food models.Food) error
+	Update(ctx context.Context, food models.Food) error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"restaurant-management-system/models"
+	"time"
+)
+
+// ErrOrderInvoiced is returned by Issue when the order already has an invoice
+// that has not been voided.
+var ErrOrderInvoiced = errors.New("order has already been invoiced")
+
+type InvoiceRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Invoice, error)
+	// ListPaidIn lists the invoices with a payment taken during period.
+	ListPaidIn(ctx context.Context, restaurantId string, period Period) ([]models.Invoice, error)
+	Get(ctx context.Context, restaurantId string, invoiceId string) (models.Invoice, error)
+	// GetByOrder finds the invoice of the order that has not been voided, or
+	// returns ErrNotFound when there is none.
+	GetByOrder(ctx context.Context, restaurantId string, orderId string) (models.Invoice, error)
+	Create(ctx context.Context, invoice models.Invoice) error
+	// Issue stores invoice under the next number of its restaurant and
+	// fiscal year, starting with prefix, and returns it numbered. Numbers
+	// follow each other without gaps, however many invoices are issued at
+	// once. An order has at most one invoice that has not been voided.
+	Issue(ctx context.Context, invoice models.Invoice, prefix string) (models.Invoice, error)
+	// SetPaymentMethod changes how the invoice is to be paid.
+	SetPaymentMethod(ctx context.Context, restaurantId string, invoiceId string, method string, at time.Time) error
+	// SetTotals stores the totals of an invoice created before totals were
+	// stored, only while it still has none, and reports whether it did.
+	SetTotals(ctx context.Context, restaurantId string, invoiceId string, totals models.OrderTotals) (bool, error)
+	// Void moves the invoice to VOIDED, only while it is not voided yet and
+	// still has exactly paid payments and is still credited, and reports
+	// whether it did.
+	Void(ctx context.Context, restaurantId string, invoiceId string, paid int, credited models.Money, at time.Time) (bool, error)
+	// AddPayment records payment and moves the invoice to status, only while
+	// the invoice still has exactly paid payments, and reports whether it did.
+	AddPayment(ctx context.Context, restaurantId string, invoiceId string, paid int, payment models.Payment, status string) (bool, error)
+	// AddCardTransaction records a card payment attempt on the invoice.
+	AddCardTransaction(ctx context.Context, restaurantId string, invoiceId string, transaction models.CardTransaction) error
+	// AddCredit adds amount to what the invoice has been credited, only while
+	// it is still credited, and reports whether it did.
+	AddCredit(ctx context.Context, restaurantId string, invoiceId string, credited models.Money, amount models.Money) (bool, error)
+	// ReserveRefund adds amount to what has been refunded of a payment on the
+	// invoice, only while it is still refunded, and reports whether it did.
+	ReserveRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, refunded models.Money, amount models.Money) (bool, error)
+	// ReleaseRefund takes amount off what has been refunded of a payment on
+	// the invoice, for a refund that was not given after all.
+	ReleaseRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, amount models.Money) error
+	// SetPaymentTip changes the tip of a payment on the invoice.
+	SetPaymentTip(ctx context.Context, restaurantId string, invoiceId string, paymentId string, tip models.Money) error
+}
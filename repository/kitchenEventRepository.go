@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type KitchenEventRepository interface {
+	// Append numbers the event with the next sequence of its restaurant and
+	// stores it.
+	Append(ctx context.Context, event models.KitchenEvent) (models.KitchenEvent, error)
+	// ListAfter returns up to limit events of the restaurant with a sequence
+	// above after, in sequence order.
+	ListAfter(ctx context.Context, restaurantId string, after int64, limit int) ([]models.KitchenEvent, error)
+	// LastSequence is the sequence of the newest event of the restaurant, or 0.
+	LastSequence(ctx context.Context, restaurantId string) (int64, error)
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type revokedTokenRepository struct{ db *database }
+
+func (r revokedTokenRepository) Add(ctx context.Context, token models.RevokedToken) error {
+	r.db.revokedTokens.upsert(func(stored models.RevokedToken) bool { return stored.TokenId == token.TokenId }, token)
+	return nil
+}
+
+func (r revokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	_, err := r.db.revokedTokens.find(func(stored models.RevokedToken) bool { return stored.TokenId == tokenId })
+	return err == nil, nil
+}
+
+type passwordResetRepository struct{ db *database }
+
+func (r passwordResetRepository) Create(ctx context.Context, reset models.PasswordReset) error {
+	r.db.passwordResets.insert(reset)
+	return nil
+}
+
+func (r passwordResetRepository) CancelOpen(ctx context.Context, userId string, at time.Time) error {
+	r.db.passwordResets.update(func(reset models.PasswordReset) bool {
+		return reset.UserId == userId && reset.UsedAt == nil
+	}, func(reset *models.PasswordReset) {
+		reset.UsedAt = &at
+	})
+
+	return nil
+}
+
+func (r passwordResetRepository) Consume(ctx context.Context, tokenHash string, at time.Time) (models.PasswordReset, error) {
+	var consumed models.PasswordReset
+	matched := r.db.passwordResets.update(func(reset models.PasswordReset) bool {
+		return reset.TokenHash == tokenHash && reset.UsedAt == nil && reset.ExpiresAt.After(at)
+	}, func(reset *models.PasswordReset) {
+		reset.UsedAt = &at
+		consumed = *reset
+	})
+	if matched == 0 {
+		return models.PasswordReset{}, repository.ErrNotFound
+	}
+
+	return consumed, nil
+}
+
+type loginAttemptRepository struct{ db *database }
+
+func (r loginAttemptRepository) Get(ctx context.Context, key string) (models.LoginAttempt, error) {
+	return r.db.loginAttempts.find(func(attempt models.LoginAttempt) bool { return attempt.Key == key })
+}
+
+func (r loginAttemptRepository) GetMany(ctx context.Context, keys []string) ([]models.LoginAttempt, error) {
+	return r.db.loginAttempts.all(func(attempt models.LoginAttempt) bool { return slices.Contains(keys, attempt.Key) }), nil
+}
+
+func (r loginAttemptRepository) RecordFailure(ctx context.Context, key string, source string, at time.Time, since time.Time) (models.LoginAttempt, error) {
+	return r.db.loginAttempts.upsertWith(func(attempt models.LoginAttempt) bool { return attempt.Key == key }, models.LoginAttempt{ID: primitive.NewObjectID(), Key: key}, func(attempt *models.LoginAttempt) {
+		if attempt.LastFailureAt.Before(since) {
+			attempt.Failures = 0
+			attempt.Sources = nil
+		}
+		attempt.Failures++
+		attempt.LastFailureAt = at
+		if source != "" && !slices.Contains(attempt.Sources, source) {
+			attempt.Sources = append(slices.Clone(attempt.Sources), source)
+		}
+	}), nil
+}
+
+func (r loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.db.loginAttempts.update(func(attempt models.LoginAttempt) bool {
+		return attempt.Key == key && (attempt.LockedUntil == nil || attempt.LockedUntil.Before(until))
+	}, func(attempt *models.LoginAttempt) {
+		attempt.LockedUntil = &until
+	})
+	return nil
+}
+
+func (r loginAttemptRepository) Delete(ctx context.Context, keys []string) error {
+	r.db.loginAttempts.delete(func(attempt models.LoginAttempt) bool { return slices.Contains(keys, attempt.Key) })
+	return nil
+}
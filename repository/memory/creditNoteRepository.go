@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type creditNoteRepository struct{ db *database }
+
+func (r creditNoteRepository) List(ctx context.Context, restaurantId string) ([]models.CreditNote, error) {
+	return r.db.creditNotes.all(func(creditNote models.CreditNote) bool { return creditNote.RestaurantId == restaurantId }), nil
+}
+
+func (r creditNoteRepository) ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.CreditNote, error) {
+	return r.db.creditNotes.all(func(creditNote models.CreditNote) bool {
+		return creditNote.RestaurantId == restaurantId && creditNote.InvoiceId == invoiceId
+	}), nil
+}
+
+func (r creditNoteRepository) Get(ctx context.Context, restaurantId string, creditNoteId string) (models.CreditNote, error) {
+	return r.db.creditNotes.find(func(creditNote models.CreditNote) bool {
+		return creditNote.RestaurantId == restaurantId && creditNote.CreditNoteId == creditNoteId
+	})
+}
+
+func (r creditNoteRepository) Issue(ctx context.Context, creditNote models.CreditNote, prefix string) (models.CreditNote, error) {
+	return r.db.creditNotes.insertNext(func(stored []models.CreditNote) (models.CreditNote, error) {
+		creditNote.Sequence = 1
+		for _, issued := range stored {
+			if issued.RestaurantId == creditNote.RestaurantId && issued.FiscalYear == creditNote.FiscalYear && issued.Sequence >= creditNote.Sequence {
+				creditNote.Sequence = issued.Sequence + 1
+			}
+		}
+		creditNote.CreditNoteNumber = models.DocumentNumber(prefix, creditNote.FiscalYear, creditNote.Sequence)
+		return creditNote, nil
+	})
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type deviceRepository struct{ db *database }
+
+func (r deviceRepository) List(ctx context.Context, restaurantId string) ([]models.Device, error) {
+	return r.db.devices.all(func(device models.Device) bool { return device.RestaurantId == restaurantId }), nil
+}
+
+func (r deviceRepository) Get(ctx context.Context, deviceId string) (models.Device, error) {
+	return r.db.devices.find(func(device models.Device) bool { return device.DeviceId == deviceId })
+}
+
+func (r deviceRepository) Create(ctx context.Context, device models.Device) error {
+	r.db.devices.insert(device)
+	return nil
+}
+
+func (r deviceRepository) Update(ctx context.Context, device models.Device) error {
+	return r.db.devices.replace(func(stored models.Device) bool { return stored.DeviceId == device.DeviceId }, device)
+}
@@ -0,0 +1,38 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+)
+
+type foodRepository struct{ db *database }
+
+func (r foodRepository) List(ctx context.Context, restaurantId string, page repository.Page) ([]models.Food, int, error) {
+	foods := r.db.foods.all(func(food models.Food) bool { return food.RestaurantId == restaurantId })
+	return paginate(foods, page), len(foods), nil
+}
+
+func (r foodRepository) Get(ctx context.Context, restaurantId string, foodId string) (models.Food, error) {
+	return r.db.foods.find(func(food models.Food) bool {
+		return food.RestaurantId == restaurantId && food.FoodId == foodId
+	})
+}
+
+func (r foodRepository) GetMany(ctx context.Context, restaurantId string, foodIds []string) ([]models.Food, error) {
+	return r.db.foods.all(func(food models.Food) bool {
+		return food.RestaurantId == restaurantId && slices.Contains(foodIds, food.FoodId)
+	}), nil
+}
+
+func (r foodRepository) Create(ctx context.Context, food models.Food) error {
+	r.db.foods.insert(food)
+	return nil
+}
+
+func (r foodRepository) Update(ctx context.Context, food models.Food) error {
+	return r.db.foods.replace(func(stored models.Food) bool {
+		return stored.RestaurantId == food.RestaurantId && stored.FoodId == food.FoodId
+	}, food)
+}
@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"time"
+)
+
+type invoiceRepository struct{ db *database }
+
+func (r invoiceRepository) List(ctx context.Context, restaurantId string) ([]models.Invoice, error) {
+	return r.db.invoices.all(func(invoice models.Invoice) bool { return invoice.RestaurantId == restaurantId }), nil
+}
+
+func (r invoiceRepository) ListPaidIn(ctx context.Context, restaurantId string, period repository.Period) ([]models.Invoice, error) {
+	return r.db.invoices.all(func(invoice models.Invoice) bool {
+		if invoice.RestaurantId != restaurantId {
+			return false
+		}
+		for _, payment := range invoice.Payments {
+			if period.Contains(payment.CreatedAt) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r invoiceRepository) Get(ctx context.Context, restaurantId string, invoiceId string) (models.Invoice, error) {
+	return r.db.invoices.find(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	})
+}
+
+func (r invoiceRepository) GetByOrder(ctx context.Context, restaurantId string, orderId string) (models.Invoice, error) {
+	return r.db.invoices.find(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.OrderId == orderId && (invoice.PaymentStatus == nil || *invoice.PaymentStatus != models.InvoiceVoided)
+	})
+}
+
+func (r invoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	r.db.invoices.insert(invoice)
+	return nil
+}
+
+func (r invoiceRepository) Issue(ctx context.Context, invoice models.Invoice, prefix string) (models.Invoice, error) {
+	return r.db.invoices.insertNext(func(stored []models.Invoice) (models.Invoice, error) {
+		invoice.Sequence = 1
+		for _, issued := range stored {
+			if issued.RestaurantId == invoice.RestaurantId && issued.OrderId == invoice.OrderId && (issued.PaymentStatus == nil || *issued.PaymentStatus != models.InvoiceVoided) {
+				return invoice, repository.ErrOrderInvoiced
+			}
+			if issued.RestaurantId == invoice.RestaurantId && issued.FiscalYear == invoice.FiscalYear && issued.Sequence >= invoice.Sequence {
+				invoice.Sequence = issued.Sequence + 1
+			}
+		}
+		invoice.InvoiceNumber = models.DocumentNumber(prefix, invoice.FiscalYear, invoice.Sequence)
+		return invoice, nil
+	})
+}
+
+func (r invoiceRepository) SetPaymentMethod(ctx context.Context, restaurantId string, invoiceId string, method string, at time.Time) error {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	}, func(invoice *models.Invoice) {
+		invoice.PaymentMethod = &method
+		invoice.UpdatedAt = at
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) SetTotals(ctx context.Context, restaurantId string, invoiceId string, totals models.OrderTotals) (bool, error) {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId && invoice.Totals == nil
+	}, func(invoice *models.Invoice) {
+		invoice.Totals = &totals
+	})
+
+	return matched > 0, nil
+}
+
+func (r invoiceRepository) Void(ctx context.Context, restaurantId string, invoiceId string, paid int, credited models.Money, at time.Time) (bool, error) {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId &&
+			(invoice.PaymentStatus == nil || *invoice.PaymentStatus != models.InvoiceVoided) &&
+			len(invoice.Payments) == paid && invoice.Credited == credited
+	}, func(invoice *models.Invoice) {
+		status := models.InvoiceVoided
+		invoice.PaymentStatus = &status
+		invoice.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
+
+func (r invoiceRepository) AddPayment(ctx context.Context, restaurantId string, invoiceId string, paid int, payment models.Payment, status string) (bool, error) {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId && len(invoice.Payments) == paid
+	}, func(invoice *models.Invoice) {
+		invoice.Payments = append(invoice.Payments, payment)
+		invoice.PaymentStatus = &status
+		invoice.UpdatedAt = payment.CreatedAt
+	})
+
+	return matched > 0, nil
+}
+
+func (r invoiceRepository) AddCardTransaction(ctx context.Context, restaurantId string, invoiceId string, transaction models.CardTransaction) error {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	}, func(invoice *models.Invoice) {
+		invoice.CardTransactions = append(invoice.CardTransactions, transaction)
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) AddCredit(ctx context.Context, restaurantId string, invoiceId string, credited models.Money, amount models.Money) (bool, error) {
+	matched := r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId && invoice.Credited == credited
+	}, func(invoice *models.Invoice) {
+		invoice.Credited += amount
+	})
+
+	return matched > 0, nil
+}
+
+func (r invoiceRepository) ReserveRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, refunded models.Money, amount models.Money) (bool, error) {
+	reserved := false
+	r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	}, func(invoice *models.Invoice) {
+		payments := slices.Clone(invoice.Payments)
+		for i := range payments {
+			if payments[i].PaymentId == paymentId && payments[i].Refunded == refunded {
+				payments[i].Refunded += amount
+				reserved = true
+			}
+		}
+		invoice.Payments = payments
+	})
+
+	return reserved, nil
+}
+
+func (r invoiceRepository) ReleaseRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, amount models.Money) error {
+	found := false
+	r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	}, func(invoice *models.Invoice) {
+		payments := slices.Clone(invoice.Payments)
+		for i := range payments {
+			if payments[i].PaymentId == paymentId {
+				payments[i].Refunded -= amount
+				found = true
+			}
+		}
+		invoice.Payments = payments
+	})
+	if !found {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) SetPaymentTip(ctx context.Context, restaurantId string, invoiceId string, paymentId string, tip models.Money) error {
+	found := false
+	r.db.invoices.update(func(invoice models.Invoice) bool {
+		return invoice.RestaurantId == restaurantId && invoice.InvoiceId == invoiceId
+	}, func(invoice *models.Invoice) {
+		payments := slices.Clone(invoice.Payments)
+		for i := range payments {
+			if payments[i].PaymentId == paymentId {
+				payments[i].Tip = tip
+				found = true
+			}
+		}
+		invoice.Payments = payments
+	})
+	if !found {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"sync"
+)
+
+type kitchenEventRepository struct {
+	db *database
+
+	// mu makes numbering and storing an event one step, so readers never see
+	// a later sequence before an earlier one
+	mu        sync.Mutex
+	sequences map[string]int64
+}
+
+func (r *kitchenEventRepository) Append(ctx context.Context, event models.KitchenEvent) (models.KitchenEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sequences == nil {
+		r.sequences = map[string]int64{}
+	}
+	r.sequences[event.RestaurantId]++
+	event.Sequence = r.sequences[event.RestaurantId]
+	r.db.kitchenEvents.insert(event)
+
+	return event, nil
+}
+
+func (r *kitchenEventRepository) ListAfter(ctx context.Context, restaurantId string, after int64, limit int) ([]models.KitchenEvent, error) {
+	events := r.db.kitchenEvents.all(func(event models.KitchenEvent) bool {
+		return event.RestaurantId == restaurantId && event.Sequence > after
+	})
+
+	return paginate(events, repository.Page{Limit: limit}), nil
+}
+
+func (r *kitchenEventRepository) LastSequence(ctx context.Context, restaurantId string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sequences[restaurantId], nil
+}
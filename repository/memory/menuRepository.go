@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type menuRepository struct{ db *database }
+
+func (r menuRepository) List(ctx context.Context, restaurantId string) ([]models.Menu, error) {
+	return r.db.menus.all(func(menu models.Menu) bool { return menu.RestaurantId == restaurantId }), nil
+}
+
+func (r menuRepository) Get(ctx context.Context, restaurantId string, menuId string) (models.Menu, error) {
+	return r.db.menus.find(func(menu models.Menu) bool {
+		return menu.RestaurantId == restaurantId && menu.MenuId == menuId
+	})
+}
+
+func (r menuRepository) Create(ctx context.Context, menu models.Menu) error {
+	r.db.menus.insert(menu)
+	return nil
+}
+
+func (r menuRepository) Update(ctx context.Context, menu models.Menu) error {
+	return r.db.menus.replace(func(stored models.Menu) bool {
+		return stored.RestaurantId == menu.RestaurantId && stored.MenuId == menu.MenuId
+	}, menu)
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"sort"
+	"time"
+)
+
+type orderItemRepository struct{ db *database }
+
+func (r orderItemRepository) List(ctx context.Context, restaurantId string) ([]models.OrderItem, error) {
+	return r.db.orderItems.all(func(orderItem models.OrderItem) bool { return orderItem.RestaurantId == restaurantId }), nil
+}
+
+func (r orderItemRepository) ListByOrder(ctx context.Context, restaurantId string, orderId string) ([]models.OrderItem, error) {
+	return r.db.orderItems.all(func(orderItem models.OrderItem) bool {
+		return orderItem.RestaurantId == restaurantId && orderItem.OrderId == orderId
+	}), nil
+}
+
+func (r orderItemRepository) Get(ctx context.Context, restaurantId string, orderItemId string) (models.OrderItem, error) {
+	return r.db.orderItems.find(func(orderItem models.OrderItem) bool {
+		return orderItem.RestaurantId == restaurantId && orderItem.OrderItemId == orderItemId
+	})
+}
+
+func (r orderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	r.db.orderItems.insert(orderItems...)
+	return nil
+}
+
+func (r orderItemRepository) Update(ctx context.Context, orderItem models.OrderItem) error {
+	matched := r.db.orderItems.update(func(stored models.OrderItem) bool {
+		return stored.RestaurantId == orderItem.RestaurantId && stored.OrderItemId == orderItem.OrderItemId
+	}, func(stored *models.OrderItem) {
+		orderItem.Status, orderItem.StatusUpdatedAt = stored.Status, stored.StatusUpdatedAt
+		*stored = orderItem
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r orderItemRepository) ListByStation(ctx context.Context, restaurantId string, station string, statuses []string) ([]models.OrderItem, error) {
+	orderItems := r.db.orderItems.all(func(orderItem models.OrderItem) bool {
+		return orderItem.RestaurantId == restaurantId && orderItem.Station == station && slices.Contains(statuses, orderItem.CurrentStatus())
+	})
+	sort.SliceStable(orderItems, func(i, j int) bool { return orderItems[i].CreatedAt.Before(orderItems[j].CreatedAt) })
+
+	return orderItems, nil
+}
+
+func (r orderItemRepository) SetStatus(ctx context.Context, restaurantId string, orderItemId string, from string, status string, at time.Time) (bool, error) {
+	matched := r.db.orderItems.update(func(orderItem models.OrderItem) bool {
+		return orderItem.RestaurantId == restaurantId && orderItem.OrderItemId == orderItemId && orderItem.CurrentStatus() == from
+	}, func(orderItem *models.OrderItem) {
+		orderItem.Status = status
+		orderItem.StatusUpdatedAt = at
+		orderItem.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"slices"
+	"time"
+)
+
+type orderRepository struct{ db *database }
+
+func (r orderRepository) List(ctx context.Context, restaurantId string) ([]models.Order, error) {
+	return r.db.orders.all(func(order models.Order) bool { return order.RestaurantId == restaurantId }), nil
+}
+
+func (r orderRepository) Get(ctx context.Context, restaurantId string, orderId string) (models.Order, error) {
+	return r.db.orders.find(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId
+	})
+}
+
+func (r orderRepository) Create(ctx context.Context, order models.Order) error {
+	r.db.orders.insert(order)
+	return nil
+}
+
+func (r orderRepository) Reassign(ctx context.Context, restaurantId string, orderId string, from string, tableId *string, serverId string, at time.Time) (bool, error) {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId && order.CurrentStatus() == from
+	}, func(order *models.Order) {
+		order.TableId = tableId
+		order.ServerId = serverId
+		order.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
+
+func (r orderRepository) SetDiscount(ctx context.Context, restaurantId string, orderId string, from string, discount *models.Discount, at time.Time) (bool, error) {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId && order.CurrentStatus() == from
+	}, func(order *models.Order) {
+		order.Discount = discount
+		order.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
+
+func (r orderRepository) SetAppliedDiscounts(ctx context.Context, restaurantId string, orderId string, discounts []models.AppliedDiscount) error {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId
+	}, func(order *models.Order) {
+		order.AppliedDiscounts = discounts
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r orderRepository) Transition(ctx context.Context, restaurantId string, orderId string, from string, change models.OrderStatusChange) (bool, error) {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId && order.CurrentStatus() == from
+	}, func(order *models.Order) {
+		order.Status = change.Status
+		order.StatusHistory = append(order.StatusHistory, change)
+		order.UpdatedAt = change.ChangedAt
+	})
+
+	return matched > 0, nil
+}
+
+func (r orderRepository) AddCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error) {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId && !slices.Contains(order.Coupons, code)
+	}, func(order *models.Order) {
+		order.Coupons = append(slices.Clone(order.Coupons), code)
+		order.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
+
+func (r orderRepository) RemoveCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error) {
+	matched := r.db.orders.update(func(order models.Order) bool {
+		return order.RestaurantId == restaurantId && order.OrderId == orderId && slices.Contains(order.Coupons, code)
+	}, func(order *models.Order) {
+		order.Coupons = slices.DeleteFunc(slices.Clone(order.Coupons), func(coupon string) bool { return coupon == code })
+		order.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
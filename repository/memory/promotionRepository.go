@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+)
+
+type promotionRepository struct{ db *database }
+
+func (r promotionRepository) List(ctx context.Context, restaurantId string) ([]models.Promotion, error) {
+	return r.db.promotions.all(func(promotion models.Promotion) bool { return promotion.RestaurantId == restaurantId }), nil
+}
+
+func (r promotionRepository) Get(ctx context.Context, restaurantId string, promotionId string) (models.Promotion, error) {
+	return r.db.promotions.find(func(promotion models.Promotion) bool {
+		return promotion.RestaurantId == restaurantId && promotion.PromotionId == promotionId
+	})
+}
+
+func (r promotionRepository) GetByCode(ctx context.Context, restaurantId string, code string) (models.Promotion, error) {
+	return r.db.promotions.find(func(promotion models.Promotion) bool {
+		return promotion.RestaurantId == restaurantId && promotion.Code != nil && *promotion.Code == code
+	})
+}
+
+func (r promotionRepository) Create(ctx context.Context, promotion models.Promotion) error {
+	r.db.promotions.insert(promotion)
+	return nil
+}
+
+func (r promotionRepository) Update(ctx context.Context, promotion models.Promotion) error {
+	matched := r.db.promotions.update(r.matching(promotion.RestaurantId, promotion.PromotionId), func(stored *models.Promotion) {
+		promotion.Uses = stored.Uses
+		*stored = promotion
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r promotionRepository) Redeem(ctx context.Context, restaurantId string, promotionId string) (bool, error) {
+	matched := r.db.promotions.update(func(promotion models.Promotion) bool {
+		return r.matching(restaurantId, promotionId)(promotion) && !promotion.UsedUp()
+	}, func(promotion *models.Promotion) {
+		promotion.Uses++
+	})
+
+	return matched > 0, nil
+}
+
+func (r promotionRepository) Release(ctx context.Context, restaurantId string, promotionId string) error {
+	r.db.promotions.update(func(promotion models.Promotion) bool {
+		return r.matching(restaurantId, promotionId)(promotion) && promotion.Uses > 0
+	}, func(promotion *models.Promotion) {
+		promotion.Uses--
+	})
+
+	return nil
+}
+
+func (r promotionRepository) matching(restaurantId string, promotionId string) func(models.Promotion) bool {
+	return func(promotion models.Promotion) bool {
+		return promotion.RestaurantId == restaurantId && promotion.PromotionId == promotionId
+	}
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+)
+
+type reportRepository struct{ db *database }
+
+func (r reportRepository) LocationTotals(ctx context.Context, period repository.Period) ([]repository.LocationTotals, error) {
+	totals := map[string]*repository.LocationTotals{}
+	totalsFor := func(restaurantId string) *repository.LocationTotals {
+		if totals[restaurantId] == nil {
+			totals[restaurantId] = &repository.LocationTotals{RestaurantId: restaurantId}
+		}
+		return totals[restaurantId]
+	}
+
+	for _, order := range r.db.orders.all(func(order models.Order) bool { return period.Contains(order.CreatedAt) }) {
+		totalsFor(order.RestaurantId).OrderCount++
+	}
+
+	prices := map[string]models.Money{}
+	for _, food := range r.db.foods.all(func(models.Food) bool { return true }) {
+		if food.Price != nil {
+			prices[food.FoodId] = *food.Price
+		}
+	}
+	for _, orderItem := range r.db.orderItems.all(func(orderItem models.OrderItem) bool { return period.Contains(orderItem.CreatedAt) }) {
+		location := totalsFor(orderItem.RestaurantId)
+		if orderItem.UnitPrice == nil && orderItem.FoodId != nil {
+			price := prices[*orderItem.FoodId]
+			orderItem.UnitPrice = &price
+		}
+		if orderItem.CurrentStatus() == models.OrderItemVoided {
+			location.VoidedItems++
+			location.ItemVoids += orderItem.LineTotal()
+			continue
+		}
+		location.ItemCount++
+		location.Sales += orderItem.LineTotal()
+	}
+
+	for _, invoice := range r.db.invoices.all(func(invoice models.Invoice) bool { return period.Contains(invoice.CreatedAt) }) {
+		location := totalsFor(invoice.RestaurantId)
+		switch {
+		case invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoicePaid:
+			location.PaidInvoices++
+		case invoice.PaymentStatus != nil && *invoice.PaymentStatus == models.InvoiceVoided:
+			location.VoidedInvoices++
+			location.InvoiceVoids += invoice.AmountDue()
+		default:
+			location.PendingInvoices++
+		}
+	}
+
+	for _, reversal := range r.db.reversals.all(func(reversal models.Reversal) bool { return period.Contains(reversal.CreatedAt) }) {
+		if reversal.Type == models.ReversalRefund && reversal.Status == models.ReversalCompleted {
+			totalsFor(reversal.RestaurantId).Refunds += reversal.Amount
+		}
+	}
+
+	result := []repository.LocationTotals{}
+	for _, location := range totals {
+		result = append(result, *location)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type restaurantRepository struct{ db *database }
+
+func (r restaurantRepository) List(ctx context.Context) ([]models.Restaurant, error) {
+	return r.db.restaurants.all(func(models.Restaurant) bool { return true }), nil
+}
+
+func (r restaurantRepository) Get(ctx context.Context, restaurantId string) (models.Restaurant, error) {
+	return r.db.restaurants.find(func(restaurant models.Restaurant) bool { return restaurant.RestaurantId == restaurantId })
+}
+
+func (r restaurantRepository) Create(ctx context.Context, restaurant models.Restaurant) error {
+	r.db.restaurants.insert(restaurant)
+	return nil
+}
+
+func (r restaurantRepository) Update(ctx context.Context, restaurant models.Restaurant) error {
+	return r.db.restaurants.replace(func(stored models.Restaurant) bool {
+		return stored.RestaurantId == restaurant.RestaurantId
+	}, restaurant)
+}
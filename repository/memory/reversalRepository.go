@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+)
+
+type reversalRepository struct{ db *database }
+
+func (r reversalRepository) List(ctx context.Context, restaurantId string, status string) ([]models.Reversal, error) {
+	return r.db.reversals.all(func(reversal models.Reversal) bool {
+		return reversal.RestaurantId == restaurantId && (status == "" || reversal.Status == status)
+	}), nil
+}
+
+func (r reversalRepository) ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.Reversal, error) {
+	return r.db.reversals.all(func(reversal models.Reversal) bool {
+		return reversal.RestaurantId == restaurantId && reversal.InvoiceId == invoiceId
+	}), nil
+}
+
+func (r reversalRepository) Get(ctx context.Context, restaurantId string, reversalId string) (models.Reversal, error) {
+	return r.db.reversals.find(func(reversal models.Reversal) bool {
+		return reversal.RestaurantId == restaurantId && reversal.ReversalId == reversalId
+	})
+}
+
+func (r reversalRepository) Create(ctx context.Context, reversal models.Reversal) error {
+	r.db.reversals.insert(reversal)
+	return nil
+}
+
+func (r reversalRepository) Update(ctx context.Context, reversal models.Reversal) error {
+	return r.db.reversals.replace(func(stored models.Reversal) bool {
+		return stored.RestaurantId == reversal.RestaurantId && stored.ReversalId == reversal.ReversalId
+	}, reversal)
+}
+
+func (r reversalRepository) Decide(ctx context.Context, restaurantId string, reversalId string, status string, decidedBy string, at time.Time) (bool, error) {
+	matched := r.db.reversals.update(func(reversal models.Reversal) bool {
+		return reversal.RestaurantId == restaurantId && reversal.ReversalId == reversalId && reversal.Status == models.ReversalPending
+	}, func(reversal *models.Reversal) {
+		reversal.Status = status
+		reversal.DecidedBy = decidedBy
+		reversal.DecidedAt = at
+		reversal.UpdatedAt = at
+	})
+
+	return matched > 0, nil
+}
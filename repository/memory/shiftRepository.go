@@ -0,0 +1,33 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+)
+
+type shiftRepository struct{ db *database }
+
+func (r shiftRepository) List(ctx context.Context, restaurantId string) ([]models.Shift, error) {
+	return r.db.shifts.all(func(shift models.Shift) bool { return shift.RestaurantId == restaurantId }), nil
+}
+
+func (r shiftRepository) Get(ctx context.Context, restaurantId string, shiftId string) (models.Shift, error) {
+	return r.db.shifts.find(func(shift models.Shift) bool {
+		return shift.RestaurantId == restaurantId && shift.ShiftId == shiftId
+	})
+}
+
+func (r shiftRepository) Last(ctx context.Context, restaurantId string) (models.Shift, error) {
+	shifts, _ := r.List(ctx, restaurantId)
+	if len(shifts) == 0 {
+		return models.Shift{}, repository.ErrNotFound
+	}
+	return shifts[len(shifts)-1], nil
+}
+
+func (r shiftRepository) Close(ctx context.Context, shift models.Shift) (bool, error) {
+	return r.db.shifts.insertUnless(func(stored models.Shift) bool {
+		return stored.RestaurantId == shift.RestaurantId && stored.StartedAt.Equal(shift.StartedAt)
+	}, shift), nil
+}
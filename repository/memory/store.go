@@ -0,0 +1,55 @@
+// Package memory implements the repositories in process memory. It backs the
+// HTTP tests and local experiments; nothing is persisted.
+package memory
+
+import (
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+)
+
+type database struct {
+	foods          table[models.Food]
+	menus          table[models.Menu]
+	tables         table[models.Table]
+	orders         table[models.Order]
+	orderItems     table[models.OrderItem]
+	invoices       table[models.Invoice]
+	users          table[models.User]
+	restaurants    table[models.Restaurant]
+	terminals      table[models.Terminal]
+	devices        table[models.Device]
+	revokedTokens  table[models.RevokedToken]
+	passwordResets table[models.PasswordReset]
+	loginAttempts  table[models.LoginAttempt]
+	kitchenEvents  table[models.KitchenEvent]
+	promotions     table[models.Promotion]
+	reversals      table[models.Reversal]
+	shifts         table[models.Shift]
+	creditNotes    table[models.CreditNote]
+}
+
+func NewStore() *repository.Store {
+	db := &database{}
+
+	return &repository.Store{
+		Foods:          foodRepository{db},
+		Menus:          menuRepository{db},
+		Tables:         tableRepository{db},
+		Orders:         orderRepository{db},
+		OrderItems:     orderItemRepository{db},
+		Invoices:       invoiceRepository{db},
+		Users:          userRepository{db},
+		Restaurants:    restaurantRepository{db},
+		Terminals:      terminalRepository{db},
+		Devices:        deviceRepository{db},
+		RevokedTokens:  revokedTokenRepository{db},
+		PasswordResets: passwordResetRepository{db},
+		LoginAttempts:  loginAttemptRepository{db},
+		Reports:        reportRepository{db},
+		KitchenEvents:  &kitchenEventRepository{db: db},
+		Promotions:     promotionRepository{db},
+		Reversals:      reversalRepository{db},
+		Shifts:         shiftRepository{db},
+		CreditNotes:    creditNoteRepository{db},
+	}
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"sync"
+
+	"restaurant-management-system/repository"
+)
+
+// table keeps rows in insertion order, which is also the order listings
+// return them in.
+type table[T any] struct {
+	mu   sync.RWMutex
+	rows []T
+}
+
+func (t *table[T]) insert(rows ...T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rows = append(t.rows, rows...)
+}
+
+// insertUnless appends row unless a row matches, and reports whether it did.
+func (t *table[T]) insertUnless(match func(T) bool, row T) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, stored := range t.rows {
+		if match(stored) {
+			return false
+		}
+	}
+	t.rows = append(t.rows, row)
+	return true
+}
+
+// insertNext appends the row next makes out of the rows already stored, with
+// no other row inserted in between, unless next returns an error.
+func (t *table[T]) insertNext(next func(rows []T) (T, error)) (T, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	row, err := next(t.rows)
+	if err != nil {
+		return row, err
+	}
+	t.rows = append(t.rows, row)
+	return row, nil
+}
+
+func (t *table[T]) all(match func(T) bool) []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := []T{}
+	for _, row := range t.rows {
+		if match(row) {
+			result = append(result, row)
+		}
+	}
+
+	return result
+}
+
+func (t *table[T]) find(match func(T) bool) (T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, row := range t.rows {
+		if match(row) {
+			return row, nil
+		}
+	}
+
+	var zero T
+	return zero, repository.ErrNotFound
+}
+
+func (t *table[T]) count(match func(T) bool) int {
+	return len(t.all(match))
+}
+
+// update applies change to every matching row and returns how many matched.
+func (t *table[T]) update(match func(T) bool, change func(*T)) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	matched := 0
+	for i := range t.rows {
+		if match(t.rows[i]) {
+			change(&t.rows[i])
+			matched++
+		}
+	}
+
+	return matched
+}
+
+// replace swaps the first matching row for row, or returns ErrNotFound.
+func (t *table[T]) replace(match func(T) bool, row T) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.rows {
+		if match(t.rows[i]) {
+			t.rows[i] = row
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+// upsert replaces the first matching row or appends row when none matches.
+func (t *table[T]) upsert(match func(T) bool, row T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.rows {
+		if match(t.rows[i]) {
+			t.rows[i] = row
+			return
+		}
+	}
+	t.rows = append(t.rows, row)
+}
+
+// upsertWith applies change to the first matching row, or to row appended
+// when none matches, and returns the row as changed.
+func (t *table[T]) upsertWith(match func(T) bool, row T, change func(*T)) T {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.rows {
+		if match(t.rows[i]) {
+			change(&t.rows[i])
+			return t.rows[i]
+		}
+	}
+	change(&row)
+	t.rows = append(t.rows, row)
+	return row
+}
+
+func (t *table[T]) delete(match func(T) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kept := t.rows[:0]
+	for _, row := range t.rows {
+		if !match(row) {
+			kept = append(kept, row)
+		}
+	}
+	t.rows = kept
+}
+
+func paginate[T any](rows []T, page repository.Page) []T {
+	if page.Skip >= len(rows) {
+		return []T{}
+	}
+	rows = rows[page.Skip:]
+	if page.Limit > 0 && page.Limit < len(rows) {
+		rows = rows[:page.Limit]
+	}
+
+	return rows
+}
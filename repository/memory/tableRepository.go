@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type tableRepository struct{ db *database }
+
+func (r tableRepository) List(ctx context.Context, restaurantId string) ([]models.Table, error) {
+	return r.db.tables.all(func(table models.Table) bool { return table.RestaurantId == restaurantId }), nil
+}
+
+func (r tableRepository) Get(ctx context.Context, restaurantId string, tableId string) (models.Table, error) {
+	return r.db.tables.find(func(table models.Table) bool {
+		return table.RestaurantId == restaurantId && table.TableId == tableId
+	})
+}
+
+func (r tableRepository) Create(ctx context.Context, table models.Table) error {
+	r.db.tables.insert(table)
+	return nil
+}
+
+func (r tableRepository) Update(ctx context.Context, table models.Table) error {
+	return r.db.tables.replace(func(stored models.Table) bool {
+		return stored.RestaurantId == table.RestaurantId && stored.TableId == table.TableId
+	}, table)
+}
@@ -0,0 +1,25 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type terminalRepository struct{ db *database }
+
+func (r terminalRepository) List(ctx context.Context, restaurantId string) ([]models.Terminal, error) {
+	return r.db.terminals.all(func(terminal models.Terminal) bool { return terminal.RestaurantId == restaurantId }), nil
+}
+
+func (r terminalRepository) Get(ctx context.Context, terminalId string) (models.Terminal, error) {
+	return r.db.terminals.find(func(terminal models.Terminal) bool { return terminal.TerminalId == terminalId })
+}
+
+func (r terminalRepository) Create(ctx context.Context, terminal models.Terminal) error {
+	r.db.terminals.insert(terminal)
+	return nil
+}
+
+func (r terminalRepository) Update(ctx context.Context, terminal models.Terminal) error {
+	return r.db.terminals.replace(func(stored models.Terminal) bool { return stored.TerminalId == terminal.TerminalId }, terminal)
+}
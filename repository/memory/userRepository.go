@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+)
+
+type userRepository struct{ db *database }
+
+func (r userRepository) List(ctx context.Context, restaurantId string, page repository.Page) ([]models.User, int, error) {
+	users := r.db.users.all(func(user models.User) bool { return user.RestaurantId == restaurantId })
+	return paginate(users, page), len(users), nil
+}
+
+func (r userRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	return r.db.users.find(func(user models.User) bool { return user.UserId == userId })
+}
+
+func (r userRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.db.users.find(func(user models.User) bool { return user.Email != nil && *user.Email == email })
+}
+
+func (r userRepository) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	return r.db.users.find(func(user models.User) bool { return user.Phone != nil && *user.Phone == phone })
+}
+
+func (r userRepository) Count(ctx context.Context) (int, error) {
+	return r.db.users.count(func(models.User) bool { return true }), nil
+}
+
+func (r userRepository) Create(ctx context.Context, user models.User) error {
+	r.db.users.insert(user)
+	return nil
+}
+
+func (r userRepository) Update(ctx context.Context, user models.User) error {
+	matched := r.db.users.update(func(stored models.User) bool { return stored.UserId == user.UserId }, func(stored *models.User) {
+		user.SessionEpoch = stored.SessionEpoch
+		*stored = user
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r userRepository) SetTokens(ctx context.Context, userId string, signedToken string, signedRefreshToken string) error {
+	matched := r.db.users.update(func(user models.User) bool { return user.UserId == userId }, func(user *models.User) {
+		user.Token = &signedToken
+		user.RefreshToken = &signedRefreshToken
+		user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r userRepository) RotateTokens(ctx context.Context, userId string, currentRefreshToken string, signedToken string, signedRefreshToken string) (bool, error) {
+	matched := r.db.users.update(func(user models.User) bool {
+		return user.UserId == userId && user.RefreshToken != nil && *user.RefreshToken == currentRefreshToken
+	}, func(user *models.User) {
+		user.Token = &signedToken
+		user.RefreshToken = &signedRefreshToken
+		user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	})
+
+	return matched > 0, nil
+}
+
+func (r userRepository) RevokeSessions(ctx context.Context, userId string) error {
+	matched := r.db.users.update(func(user models.User) bool { return user.UserId == userId }, func(user *models.User) {
+		empty := ""
+		user.Token = &empty
+		user.RefreshToken = &empty
+		user.SessionEpoch++
+		user.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	})
+	if matched == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
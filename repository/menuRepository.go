@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type MenuRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Menu, error)
+	Get(ctx context.Context, restaurantId string, menuId string) (models.Menu, error)
+	Create(ctx context.Context, menu models.Menu) error
+	Update(ctx context.Context, menu models.Menu) error
+}
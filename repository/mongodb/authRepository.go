@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type revokedTokenRepository struct {
+	collection[models.RevokedToken]
+}
+
+func (r revokedTokenRepository) Add(ctx context.Context, token models.RevokedToken) error {
+	_, err := r.UpdateOne(
+		ctx,
+		bson.M{"token_id": token.TokenId},
+		bson.D{{Key: "$setOnInsert", Value: token}},
+		options.Update().SetUpsert(true),
+	)
+
+	return err
+}
+
+func (r revokedTokenRepository) Exists(ctx context.Context, tokenId string) (bool, error) {
+	count, err := r.CountDocuments(ctx, bson.M{"token_id": tokenId})
+	return count > 0, err
+}
+
+type passwordResetRepository struct {
+	collection[models.PasswordReset]
+}
+
+func (r passwordResetRepository) Create(ctx context.Context, reset models.PasswordReset) error {
+	return r.insert(ctx, reset)
+}
+
+func (r passwordResetRepository) CancelOpen(ctx context.Context, userId string, at time.Time) error {
+	_, err := r.UpdateMany(
+		ctx,
+		bson.M{"user_id": userId, "used_at": nil},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: at}}}},
+	)
+
+	return err
+}
+
+func (r passwordResetRepository) Consume(ctx context.Context, tokenHash string, at time.Time) (models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_hash": tokenHash,
+			"used_at":    nil,
+			"expires_at": bson.M{"$gt": at},
+		},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: at}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return reset, repository.ErrNotFound
+	}
+
+	return reset, err
+}
+
+type loginAttemptRepository struct {
+	collection[models.LoginAttempt]
+}
+
+func (r loginAttemptRepository) Get(ctx context.Context, key string) (models.LoginAttempt, error) {
+	return r.findOne(ctx, bson.M{"key": key})
+}
+
+func (r loginAttemptRepository) GetMany(ctx context.Context, keys []string) ([]models.LoginAttempt, error) {
+	return r.find(ctx, bson.M{"key": bson.M{"$in": keys}})
+}
+
+func (r loginAttemptRepository) RecordFailure(ctx context.Context, key string, source string, at time.Time, since time.Time) (models.LoginAttempt, error) {
+	// failures from before the window are forgotten first, so that the $inc
+	// below counts from zero; a concurrent failure can only find the count
+	// already reset or already counted within the window
+	if _, err := r.UpdateOne(ctx, bson.M{"key": key, "last_failure_at": bson.M{"$lt": since}}, bson.M{"$set": bson.M{"failures": 0, "sources": bson.A{}}}); err != nil {
+		return models.LoginAttempt{}, err
+	}
+
+	change := bson.M{"$inc": bson.M{"failures": 1}, "$set": bson.M{"last_failure_at": at}}
+	if source != "" {
+		change["$addToSet"] = bson.M{"sources": source}
+	}
+
+	var attempt models.LoginAttempt
+	err := r.FindOneAndUpdate(
+		ctx,
+		bson.M{"key": key},
+		change,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+
+	return attempt, err
+}
+
+func (r loginAttemptRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.UpdateOne(ctx, bson.M{"key": key}, bson.M{"$max": bson.M{"locked_until": until}})
+	return err
+}
+
+func (r loginAttemptRepository) Delete(ctx context.Context, keys []string) error {
+	_, err := r.DeleteMany(ctx, bson.M{"key": bson.M{"$in": keys}})
+	return err
+}
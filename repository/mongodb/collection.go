@@ -0,0 +1,135 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// collection decodes documents of one collection into T and translates
+// driver errors into repository ones.
+type collection[T any] struct {
+	*mongo.Collection
+}
+
+func (c collection[T]) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := c.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := []T{}
+	if err = cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func (c collection[T]) findOne(ctx context.Context, filter bson.M) (T, error) {
+	var document T
+	err := c.FindOne(ctx, filter).Decode(&document)
+	if err == mongo.ErrNoDocuments {
+		return document, repository.ErrNotFound
+	}
+
+	return document, err
+}
+
+func (c collection[T]) insert(ctx context.Context, document T) error {
+	_, err := c.InsertOne(ctx, document)
+	return err
+}
+
+// numberingAttempts is how many sequence numbers insertNumbered tries before
+// giving up, each one having been taken by a concurrent insert.
+const numberingAttempts = 10
+
+// insertNumbered inserts the document number makes for the sequence number
+// following the last one stored under scope, and returns it. A unique index
+// on scope and sequence keeps two documents from taking the same number; the
+// one that loses moves on to the next. As numbers are only taken by documents
+// that are stored, they follow each other without gaps.
+func (c collection[T]) insertNumbered(ctx context.Context, scope bson.M, number func(sequence int) T) (T, error) {
+	var document T
+	for attempt := 0; attempt < numberingAttempts; attempt++ {
+		var last struct {
+			Sequence int `bson:"sequence"`
+		}
+		opts := options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}}).SetProjection(bson.M{"sequence": 1})
+		err := c.FindOne(ctx, scope, opts).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return document, err
+		}
+
+		document = number(last.Sequence + 1)
+		err = c.insert(ctx, document)
+		if !mongo.IsDuplicateKeyError(err) {
+			return document, err
+		}
+
+		// the document may clash on another unique index, in which case
+		// no other number would do
+		taken := bson.M{"sequence": last.Sequence + 1}
+		for key, value := range scope {
+			taken[key] = value
+		}
+		if count, countErr := c.CountDocuments(ctx, taken); countErr != nil || count == 0 {
+			return document, err
+		}
+	}
+
+	return document, errors.New("too many documents were numbered at once, please try again")
+}
+
+// replace overwrites the document matching filter, or returns ErrNotFound.
+func (c collection[T]) replace(ctx context.Context, filter bson.M, document T) error {
+	result, err := c.ReplaceOne(ctx, filter, document)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// page lists the documents matching filter in insertion order, together with
+// how many there are in total.
+func (c collection[T]) page(ctx context.Context, filter bson.M, page repository.Page) ([]T, int, error) {
+	total, err := c.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(int64(page.Skip))
+	if page.Limit > 0 {
+		opts.SetLimit(int64(page.Limit))
+	}
+	documents, err := c.find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return documents, int(total), nil
+}
+
+// appended is an update pipeline expression for field with value added at
+// its end. value goes in as a $literal, so that strings in it starting with $
+// are stored as they are rather than read as field paths or variables.
+func appended(field string, value interface{}) bson.D {
+	return bson.D{{Key: "$concatArrays", Value: bson.A{
+		bson.D{{Key: "$ifNull", Value: bson.A{"$" + field, bson.A{}}}},
+		bson.D{{Key: "$literal", Value: bson.A{value}}},
+	}}}
+}
+
+func scoped(restaurantId string, filter bson.M) bson.M {
+	filter["restaurant_id"] = restaurantId
+	return filter
+}
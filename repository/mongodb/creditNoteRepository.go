@@ -0,0 +1,33 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type creditNoteRepository struct{ collection[models.CreditNote] }
+
+func (r creditNoteRepository) List(ctx context.Context, restaurantId string) ([]models.CreditNote, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}), options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "sequence", Value: 1}}))
+}
+
+func (r creditNoteRepository) ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.CreditNote, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{"invoice_id": invoiceId}), options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "sequence", Value: 1}}))
+}
+
+func (r creditNoteRepository) Get(ctx context.Context, restaurantId string, creditNoteId string) (models.CreditNote, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"credit_note_id": creditNoteId}))
+}
+
+// Issue relies on the unique index on restaurant_id, fiscal_year and
+// sequence.
+func (r creditNoteRepository) Issue(ctx context.Context, creditNote models.CreditNote, prefix string) (models.CreditNote, error) {
+	return r.insertNumbered(ctx, scoped(creditNote.RestaurantId, bson.M{"fiscal_year": creditNote.FiscalYear}), func(sequence int) models.CreditNote {
+		creditNote.Sequence = sequence
+		creditNote.CreditNoteNumber = models.DocumentNumber(prefix, creditNote.FiscalYear, sequence)
+		return creditNote
+	})
+}
@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type deviceRepository struct{ collection[models.Device] }
+
+func (r deviceRepository) List(ctx context.Context, restaurantId string) ([]models.Device, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r deviceRepository) Get(ctx context.Context, deviceId string) (models.Device, error) {
+	return r.findOne(ctx, bson.M{"device_id": deviceId})
+}
+
+func (r deviceRepository) Create(ctx context.Context, device models.Device) error {
+	return r.insert(ctx, device)
+}
+
+func (r deviceRepository) Update(ctx context.Context, device models.Device) error {
+	return r.replace(ctx, bson.M{"device_id": device.DeviceId}, device)
+}
@@ -0,0 +1,31 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type foodRepository struct{ collection[models.Food] }
+
+func (r foodRepository) List(ctx context.Context, restaurantId string, page repository.Page) ([]models.Food, int, error) {
+	return r.page(ctx, scoped(restaurantId, bson.M{}), page)
+}
+
+func (r foodRepository) Get(ctx context.Context, restaurantId string, foodId string) (models.Food, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"food_id": foodId}))
+}
+
+func (r foodRepository) GetMany(ctx context.Context, restaurantId string, foodIds []string) ([]models.Food, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{"food_id": bson.M{"$in": foodIds}}))
+}
+
+func (r foodRepository) Create(ctx context.Context, food models.Food) error {
+	return r.insert(ctx, food)
+}
+
+func (r foodRepository) Update(ctx context.Context, food models.Food) error {
+	return r.replace(ctx, scoped(food.RestaurantId, bson.M{"food_id": food.FoodId}), food)
+}
@@ -0,0 +1,224 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type invoiceRepository struct{ collection[models.Invoice] }
+
+func (r invoiceRepository) List(ctx context.Context, restaurantId string) ([]models.Invoice, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r invoiceRepository) ListPaidIn(ctx context.Context, restaurantId string, period repository.Period) ([]models.Invoice, error) {
+	createdAt := bson.M{}
+	if !period.From.IsZero() {
+		createdAt["$gte"] = period.From
+	}
+	if !period.To.IsZero() {
+		createdAt["$lt"] = period.To
+	}
+	payment := bson.M{}
+	if len(createdAt) > 0 {
+		payment["created_at"] = createdAt
+	}
+
+	return r.find(ctx, scoped(restaurantId, bson.M{"payments": bson.M{"$elemMatch": payment}}))
+}
+
+func (r invoiceRepository) Get(ctx context.Context, restaurantId string, invoiceId string) (models.Invoice, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"invoice_id": invoiceId}))
+}
+
+func (r invoiceRepository) GetByOrder(ctx context.Context, restaurantId string, orderId string) (models.Invoice, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"order_id": orderId, "payment_status": bson.M{"$ne": models.InvoiceVoided}}))
+}
+
+func (r invoiceRepository) Create(ctx context.Context, invoice models.Invoice) error {
+	return r.insert(ctx, invoice)
+}
+
+// Issue relies on the unique index on restaurant_id, fiscal_year and
+// sequence.
+func (r invoiceRepository) Issue(ctx context.Context, invoice models.Invoice, prefix string) (models.Invoice, error) {
+	issued, err := r.insertNumbered(ctx, scoped(invoice.RestaurantId, bson.M{"fiscal_year": invoice.FiscalYear}), func(sequence int) models.Invoice {
+		invoice.Sequence = sequence
+		invoice.InvoiceNumber = models.DocumentNumber(prefix, invoice.FiscalYear, sequence)
+		return invoice
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return issued, repository.ErrOrderInvoiced
+	}
+
+	return issued, err
+}
+
+func (r invoiceRepository) SetPaymentMethod(ctx context.Context, restaurantId string, invoiceId string, method string, at time.Time) error {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId}),
+		bson.M{"$set": bson.M{"payment_method": method, "updated_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) SetTotals(ctx context.Context, restaurantId string, invoiceId string, totals models.OrderTotals) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "totals": nil}),
+		bson.M{"$set": bson.M{"totals": totals}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r invoiceRepository) Void(ctx context.Context, restaurantId string, invoiceId string, paid int, credited models.Money, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{
+			"invoice_id":     invoiceId,
+			"payment_status": bson.M{"$ne": models.InvoiceVoided},
+			"payments":       paymentCount(paid),
+			"credited":       creditedAt(credited),
+		}),
+		bson.M{"$set": bson.M{"payment_status": models.InvoiceVoided, "updated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r invoiceRepository) AddPayment(ctx context.Context, restaurantId string, invoiceId string, paid int, payment models.Payment, status string) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "payments": paymentCount(paid)}),
+		bson.A{bson.D{{Key: "$set", Value: bson.D{
+			{Key: "payment_status", Value: status},
+			{Key: "updated_at", Value: payment.CreatedAt},
+			{Key: "payments", Value: appended("payments", payment)},
+		}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r invoiceRepository) AddCardTransaction(ctx context.Context, restaurantId string, invoiceId string, transaction models.CardTransaction) error {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId}),
+		bson.A{bson.D{{Key: "$set", Value: bson.D{
+			{Key: "card_transactions", Value: appended("card_transactions", transaction)},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) AddCredit(ctx context.Context, restaurantId string, invoiceId string, credited models.Money, amount models.Money) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "credited": creditedAt(credited)}),
+		bson.M{"$set": bson.M{"credited": credited + amount}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r invoiceRepository) ReserveRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, refunded models.Money, amount models.Money) (bool, error) {
+	current := interface{}(refunded)
+	if refunded == 0 {
+		// payments taken before refunds were reserved have none
+		current = bson.M{"$in": bson.A{nil, refunded}}
+	}
+
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "payments": bson.M{"$elemMatch": bson.M{"payment_id": paymentId, "refunded": current}}}),
+		bson.M{"$set": bson.M{"payments.$.refunded": refunded + amount}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r invoiceRepository) ReleaseRefund(ctx context.Context, restaurantId string, invoiceId string, paymentId string, amount models.Money) error {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "payments.payment_id": paymentId}),
+		bson.M{"$inc": bson.M{"payments.$.refunded": -amount}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r invoiceRepository) SetPaymentTip(ctx context.Context, restaurantId string, invoiceId string, paymentId string, tip models.Money) error {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"invoice_id": invoiceId, "payments.payment_id": paymentId}),
+		bson.M{"$set": bson.M{"payments.$.tip": tip}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+// paymentCount matches invoices with exactly paid payments.
+func paymentCount(paid int) interface{} {
+	if paid == 0 {
+		// invoices stored before payments existed have none
+		return bson.M{"$in": bson.A{nil, bson.A{}}}
+	}
+	return bson.M{"$size": paid}
+}
+
+// creditedAt matches invoices credited exactly credited.
+func creditedAt(credited models.Money) interface{} {
+	if credited == 0 {
+		// invoices stored before credit notes existed have none
+		return bson.M{"$in": bson.A{nil, credited}}
+	}
+	return credited
+}
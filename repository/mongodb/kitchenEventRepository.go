@@ -0,0 +1,53 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type kitchenEventRepository struct {
+	collection[models.KitchenEvent]
+	counters *mongo.Collection
+}
+
+// Append takes the next sequence from the counters collection before storing
+// the event. Two concurrent appends may become visible out of order, which
+// the feed allows for by waiting briefly on gaps.
+func (r kitchenEventRepository) Append(ctx context.Context, event models.KitchenEvent) (models.KitchenEvent, error) {
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := r.counters.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": "kitchen_events:" + event.RestaurantId},
+		bson.M{"$inc": bson.M{"sequence": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return event, err
+	}
+
+	event.Sequence = counter.Sequence
+	return event, r.insert(ctx, event)
+}
+
+func (r kitchenEventRepository) ListAfter(ctx context.Context, restaurantId string, after int64, limit int) ([]models.KitchenEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, scoped(restaurantId, bson.M{"sequence": bson.M{"$gt": after}}), opts)
+}
+
+func (r kitchenEventRepository) LastSequence(ctx context.Context, restaurantId string) (int64, error) {
+	var counter struct {
+		Sequence int64 `bson:"sequence"`
+	}
+	err := r.counters.FindOne(ctx, bson.M{"_id": "kitchen_events:" + restaurantId}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+
+	return counter.Sequence, err
+}
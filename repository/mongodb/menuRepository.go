@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type menuRepository struct{ collection[models.Menu] }
+
+func (r menuRepository) List(ctx context.Context, restaurantId string) ([]models.Menu, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r menuRepository) Get(ctx context.Context, restaurantId string, menuId string) (models.Menu, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"menu_id": menuId}))
+}
+
+func (r menuRepository) Create(ctx context.Context, menu models.Menu) error {
+	return r.insert(ctx, menu)
+}
+
+func (r menuRepository) Update(ctx context.Context, menu models.Menu) error {
+	return r.replace(ctx, scoped(menu.RestaurantId, bson.M{"menu_id": menu.MenuId}), menu)
+}
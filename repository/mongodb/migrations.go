@@ -0,0 +1,134 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrate brings documents stored by older versions up to date. Every step
+// only touches documents still in the old shape, so it is safe to run on
+// every start.
+func Migrate(ctx context.Context, db *mongo.Database) error {
+	// Order items, and the copies of them in kitchen events, used to keep
+	// their size in quantity.
+	_, err := db.Collection("orderItem").UpdateMany(
+		ctx,
+		bson.M{"quantity": bson.M{"$type": "string"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.D{
+			{Key: "size", Value: "$quantity"},
+			{Key: "quantity", Value: 1},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("kitchen_events").UpdateMany(
+		ctx,
+		bson.M{"order_item.quantity": bson.M{"$type": "string"}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.D{
+			{Key: "order_item.size", Value: "$order_item.quantity"},
+			{Key: "order_item.quantity", Value: 1},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	return migrateMoneyToCents(ctx, db)
+}
+
+// migrateMoneyToCents turns the amounts that used to be stored as floating
+// point numbers into whole cents.
+func migrateMoneyToCents(ctx context.Context, db *mongo.Database) error {
+	steps := []struct {
+		collection string
+		field      string
+		set        bson.D
+		unset      string
+	}{
+		{"foods", "price", bson.D{{Key: "price", Value: cents("$price")}}, ""},
+		{"foods", "variants.price", bson.D{{Key: "variants", Value: eachCents("$variants", "price")}}, ""},
+		{"foods", "modifier_groups.options.price_delta", bson.D{{Key: "modifier_groups", Value: bson.D{{Key: "$map", Value: bson.D{
+			{Key: "input", Value: "$modifier_groups"},
+			{Key: "as", Value: "group"},
+			{Key: "in", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{
+				"$$group",
+				bson.D{{Key: "options", Value: eachCents("$$group.options", "price_delta")}},
+			}}}},
+		}}}}}, ""},
+		{"orderItem", "unit_price", bson.D{{Key: "unit_price", Value: cents("$unit_price")}}, ""},
+		{"orderItem", "modifiers.price_delta", bson.D{{Key: "modifiers", Value: eachCents("$modifiers", "price_delta")}}, ""},
+		{"orderItem", "discount.value", discountInCents("discount"), "discount.value"},
+		{"orders", "discount.value", discountInCents("discount"), "discount.value"},
+		{"kitchen_events", "order_item.unit_price", bson.D{{Key: "order_item.unit_price", Value: cents("$order_item.unit_price")}}, ""},
+		{"kitchen_events", "order_item.modifiers.price_delta", bson.D{{Key: "order_item.modifiers", Value: eachCents("$order_item.modifiers", "price_delta")}}, ""},
+		{"restaurants", "pricing.cash_rounding", bson.D{{Key: "pricing.cash_rounding", Value: cents("$pricing.cash_rounding")}}, ""},
+		{"invoice", "totals.total", bson.D{
+			{Key: "totals.subtotal", Value: cents("$totals.subtotal")},
+			{Key: "totals.line_discounts", Value: cents("$totals.line_discounts")},
+			{Key: "totals.order_discount", Value: cents("$totals.order_discount")},
+			{Key: "totals.net", Value: cents("$totals.net")},
+			{Key: "totals.service_charge", Value: cents("$totals.service_charge")},
+			{Key: "totals.tax", Value: cents("$totals.tax")},
+			{Key: "totals.rounding", Value: cents("$totals.rounding")},
+			{Key: "totals.total", Value: cents("$totals.total")},
+			{Key: "totals.lines", Value: eachCents("$totals.lines", "gross", "discount", "order_discount", "net")},
+			{Key: "totals.taxes", Value: eachCents("$totals.taxes", "taxable", "tax")},
+		}, ""},
+	}
+
+	for _, step := range steps {
+		pipeline := mongo.Pipeline{{{Key: "$set", Value: step.set}}}
+		if step.unset != "" {
+			pipeline = append(pipeline, bson.D{{Key: "$unset", Value: step.unset}})
+		}
+
+		_, err := db.Collection(step.collection).UpdateMany(ctx, bson.M{step.field: bson.M{"$type": "double"}}, pipeline)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cents converts the amount at path to whole cents, leaving amounts that
+// already are untouched.
+func cents(path string) bson.D {
+	return bson.D{{Key: "$cond", Value: bson.A{
+		bson.D{{Key: "$eq", Value: bson.A{bson.D{{Key: "$type", Value: path}}, "double"}}},
+		bson.D{{Key: "$toLong", Value: bson.D{{Key: "$round", Value: bson.A{bson.D{{Key: "$multiply", Value: bson.A{path, 100}}}, 0}}}}},
+		path,
+	}}}
+}
+
+// eachCents converts fields of every element of the array at path to cents.
+func eachCents(path string, fields ...string) bson.D {
+	converted := bson.D{}
+	for _, field := range fields {
+		converted = append(converted, bson.E{Key: field, Value: cents("$$element." + field)})
+	}
+
+	return bson.D{{Key: "$map", Value: bson.D{
+		{Key: "input", Value: path},
+		{Key: "as", Value: "element"},
+		{Key: "in", Value: bson.D{{Key: "$mergeObjects", Value: bson.A{"$$element", converted}}}},
+	}}}
+}
+
+// discountInCents splits the old value of the discount at field into a
+// percentage or an amount in cents, by its type.
+func discountInCents(field string) bson.D {
+	value := "$" + field + ".value"
+	isPercent := bson.D{{Key: "$eq", Value: bson.A{"$" + field + ".type", "PERCENT"}}}
+
+	return bson.D{{Key: field, Value: bson.D{{Key: "$mergeObjects", Value: bson.A{
+		"$" + field,
+		bson.D{
+			{Key: "percent", Value: bson.D{{Key: "$cond", Value: bson.A{isPercent, value, 0}}}},
+			{Key: "amount", Value: bson.D{{Key: "$cond", Value: bson.A{isPercent, 0, cents(value)}}}},
+		},
+	}}}}}
+}
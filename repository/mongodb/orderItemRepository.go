@@ -0,0 +1,95 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type orderItemRepository struct{ collection[models.OrderItem] }
+
+func (r orderItemRepository) List(ctx context.Context, restaurantId string) ([]models.OrderItem, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r orderItemRepository) ListByOrder(ctx context.Context, restaurantId string, orderId string) ([]models.OrderItem, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{"order_id": orderId}))
+}
+
+func (r orderItemRepository) Get(ctx context.Context, restaurantId string, orderItemId string) (models.OrderItem, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"order_item_id": orderItemId}))
+}
+
+func (r orderItemRepository) CreateMany(ctx context.Context, orderItems []models.OrderItem) error {
+	documents := make([]interface{}, len(orderItems))
+	for i, orderItem := range orderItems {
+		documents[i] = orderItem
+	}
+
+	_, err := r.InsertMany(ctx, documents)
+	return err
+}
+
+func (r orderItemRepository) Update(ctx context.Context, orderItem models.OrderItem) error {
+	raw, err := bson.Marshal(orderItem)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	delete(fields, "_id")
+	delete(fields, "status")
+	delete(fields, "status_updated_at")
+
+	result, err := r.UpdateOne(ctx, scoped(orderItem.RestaurantId, bson.M{"order_item_id": orderItem.OrderItemId}), bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r orderItemRepository) ListByStation(ctx context.Context, restaurantId string, station string, statuses []string) ([]models.OrderItem, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	return r.find(ctx, scoped(restaurantId, bson.M{"station": station, "status": itemStatusIn(statuses...)}), opts)
+}
+
+func (r orderItemRepository) SetStatus(ctx context.Context, restaurantId string, orderItemId string, from string, status string, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_item_id": orderItemId, "status": itemStatusIn(from)}),
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: status},
+			{Key: "status_updated_at", Value: at},
+			{Key: "updated_at", Value: at},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// itemStatusIn matches order items in any of statuses, counting items stored
+// before statuses existed as queued.
+func itemStatusIn(statuses ...string) bson.M {
+	values := bson.A{}
+	for _, status := range statuses {
+		values = append(values, status)
+		if status == models.OrderItemQueued {
+			values = append(values, "", nil)
+		}
+	}
+
+	return bson.M{"$in": values}
+}
@@ -0,0 +1,129 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type orderRepository struct{ collection[models.Order] }
+
+func (r orderRepository) List(ctx context.Context, restaurantId string) ([]models.Order, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r orderRepository) Get(ctx context.Context, restaurantId string, orderId string) (models.Order, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"order_id": orderId}))
+}
+
+func (r orderRepository) Create(ctx context.Context, order models.Order) error {
+	return r.insert(ctx, order)
+}
+
+func (r orderRepository) Reassign(ctx context.Context, restaurantId string, orderId string, from string, tableId *string, serverId string, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId, "status": statusIs(from)}),
+		bson.M{"$set": bson.M{"table_id": tableId, "server_id": serverId, "updated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r orderRepository) SetDiscount(ctx context.Context, restaurantId string, orderId string, from string, discount *models.Discount, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId, "status": statusIs(from)}),
+		bson.M{"$set": bson.M{"discount": discount, "updated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r orderRepository) SetAppliedDiscounts(ctx context.Context, restaurantId string, orderId string, discounts []models.AppliedDiscount) error {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId}),
+		bson.M{"$set": bson.M{"applied_discounts": discounts}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r orderRepository) Transition(ctx context.Context, restaurantId string, orderId string, from string, change models.OrderStatusChange) (bool, error) {
+	// an update pipeline, because $push fails on orders whose history was
+	// stored as null
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId, "status": statusIs(from)}),
+		bson.A{bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: change.Status},
+			{Key: "updated_at", Value: change.ChangedAt},
+			{Key: "status_history", Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+				bson.D{{Key: "$ifNull", Value: bson.A{"$status_history", bson.A{}}}},
+				bson.A{change},
+			}}}},
+		}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r orderRepository) AddCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error) {
+	// an update pipeline rather than $addToSet, which fails on orders whose
+	// coupons were stored as null; the filter keeps codes from being added
+	// twice
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId, "coupons": bson.M{"$ne": code}}),
+		bson.A{bson.D{{Key: "$set", Value: bson.D{
+			{Key: "coupons", Value: appended("coupons", code)},
+			{Key: "updated_at", Value: at},
+		}}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r orderRepository) RemoveCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"order_id": orderId, "coupons": code}),
+		bson.M{"$pull": bson.M{"coupons": code}, "$set": bson.M{"updated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// statusIs matches orders in status from.
+func statusIs(from string) interface{} {
+	if from == models.OrderOpen {
+		// orders stored before statuses existed have none and count as open
+		return bson.M{"$in": bson.A{models.OrderOpen, "", nil}}
+	}
+	return from
+}
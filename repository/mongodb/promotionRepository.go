@@ -0,0 +1,80 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type promotionRepository struct{ collection[models.Promotion] }
+
+func (r promotionRepository) List(ctx context.Context, restaurantId string) ([]models.Promotion, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r promotionRepository) Get(ctx context.Context, restaurantId string, promotionId string) (models.Promotion, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"promotion_id": promotionId}))
+}
+
+func (r promotionRepository) GetByCode(ctx context.Context, restaurantId string, code string) (models.Promotion, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"code": code}))
+}
+
+func (r promotionRepository) Create(ctx context.Context, promotion models.Promotion) error {
+	return r.insert(ctx, promotion)
+}
+
+func (r promotionRepository) Update(ctx context.Context, promotion models.Promotion) error {
+	document, err := bson.Marshal(promotion)
+	if err != nil {
+		return err
+	}
+	fields := bson.M{}
+	if err := bson.Unmarshal(document, &fields); err != nil {
+		return err
+	}
+	// uses only ever changes through Redeem and Release
+	delete(fields, "uses")
+	delete(fields, "_id")
+
+	result, err := r.UpdateOne(ctx, scoped(promotion.RestaurantId, bson.M{"promotion_id": promotion.PromotionId}), bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r promotionRepository) Redeem(ctx context.Context, restaurantId string, promotionId string) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{
+			"promotion_id": promotionId,
+			"$or": bson.A{
+				bson.M{"max_uses": bson.M{"$lte": 0}},
+				bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+			},
+		}),
+		bson.M{"$inc": bson.M{"uses": 1}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r promotionRepository) Release(ctx context.Context, restaurantId string, promotionId string) error {
+	_, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"promotion_id": promotionId, "uses": bson.M{"$gt": 0}}),
+		bson.M{"$inc": bson.M{"uses": -1}},
+	)
+
+	return err
+}
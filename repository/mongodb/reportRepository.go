@@ -0,0 +1,177 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type reportRepository struct{ db *mongo.Database }
+
+func (r reportRepository) LocationTotals(ctx context.Context, period repository.Period) ([]repository.LocationTotals, error) {
+	createdAt := bson.M{}
+	if !period.From.IsZero() {
+		createdAt["$gte"] = period.From
+	}
+	if !period.To.IsZero() {
+		createdAt["$lt"] = period.To
+	}
+	match := bson.M{}
+	if len(createdAt) > 0 {
+		match["created_at"] = createdAt
+	}
+
+	totals := map[string]*repository.LocationTotals{}
+	totalsFor := func(restaurantId string) *repository.LocationTotals {
+		if totals[restaurantId] == nil {
+			totals[restaurantId] = &repository.LocationTotals{RestaurantId: restaurantId}
+		}
+		return totals[restaurantId]
+	}
+
+	var orderTotals []struct {
+		RestaurantId string `bson:"_id"`
+		Count        int    `bson:"count"`
+	}
+	if err := r.aggregate(ctx, "orders", &orderTotals, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$restaurant_id"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+	}); err != nil {
+		return nil, err
+	}
+	for _, total := range orderTotals {
+		totalsFor(total.RestaurantId).OrderCount = total.Count
+	}
+
+	// lineTotal matches OrderItem.LineTotal, falling back to the food price
+	// and a quantity of one for items stored before either was recorded.
+	lineTotal := bson.D{{Key: "$multiply", Value: bson.A{
+		bson.D{{Key: "$add", Value: bson.A{
+			bson.D{{Key: "$ifNull", Value: bson.A{"$unit_price", bson.D{{Key: "$ifNull", Value: bson.A{"$food.price", 0}}}}}},
+			bson.D{{Key: "$sum", Value: "$modifiers.price_delta"}},
+		}}},
+		bson.D{{Key: "$ifNull", Value: bson.A{"$quantity", 1}}},
+	}}}
+
+	voided := bson.D{{Key: "$eq", Value: bson.A{"$status", models.OrderItemVoided}}}
+	unlessVoided := func(value interface{}) bson.D {
+		return bson.D{{Key: "$cond", Value: bson.A{voided, 0, value}}}
+	}
+	ifVoided := func(value interface{}) bson.D {
+		return bson.D{{Key: "$cond", Value: bson.A{voided, value, 0}}}
+	}
+
+	var itemTotals []struct {
+		RestaurantId string       `bson:"_id"`
+		Count        int          `bson:"count"`
+		Sales        models.Money `bson:"sales"`
+		VoidedCount  int          `bson:"voided_count"`
+		Voids        models.Money `bson:"voids"`
+	}
+	if err := r.aggregate(ctx, "orderItem", &itemTotals, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "foods"},
+			{Key: "localField", Value: "food_id"},
+			{Key: "foreignField", Value: "food_id"},
+			{Key: "as", Value: "food"},
+		}}},
+		{{Key: "$unwind", Value: bson.D{
+			{Key: "path", Value: "$food"},
+			{Key: "preserveNullAndEmptyArrays", Value: true},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$restaurant_id"},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: unlessVoided(1)}}},
+			{Key: "sales", Value: bson.D{{Key: "$sum", Value: unlessVoided(lineTotal)}}},
+			{Key: "voided_count", Value: bson.D{{Key: "$sum", Value: ifVoided(1)}}},
+			{Key: "voids", Value: bson.D{{Key: "$sum", Value: ifVoided(lineTotal)}}},
+		}}},
+	}); err != nil {
+		return nil, err
+	}
+	for _, total := range itemTotals {
+		location := totalsFor(total.RestaurantId)
+		location.ItemCount = total.Count
+		location.Sales = total.Sales
+		location.VoidedItems = total.VoidedCount
+		location.ItemVoids = total.Voids
+	}
+
+	var invoiceTotals []struct {
+		Id struct {
+			RestaurantId  string `bson:"restaurant_id"`
+			PaymentStatus string `bson:"payment_status"`
+		} `bson:"_id"`
+		Count  int          `bson:"count"`
+		Amount models.Money `bson:"amount"`
+	}
+	if err := r.aggregate(ctx, "invoice", &invoiceTotals, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "restaurant_id", Value: "$restaurant_id"},
+				{Key: "payment_status", Value: "$payment_status"},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "amount", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$totals.total", 0}}}}}},
+		}}},
+	}); err != nil {
+		return nil, err
+	}
+	for _, total := range invoiceTotals {
+		location := totalsFor(total.Id.RestaurantId)
+		switch total.Id.PaymentStatus {
+		case models.InvoicePaid:
+			location.PaidInvoices += total.Count
+		case models.InvoiceVoided:
+			location.VoidedInvoices += total.Count
+			location.InvoiceVoids += total.Amount
+		default:
+			location.PendingInvoices += total.Count
+		}
+	}
+
+	refunded := bson.M{"type": models.ReversalRefund, "status": models.ReversalCompleted}
+	for key, value := range match {
+		refunded[key] = value
+	}
+	var refundTotals []struct {
+		RestaurantId string       `bson:"_id"`
+		Amount       models.Money `bson:"amount"`
+	}
+	if err := r.aggregate(ctx, "reversals", &refundTotals, mongo.Pipeline{
+		{{Key: "$match", Value: refunded}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$restaurant_id"},
+			{Key: "amount", Value: bson.D{{Key: "$sum", Value: "$amount"}}},
+		}}},
+	}); err != nil {
+		return nil, err
+	}
+	for _, total := range refundTotals {
+		totalsFor(total.RestaurantId).Refunds = total.Amount
+	}
+
+	result := []repository.LocationTotals{}
+	for _, location := range totals {
+		result = append(result, *location)
+	}
+
+	return result, nil
+}
+
+func (r reportRepository) aggregate(ctx context.Context, collectionName string, results interface{}, pipeline mongo.Pipeline) error {
+	cursor, err := r.db.Collection(collectionName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+
+	return cursor.All(ctx, results)
+}
@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type restaurantRepository struct{ collection[models.Restaurant] }
+
+func (r restaurantRepository) List(ctx context.Context) ([]models.Restaurant, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r restaurantRepository) Get(ctx context.Context, restaurantId string) (models.Restaurant, error) {
+	return r.findOne(ctx, bson.M{"restaurant_id": restaurantId})
+}
+
+func (r restaurantRepository) Create(ctx context.Context, restaurant models.Restaurant) error {
+	return r.insert(ctx, restaurant)
+}
+
+func (r restaurantRepository) Update(ctx context.Context, restaurant models.Restaurant) error {
+	return r.replace(ctx, bson.M{"restaurant_id": restaurant.RestaurantId}, restaurant)
+}
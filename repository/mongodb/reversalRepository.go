@@ -0,0 +1,48 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type reversalRepository struct{ collection[models.Reversal] }
+
+func (r reversalRepository) List(ctx context.Context, restaurantId string, status string) ([]models.Reversal, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, scoped(restaurantId, filter))
+}
+
+func (r reversalRepository) ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.Reversal, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{"invoice_id": invoiceId}))
+}
+
+func (r reversalRepository) Get(ctx context.Context, restaurantId string, reversalId string) (models.Reversal, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"reversal_id": reversalId}))
+}
+
+func (r reversalRepository) Create(ctx context.Context, reversal models.Reversal) error {
+	return r.insert(ctx, reversal)
+}
+
+func (r reversalRepository) Update(ctx context.Context, reversal models.Reversal) error {
+	return r.replace(ctx, scoped(reversal.RestaurantId, bson.M{"reversal_id": reversal.ReversalId}), reversal)
+}
+
+func (r reversalRepository) Decide(ctx context.Context, restaurantId string, reversalId string, status string, decidedBy string, at time.Time) (bool, error) {
+	result, err := r.UpdateOne(
+		ctx,
+		scoped(restaurantId, bson.M{"reversal_id": reversalId, "status": models.ReversalPending}),
+		bson.M{"$set": bson.M{"status": status, "decided_by": decidedBy, "decided_at": at, "updated_at": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
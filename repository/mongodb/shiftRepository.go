@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type shiftRepository struct{ collection[models.Shift] }
+
+func (r shiftRepository) List(ctx context.Context, restaurantId string) ([]models.Shift, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}), options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}}))
+}
+
+func (r shiftRepository) Get(ctx context.Context, restaurantId string, shiftId string) (models.Shift, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"shift_id": shiftId}))
+}
+
+func (r shiftRepository) Last(ctx context.Context, restaurantId string) (models.Shift, error) {
+	shifts, err := r.find(ctx, scoped(restaurantId, bson.M{}), options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(1))
+	if err != nil {
+		return models.Shift{}, err
+	}
+	if len(shifts) == 0 {
+		return models.Shift{}, repository.ErrNotFound
+	}
+
+	return shifts[0], nil
+}
+
+// Close relies on the unique index on restaurant_id and started_at.
+func (r shiftRepository) Close(ctx context.Context, shift models.Shift) (bool, error) {
+	err := r.insert(ctx, shift)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
@@ -0,0 +1,148 @@
+// Package mongodb implements the repositories on top of MongoDB, one
+// collection per model.
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewStore(db *mongo.Database) *repository.Store {
+	return &repository.Store{
+		Foods:          foodRepository{collection[models.Food]{db.Collection("foods")}},
+		Menus:          menuRepository{collection[models.Menu]{db.Collection("menus")}},
+		Tables:         tableRepository{collection[models.Table]{db.Collection("tables")}},
+		Orders:         orderRepository{collection[models.Order]{db.Collection("orders")}},
+		OrderItems:     orderItemRepository{collection[models.OrderItem]{db.Collection("orderItem")}},
+		Invoices:       invoiceRepository{collection[models.Invoice]{db.Collection("invoice")}},
+		Users:          userRepository{collection[models.User]{db.Collection("users")}},
+		Restaurants:    restaurantRepository{collection[models.Restaurant]{db.Collection("restaurants")}},
+		Terminals:      terminalRepository{collection[models.Terminal]{db.Collection("terminals")}},
+		Devices:        deviceRepository{collection[models.Device]{db.Collection("devices")}},
+		RevokedTokens:  revokedTokenRepository{collection[models.RevokedToken]{db.Collection("revoked_tokens")}},
+		PasswordResets: passwordResetRepository{collection[models.PasswordReset]{db.Collection("password_resets")}},
+		LoginAttempts:  loginAttemptRepository{collection[models.LoginAttempt]{db.Collection("login_attempts")}},
+		Reports:        reportRepository{db},
+		KitchenEvents:  kitchenEventRepository{collection[models.KitchenEvent]{db.Collection("kitchen_events")}, db.Collection("counters")},
+		Promotions:     promotionRepository{collection[models.Promotion]{db.Collection("promotions")}},
+		Reversals:      reversalRepository{collection[models.Reversal]{db.Collection("reversals")}},
+		Shifts:         shiftRepository{collection[models.Shift]{db.Collection("shifts")}},
+		CreditNotes:    creditNoteRepository{collection[models.CreditNote]{db.Collection("credit_notes")}},
+	}
+}
+
+// EnsureIndexes creates the indexes the repositories rely on, both for their
+// lookups and for the unique keys some of their writes depend on.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	// revoked tokens are dropped once they would have expired anyway
+	_, err := db.Collection("revoked_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// failures are counted with an upsert on the key
+	_, err = db.Collection("login_attempts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// station queues
+	_, err = db.Collection("orderItem").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "station", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// screens resume the feed from a sequence; events are dropped once no
+	// screen would resume from them
+	_, err = db.Collection("kitchen_events").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// coupon codes are unique per restaurant, promotions without one are not
+	// indexed
+	_, err = db.Collection("promotions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"code": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// refunds are totalled per invoice
+	_, err = db.Collection("reversals").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "invoice_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// keeps a shift from being closed twice
+	_, err = db.Collection("shifts").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "started_at", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// numbers are issued once and an order is invoiced once; invoices issued
+	// before they were numbered have no sequence, and orders are invoiced
+	// again once their invoice is voided
+	_, err = db.Collection("invoice").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "fiscal_year", Value: 1}, {Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"sequence": bson.M{"$gt": 0}}),
+		},
+		{
+			Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"payment_status": bson.M{"$in": bson.A{
+				models.InvoicePending, models.InvoicePartiallyPaid, models.InvoicePaid,
+			}}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// numbers are issued once, and credit notes are listed per invoice
+	_, err = db.Collection("credit_notes").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "fiscal_year", Value: 1}, {Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "invoice_id", Value: 1}},
+		},
+	})
+
+	return err
+}
@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type tableRepository struct{ collection[models.Table] }
+
+func (r tableRepository) List(ctx context.Context, restaurantId string) ([]models.Table, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r tableRepository) Get(ctx context.Context, restaurantId string, tableId string) (models.Table, error) {
+	return r.findOne(ctx, scoped(restaurantId, bson.M{"table_id": tableId}))
+}
+
+func (r tableRepository) Create(ctx context.Context, table models.Table) error {
+	return r.insert(ctx, table)
+}
+
+func (r tableRepository) Update(ctx context.Context, table models.Table) error {
+	return r.replace(ctx, scoped(table.RestaurantId, bson.M{"table_id": table.TableId}), table)
+}
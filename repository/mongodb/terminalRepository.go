@@ -0,0 +1,26 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type terminalRepository struct{ collection[models.Terminal] }
+
+func (r terminalRepository) List(ctx context.Context, restaurantId string) ([]models.Terminal, error) {
+	return r.find(ctx, scoped(restaurantId, bson.M{}))
+}
+
+func (r terminalRepository) Get(ctx context.Context, terminalId string) (models.Terminal, error) {
+	return r.findOne(ctx, bson.M{"terminal_id": terminalId})
+}
+
+func (r terminalRepository) Create(ctx context.Context, terminal models.Terminal) error {
+	return r.insert(ctx, terminal)
+}
+
+func (r terminalRepository) Update(ctx context.Context, terminal models.Terminal) error {
+	return r.replace(ctx, bson.M{"terminal_id": terminal.TerminalId}, terminal)
+}
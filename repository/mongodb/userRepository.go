@@ -0,0 +1,122 @@
+package mongodb
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"restaurant-management-system/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type userRepository struct{ collection[models.User] }
+
+func (r userRepository) List(ctx context.Context, restaurantId string, page repository.Page) ([]models.User, int, error) {
+	return r.page(ctx, scoped(restaurantId, bson.M{}), page)
+}
+
+func (r userRepository) Get(ctx context.Context, userId string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"user_id": userId})
+}
+
+func (r userRepository) GetByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r userRepository) GetByPhone(ctx context.Context, phone string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"phone": phone})
+}
+
+func (r userRepository) Count(ctx context.Context) (int, error) {
+	count, err := r.CountDocuments(ctx, bson.M{})
+	return int(count), err
+}
+
+func (r userRepository) Create(ctx context.Context, user models.User) error {
+	return r.insert(ctx, user)
+}
+
+// Update leaves the session epoch alone, so that it cannot undo a revoke-all
+// made since the user was fetched.
+func (r userRepository) Update(ctx context.Context, user models.User) error {
+	raw, err := bson.Marshal(user)
+	if err != nil {
+		return err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	delete(fields, "_id")
+	delete(fields, "session_epoch")
+
+	result, err := r.UpdateOne(ctx, bson.M{"user_id": user.UserId}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r userRepository) SetTokens(ctx context.Context, userId string, signedToken string, signedRefreshToken string) error {
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	result, err := r.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "signed_token", Value: signedToken},
+			{Key: "signed_refresh_token", Value: signedRefreshToken},
+			{Key: "updated_at", Value: updatedAt},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r userRepository) RotateTokens(ctx context.Context, userId string, currentRefreshToken string, signedToken string, signedRefreshToken string) (bool, error) {
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	result, err := r.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId, "signed_refresh_token": currentRefreshToken},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "signed_token", Value: signedToken},
+			{Key: "signed_refresh_token", Value: signedRefreshToken},
+			{Key: "updated_at", Value: updatedAt},
+		}}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+func (r userRepository) RevokeSessions(ctx context.Context, userId string) error {
+	updatedAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+	result, err := r.UpdateOne(
+		ctx,
+		bson.M{"user_id": userId},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "signed_token", Value: ""},
+			{Key: "signed_refresh_token", Value: ""},
+			{Key: "updated_at", Value: updatedAt},
+		}}, {Key: "$inc", Value: bson.D{{Key: "session_epoch", Value: 1}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+)
+
+type OrderItemRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.OrderItem, error)
+	ListByOrder(ctx context.Context, restaurantId string, orderId string) ([]models.OrderItem, error)
+	Get(ctx context.Context, restaurantId string, orderItemId string) (models.OrderItem, error)
+	CreateMany(ctx context.Context, orderItems []models.OrderItem) error
+	// Update stores the changes to the item but its kitchen status, which
+	// only SetStatus moves.
+	Update(ctx context.Context, orderItem models.OrderItem) error
+	// ListByStation returns the items of station in any of statuses, oldest
+	// first.
+	ListByStation(ctx context.Context, restaurantId string, station string, statuses []string) ([]models.OrderItem, error)
+	// SetStatus moves the item to status only while it is still in status
+	// from, and reports whether it did.
+	SetStatus(ctx context.Context, restaurantId string, orderItemId string, from string, status string, at time.Time) (bool, error)
+}
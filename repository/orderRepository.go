@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+)
+
+type OrderRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Order, error)
+	Get(ctx context.Context, restaurantId string, orderId string) (models.Order, error)
+	Create(ctx context.Context, order models.Order) error
+	// Reassign puts the order at tableId and hands it to serverId, only while
+	// the order is still in status from, and reports whether it did.
+	Reassign(ctx context.Context, restaurantId string, orderId string, from string, tableId *string, serverId string, at time.Time) (bool, error)
+	// SetDiscount replaces the discount on the order, only while the order is
+	// still in status from, and reports whether it did.
+	SetDiscount(ctx context.Context, restaurantId string, orderId string, from string, discount *models.Discount, at time.Time) (bool, error)
+	// SetAppliedDiscounts records the discounts the order was invoiced with.
+	SetAppliedDiscounts(ctx context.Context, restaurantId string, orderId string, discounts []models.AppliedDiscount) error
+	// Transition moves the order to change.Status and records the change, only
+	// while the order is still in status from, and reports whether it did.
+	Transition(ctx context.Context, restaurantId string, orderId string, from string, change models.OrderStatusChange) (bool, error)
+	// AddCoupon adds code to the coupons of the order unless it is already
+	// there, and reports whether it did.
+	AddCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error)
+	// RemoveCoupon takes code off the coupons of the order, and reports
+	// whether it was there.
+	RemoveCoupon(ctx context.Context, restaurantId string, orderId string, code string, at time.Time) (bool, error)
+}
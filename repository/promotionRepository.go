@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type PromotionRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Promotion, error)
+	Get(ctx context.Context, restaurantId string, promotionId string) (models.Promotion, error)
+	GetByCode(ctx context.Context, restaurantId string, code string) (models.Promotion, error)
+	Create(ctx context.Context, promotion models.Promotion) error
+	// Update stores promotion, keeping how often it has been used as it is.
+	Update(ctx context.Context, promotion models.Promotion) error
+	// Redeem counts one use of a coupon, only while it has uses left, and
+	// reports whether it did.
+	Redeem(ctx context.Context, restaurantId string, promotionId string) (bool, error)
+	// Release gives back a use counted by Redeem.
+	Release(ctx context.Context, restaurantId string, promotionId string) error
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+// LocationTotals are the figures of one restaurant. Voided items are left
+// out of ItemCount and Sales and counted apart.
+type LocationTotals struct {
+	RestaurantId    string
+	OrderCount      int
+	ItemCount       int
+	Sales           models.Money
+	VoidedItems     int
+	ItemVoids       models.Money
+	PaidInvoices    int
+	PendingInvoices int
+	VoidedInvoices  int
+	InvoiceVoids    models.Money
+	Refunds         models.Money
+}
+
+// ReportRepository computes figures across every restaurant.
+type ReportRepository interface {
+	LocationTotals(ctx context.Context, period Period) ([]LocationTotals, error)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when the requested document does not exist, or
+// does not belong to the restaurant it was looked up in.
+var ErrNotFound = errors.New("not found")
+
+// Page selects a window of a listing.
+type Page struct {
+	Skip  int
+	Limit int
+}
+
+// Period restricts reports to documents created in [From, To). Zero values
+// leave that side open.
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+func (p Period) Contains(t time.Time) bool {
+	if !p.From.IsZero() && t.Before(p.From) {
+		return false
+	}
+	if !p.To.IsZero() && !t.Before(p.To) {
+		return false
+	}
+
+	return true
+}
+
+// Store bundles every repository the service needs, so a whole backend can be
+// swapped at once.
+type Store struct {
+	Foods          FoodRepository
+	Menus          MenuRepository
+	Tables         TableRepository
+	Orders         OrderRepository
+	OrderItems     OrderItemRepository
+	Invoices       InvoiceRepository
+	Users          UserRepository
+	Restaurants    RestaurantRepository
+	Terminals      TerminalRepository
+	Devices        DeviceRepository
+	RevokedTokens  RevokedTokenRepository
+	PasswordResets PasswordResetRepository
+	LoginAttempts  LoginAttemptRepository
+	Reports        ReportRepository
+	KitchenEvents  KitchenEventRepository
+	Promotions     PromotionRepository
+	Reversals      ReversalRepository
+	Shifts         ShiftRepository
+	CreditNotes    CreditNoteRepository
+}
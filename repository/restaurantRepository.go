@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type RestaurantRepository interface {
+	List(ctx context.Context) ([]models.Restaurant, error)
+	Get(ctx context.Context, restaurantId string) (models.Restaurant, error)
+	Create(ctx context.Context, restaurant models.Restaurant) error
+	Update(ctx context.Context, restaurant models.Restaurant) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+	"time"
+)
+
+type ReversalRepository interface {
+	// List lists the reversals of the restaurant, only those in status when
+	// it is set.
+	List(ctx context.Context, restaurantId string, status string) ([]models.Reversal, error)
+	ListByInvoice(ctx context.Context, restaurantId string, invoiceId string) ([]models.Reversal, error)
+	Get(ctx context.Context, restaurantId string, reversalId string) (models.Reversal, error)
+	Create(ctx context.Context, reversal models.Reversal) error
+	Update(ctx context.Context, reversal models.Reversal) error
+	// Decide moves a reversal waiting for approval to status, recording who
+	// decided, only while it is still waiting, and reports whether it did.
+	Decide(ctx context.Context, restaurantId string, reversalId string, status string, decidedBy string, at time.Time) (bool, error)
+}
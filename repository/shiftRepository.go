@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type ShiftRepository interface {
+	// List lists the closed shifts of the restaurant, oldest first.
+	List(ctx context.Context, restaurantId string) ([]models.Shift, error)
+	Get(ctx context.Context, restaurantId string, shiftId string) (models.Shift, error)
+	// Last is the shift closed most recently, or ErrNotFound before the first
+	// one is closed.
+	Last(ctx context.Context, restaurantId string) (models.Shift, error)
+	// Close records shift unless another shift already started when it did,
+	// which means it was closed meanwhile, and reports whether it did.
+	Close(ctx context.Context, shift models.Shift) (bool, error)
+}
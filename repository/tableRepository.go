@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type TableRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Table, error)
+	Get(ctx context.Context, restaurantId string, tableId string) (models.Table, error)
+	Create(ctx context.Context, table models.Table) error
+	Update(ctx context.Context, table models.Table) error
+}
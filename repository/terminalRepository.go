@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+type TerminalRepository interface {
+	List(ctx context.Context, restaurantId string) ([]models.Terminal, error)
+	// Get is not scoped to a restaurant because terminals identify themselves
+	// before anybody is signed in.
+	Get(ctx context.Context, terminalId string) (models.Terminal, error)
+	Create(ctx context.Context, terminal models.Terminal) error
+	Update(ctx context.Context, terminal models.Terminal) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"restaurant-management-system/models"
+)
+
+// UserRepository looks users up across restaurants, since sign-in happens
+// before the restaurant is known; callers check the restaurant themselves.
+type UserRepository interface {
+	List(ctx context.Context, restaurantId string, page Page) (users []models.User, total int, err error)
+	Get(ctx context.Context, userId string) (models.User, error)
+	GetByEmail(ctx context.Context, email string) (models.User, error)
+	GetByPhone(ctx context.Context, phone string) (models.User, error)
+	Count(ctx context.Context) (int, error)
+	Create(ctx context.Context, user models.User) error
+	Update(ctx context.Context, user models.User) error
+	// SetTokens stores the current token pair of the user.
+	SetTokens(ctx context.Context, userId string, signedToken string, signedRefreshToken string) error
+	// RotateTokens replaces the token pair only while currentRefreshToken is
+	// still the stored one, and reports whether it did.
+	RotateTokens(ctx context.Context, userId string, currentRefreshToken string, signedToken string, signedRefreshToken string) (bool, error)
+	// RevokeSessions discards the stored tokens and moves the user on to the
+	// next session epoch.
+	RevokeSessions(ctx context.Context, userId string) error
+}
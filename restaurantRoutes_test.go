@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestOwnersManageRestaurants(t *testing.T) {
+	s := newTestServer(t)
+	owner := s.headersFor("", models.RoleOwner)
+
+	var created models.Restaurant
+	s.expect(s.do(http.MethodPost, "/restaurants", map[string]string{"name": "Harbour", "address": "2 Quay Road"}, owner), http.StatusCreated, &created)
+	s.expect(s.do(http.MethodPost, "/restaurants", map[string]string{"name": "Harbour"}, owner), http.StatusBadRequest, nil)
+
+	var updated models.Restaurant
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+created.RestaurantId, map[string]string{"address": "3 Quay Road"}, owner), http.StatusOK, &updated)
+	if *updated.Address != "3 Quay Road" || *updated.Name != "Harbour" {
+		t.Fatalf("expected only the address to change, got %+v", updated)
+	}
+
+	s.expect(s.do(http.MethodGet, "/restaurants/"+created.RestaurantId, nil, owner), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/restaurants/missing", nil, owner), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/missing", map[string]string{"name": "Nowhere"}, owner), http.StatusNotFound, nil)
+
+	s.seedRestaurant("Elsewhere")
+	var restaurants []models.Restaurant
+	s.expect(s.do(http.MethodGet, "/restaurants", nil, owner), http.StatusOK, &restaurants)
+	if len(restaurants) != 2 {
+		t.Fatalf("expected 2 restaurants, got %d", len(restaurants))
+	}
+}
+
+func TestStaffOnlySeeTheirOwnRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	other := s.seedRestaurant("Elsewhere")
+
+	var restaurants []models.Restaurant
+	s.expect(s.do(http.MethodGet, "/restaurants", nil, manager), http.StatusOK, &restaurants)
+	if len(restaurants) != 1 || restaurants[0].RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected only %s, got %+v", restaurant.RestaurantId, restaurants)
+	}
+
+	s.expect(s.do(http.MethodGet, "/restaurants/"+restaurant.RestaurantId, nil, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/restaurants/"+other.RestaurantId, nil, manager), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/restaurants", map[string]string{"name": "Mine", "address": "4 Quay Road"}, manager), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPatch, "/restaurants/"+restaurant.RestaurantId, map[string]string{"name": "Mine"}, manager), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodGet, "/reports/locations", nil, manager), http.StatusForbidden, nil)
+}
+
+func TestOwnersWorkOnTheRestaurantTheyPick(t *testing.T) {
+	s := newTestServer(t)
+	harbour, market := s.seedRestaurant("Harbour"), s.seedRestaurant("Market")
+	s.seedTable(harbour.RestaurantId, 1)
+	owner := s.headersFor("", models.RoleOwner)
+
+	var tables []models.Table
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": owner["token"], "X-Restaurant-Id": harbour.RestaurantId}), http.StatusOK, &tables)
+	if len(tables) != 1 {
+		t.Fatalf("expected the harbour table, got %d tables", len(tables))
+	}
+
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": owner["token"], "X-Restaurant-Id": market.RestaurantId}), http.StatusOK, &tables)
+	if len(tables) != 0 {
+		t.Fatalf("expected no market tables, got %d", len(tables))
+	}
+
+	s.expect(s.do(http.MethodGet, "/tables", nil, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodGet, "/users", nil, owner), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": owner["token"], "X-Restaurant-Id": "made-up"}), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodGet, "/restaurants", nil, owner), http.StatusOK, nil)
+}
+
+func TestLocationsReport(t *testing.T) {
+	s := newTestServer(t)
+	harbour, market := s.seedRestaurant("Harbour"), s.seedRestaurant("Market")
+	soup := s.seedFood(harbour.RestaurantId, s.seedMenu(harbour.RestaurantId).MenuId, "Soup", "4.5")
+	s.seedOrder(harbour.RestaurantId, s.seedTable(harbour.RestaurantId, 1).TableId, soup, soup)
+	owner := s.headersFor("", models.RoleOwner)
+
+	var reports []controllers.LocationReport
+	s.expect(s.do(http.MethodGet, "/reports/locations", nil, owner), http.StatusOK, &reports)
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 locations, got %d", len(reports))
+	}
+	for _, report := range reports {
+		switch report.RestaurantId {
+		case harbour.RestaurantId:
+			if report.OrderCount != 1 || report.ItemCount != 2 || report.Sales != money("9") {
+				t.Fatalf("unexpected harbour totals %+v", report)
+			}
+		case market.RestaurantId:
+			if report.OrderCount != 0 || report.Sales != 0 {
+				t.Fatalf("unexpected market totals %+v", report)
+			}
+		}
+	}
+
+	s.expect(s.do(http.MethodGet, "/reports/locations?from=2000-01-01T00:00:00Z&to=2000-01-02T00:00:00Z", nil, owner), http.StatusOK, &reports)
+	for _, report := range reports {
+		if report.OrderCount != 0 {
+			t.Fatalf("expected nothing in 2000, got %+v", report)
+		}
+	}
+}
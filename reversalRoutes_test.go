@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/helpers"
+	"restaurant-management-system/models"
+)
+
+func TestVoidingOrderItems(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, waiter := s.staff(models.RoleWaiter)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak, salad := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Salad", "6")
+	order, orderItems := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup, steak, salad)
+	void := func(orderItem models.OrderItem, body map[string]string, requestHeaders headers, status int) models.Reversal {
+		t.Helper()
+
+		var reversal models.Reversal
+		s.expect(s.do(http.MethodPost, "/orderItems/"+orderItem.OrderItemId+"/void", body, requestHeaders), status, &reversal)
+		return reversal
+	}
+
+	void(orderItems[0], map[string]string{}, waiter, http.StatusBadRequest)
+	void(orderItems[0], map[string]string{"reason_code": "BORED"}, waiter, http.StatusBadRequest)
+	void(orderItems[0], map[string]string{"reason_code": "OTHER"}, waiter, http.StatusBadRequest)
+
+	pending := void(orderItems[0], map[string]string{"reason_code": "OTHER", "note": "guest left before it came out"}, waiter, http.StatusAccepted)
+	if pending.Status != models.ReversalPending || pending.Amount != money("4.5") || pending.RequestedBy == "" {
+		t.Fatalf("expected the void to wait for a manager, got %+v", pending)
+	}
+	var stillQueued models.OrderItem
+	s.expect(s.do(http.MethodGet, "/orderItems/"+orderItems[0].OrderItemId, nil, waiter), http.StatusOK, &stillQueued)
+	if stillQueued.CurrentStatus() != models.OrderItemQueued {
+		t.Fatalf("expected the item to stay queued until approved, got %s", stillQueued.Status)
+	}
+
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/approve", nil, waiter), http.StatusForbidden, nil)
+	var approved models.Reversal
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/approve", nil, manager), http.StatusOK, &approved)
+	if approved.Status != models.ReversalCompleted || approved.DecidedBy == "" {
+		t.Fatalf("expected the void to be approved, got %+v", approved)
+	}
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/approve", nil, manager), http.StatusConflict, nil)
+
+	for range 3 {
+		s.expect(s.do(http.MethodPost, "/orderItems/"+orderItems[1].OrderItemId+"/bump", nil, manager), http.StatusOK, nil)
+	}
+	voided := void(orderItems[1], map[string]string{"reason_code": "QUALITY"}, manager, http.StatusCreated)
+	if voided.Status != models.ReversalCompleted || voided.Amount != money("20") {
+		t.Fatalf("expected a manager to void the served steak at once, got %+v", voided)
+	}
+	void(orderItems[1], map[string]string{"reason_code": "QUALITY"}, manager, http.StatusConflict)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, waiter), http.StatusCreated, &invoice)
+	if invoice.AmountDue() != money("6") || len(invoice.Totals.Lines) != 1 {
+		t.Fatalf("expected only the salad to be billed, got %+v", invoice.Totals)
+	}
+	void(orderItems[2], map[string]string{"reason_code": "WRONG_ITEM"}, manager, http.StatusConflict)
+}
+
+func TestRefundsAboveTheLimitNeedAManager(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	restaurant.ReversalApprovalLimit = money("10")
+	if err := s.store.Restaurants.Update(context.Background(), restaurant); err != nil {
+		t.Fatal(err)
+	}
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	refund := func(body map[string]string, requestHeaders headers, status int) models.Reversal {
+		t.Helper()
+
+		var reversal models.Reversal
+		s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/refunds", body, requestHeaders), status, &reversal)
+		return reversal
+	}
+
+	refund(map[string]string{"reason_code": "OVERCHARGE"}, cashier, http.StatusConflict)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "10"}, cashier), http.StatusCreated, nil)
+	var paid models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/pay", map[string]string{"amount": "14.50", "card_token": "tok_visa"}, headers{"token": cashier["token"], "Idempotency-Key": "a"}), http.StatusCreated, &paid)
+	cash, card := paid.Payments[0], paid.Payments[1]
+
+	refund(map[string]string{"reason_code": "OVERCHARGE"}, cashier, http.StatusBadRequest)
+	refund(map[string]string{"reason_code": "OVERCHARGE", "payment_id": "unknown"}, cashier, http.StatusNotFound)
+
+	partial := refund(map[string]string{"reason_code": "OVERCHARGE", "payment_id": cash.PaymentId, "amount": "5"}, cashier, http.StatusCreated)
+	if partial.Status != models.ReversalCompleted || partial.Amount != money("5") || partial.Method != models.PaymentCash {
+		t.Fatalf("expected a cash refund within the limit to go through, got %+v", partial)
+	}
+	refund(map[string]string{"reason_code": "OVERCHARGE", "payment_id": cash.PaymentId, "amount": "6"}, cashier, http.StatusBadRequest)
+
+	pending := refund(map[string]string{"reason_code": "CUSTOMER_COMPLAINT", "payment_id": card.PaymentId}, cashier, http.StatusAccepted)
+	if pending.Status != models.ReversalPending || pending.Amount != money("14.50") {
+		t.Fatalf("expected the full card refund to wait for a manager, got %+v", pending)
+	}
+	refund(map[string]string{"reason_code": "CUSTOMER_COMPLAINT", "payment_id": card.PaymentId, "amount": "1"}, cashier, http.StatusConflict)
+
+	var waiting []models.Reversal
+	s.expect(s.do(http.MethodGet, "/reversals?status="+models.ReversalPending, nil, manager), http.StatusOK, &waiting)
+	if len(waiting) != 1 || waiting[0].ReversalId != pending.ReversalId {
+		t.Fatalf("expected the card refund to wait for approval, got %+v", waiting)
+	}
+
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/reject", nil, cashier), http.StatusForbidden, nil)
+	var approved models.Reversal
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/approve", nil, manager), http.StatusOK, &approved)
+	if approved.Status != models.ReversalCompleted || approved.TransactionId != card.TransactionId {
+		t.Fatalf("expected the card refund to be approved, got %+v", approved)
+	}
+	if _, err := s.gateway.Refund(context.Background(), card.TransactionId, money("0.01")); !errors.Is(err, helpers.ErrAmountExceedsCapture) {
+		t.Fatalf("expected the card to have been refunded in full through the gateway, got %v", err)
+	}
+
+	var view controllers.InvoiceViewFormat
+	s.expect(s.do(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, cashier), http.StatusOK, &view)
+	if len(view.Reversals) != 2 || view.AmountRefunded != money("19.50") || view.AmountPaid != money("24.50") || *view.PaymentStatus != models.InvoicePaid {
+		t.Fatalf("expected the refunds next to the untouched payments, got %+v", view)
+	}
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]string{"reason_code": "DUPLICATE"}, manager), http.StatusConflict, nil)
+	refund(map[string]string{"reason_code": "OVERCHARGE", "payment_id": cash.PaymentId}, manager, http.StatusCreated)
+
+	var voided models.Reversal
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]string{"reason_code": "DUPLICATE"}, manager), http.StatusCreated, &voided)
+	if voided.Type != models.ReversalVoidInvoice || voided.Amount != money("24.50") {
+		t.Fatalf("expected the invoice to be voided, got %+v", voided)
+	}
+	s.expect(s.do(http.MethodGet, "/invoice/"+invoice.InvoiceId, nil, cashier), http.StatusOK, &view)
+	if *view.PaymentStatus != models.InvoiceVoided {
+		t.Fatalf("expected the invoice to be VOIDED, got %s", *view.PaymentStatus)
+	}
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "1"}, cashier), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]string{"reason_code": "DUPLICATE"}, manager), http.StatusConflict, nil)
+}
+
+func TestRejectedReversalsChangeNothing(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	soup := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Soup", "4.5")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, soup)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	var pending models.Reversal
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/void", map[string]string{"reason_code": "WALKOUT"}, cashier), http.StatusAccepted, &pending)
+
+	var rejected models.Reversal
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/reject", nil, manager), http.StatusOK, &rejected)
+	if rejected.Status != models.ReversalRejected {
+		t.Fatalf("expected the void to be rejected, got %+v", rejected)
+	}
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/approve", nil, manager), http.StatusConflict, nil)
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "4.50"}, cashier), http.StatusCreated, nil)
+}
+
+func TestReportsShowVoidsAndRefunds(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	menu := s.seedMenu(restaurant.RestaurantId)
+	soup, steak := s.seedFood(restaurant.RestaurantId, menu.MenuId, "Soup", "4.5"), s.seedFood(restaurant.RestaurantId, menu.MenuId, "Steak", "20")
+	table := s.seedTable(restaurant.RestaurantId, 1)
+	dinner, dinnerItems := s.seedOrder(restaurant.RestaurantId, table.TableId, soup, soup, steak)
+	lunch, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, soup)
+
+	s.expect(s.do(http.MethodPost, "/orderItems/"+dinnerItems[0].OrderItemId+"/void", map[string]string{"reason_code": "WRONG_ITEM"}, manager), http.StatusCreated, nil)
+	var dinnerInvoice, lunchInvoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": dinner.OrderId}, manager), http.StatusCreated, &dinnerInvoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+dinnerInvoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "24.50"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/invoice/"+dinnerInvoice.InvoiceId+"/refunds", map[string]string{"reason_code": "QUALITY", "amount": "4.50"}, manager), http.StatusCreated, nil)
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": lunch.OrderId}, manager), http.StatusCreated, &lunchInvoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+lunchInvoice.InvoiceId+"/void", map[string]string{"reason_code": "WALKOUT"}, manager), http.StatusCreated, nil)
+
+	var reports []controllers.LocationReport
+	s.expect(s.do(http.MethodGet, "/reports/locations", nil, s.headersFor("", models.RoleOwner)), http.StatusOK, &reports)
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 location, got %d", len(reports))
+	}
+	report := reports[0]
+	if report.ItemCount != 3 || report.Sales != money("29") || report.VoidedItems != 1 || report.ItemVoids != money("4.5") {
+		t.Fatalf("expected the voided soup left out of the sales, got %+v", report)
+	}
+	if report.PaidInvoices != 1 || report.PendingInvoices != 0 || report.VoidedInvoices != 1 || report.InvoiceVoids != money("4.5") {
+		t.Fatalf("expected the voided invoice counted apart, got %+v", report)
+	}
+	if report.Refunds != money("4.5") || report.NetSales != money("20") {
+		t.Fatalf("expected the refund taken off the net sales, got %+v", report)
+	}
+}
+
+func TestRefundsMadeAtOnceStayWithinThePayment(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	cashier := s.headersFor(restaurant.RestaurantId, models.RoleCashier)
+	restaurant.ReversalApprovalLimit = money("5")
+	if err := s.store.Restaurants.Update(context.Background(), restaurant); err != nil {
+		t.Fatal(err)
+	}
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "20"}, cashier), http.StatusCreated, nil)
+	path := "/invoice/" + invoice.InvoiceId + "/refunds"
+
+	responses := make([]*httptest.ResponseRecorder, 10)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, path, map[string]string{"reason_code": "OVERCHARGE", "amount": "5"}, manager)
+		}(i)
+	}
+	wg.Wait()
+
+	refunded := 0
+	for _, response := range responses {
+		if response.Code == http.StatusCreated {
+			refunded++
+		} else if response.Code != http.StatusConflict && response.Code != http.StatusBadRequest {
+			t.Fatalf("expected refunds past the payment to be refused, got %d: %s", response.Code, response.Body)
+		}
+	}
+	if refunded != 4 {
+		t.Fatalf("expected 4 refunds of 5 out of a payment of 20, got %d", refunded)
+	}
+
+	s.expect(s.do(http.MethodPost, path, map[string]string{"reason_code": "OVERCHARGE", "amount": "1"}, manager), http.StatusConflict, nil)
+}
+
+func TestRejectedRefundsCanBeAskedForAgain(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, cashier := s.staff(models.RoleCashier)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	order, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 1).TableId, steak)
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": order.OrderId}, cashier), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "20"}, cashier), http.StatusCreated, nil)
+	path := "/invoice/" + invoice.InvoiceId + "/refunds"
+
+	var pending models.Reversal
+	s.expect(s.do(http.MethodPost, path, map[string]string{"reason_code": "OVERCHARGE"}, cashier), http.StatusAccepted, &pending)
+	s.expect(s.do(http.MethodPost, path, map[string]string{"reason_code": "OVERCHARGE", "amount": "1"}, cashier), http.StatusConflict, nil)
+	s.expect(s.do(http.MethodPost, "/reversals/"+pending.ReversalId+"/reject", nil, manager), http.StatusOK, nil)
+
+	var refund models.Reversal
+	s.expect(s.do(http.MethodPost, path, map[string]string{"reason_code": "OVERCHARGE"}, manager), http.StatusCreated, &refund)
+	if refund.Amount != money("20") {
+		t.Fatalf("expected the whole payment to be refundable once the refund was rejected, got %+v", refund)
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func CreditNoteRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/credit-notes", middleware.AuthorizeScope(models.ScopeInvoicesRead, models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.GetCreditNotes())
+	incomingRoutes.GET("/credit-notes/:credit_note_id", middleware.AuthorizeScope(models.ScopeInvoicesRead, models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.GetCreditNote())
+	incomingRoutes.POST("/invoice/:invoice_id/credit-notes", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateCreditNote())
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DeviceRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/devices", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetDevices())
+	incomingRoutes.POST("/devices", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateDevice())
+	incomingRoutes.POST("/devices/:device_id/rotate", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.RotateDeviceKey())
+	incomingRoutes.POST("/devices/:device_id/revoke", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.RevokeDevice())
+}
@@ -3,11 +3,13 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 )
 
-func FoodRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/foods", controller.GetFoods())
-	incomingRoutes.GET("/foods/:food_id", controller.GetFood())
-	incomingRoutes.POST("/foods", controller.CreateFood())
-	incomingRoutes.PATCH("/foods/:food_id", controller.UpdateFood())
+func FoodRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/foods", middleware.Authorize(models.StaffRoles...), ctl.GetFoods())
+	incomingRoutes.GET("/foods/:food_id", middleware.Authorize(models.StaffRoles...), ctl.GetFood())
+	incomingRoutes.POST("/foods", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateFood())
+	incomingRoutes.PATCH("/foods/:food_id", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.UpdateFood())
 }
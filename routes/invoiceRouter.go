@@ -3,11 +3,19 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 )
 
-func InvoiceRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/invoice", controller.GetInvoices())
-	incomingRoutes.GET("/invoice/:invoice_id", controller.GetInvoice())
-	incomingRoutes.POST("/invoice", controller.CreateInvoice())
-	incomingRoutes.PATCH("/invoice/:invoice_id", controller.UpdateInvoice())
+func InvoiceRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/invoice", middleware.AuthorizeScope(models.ScopeInvoicesRead, models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.GetInvoices())
+	incomingRoutes.GET("/invoice/:invoice_id", middleware.AuthorizeScope(models.ScopeInvoicesRead, models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.GetInvoice())
+	incomingRoutes.GET("/invoice/:invoice_id/receipt", middleware.AuthorizeScope(models.ScopeInvoicesRead, models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.GetInvoiceReceipt())
+	incomingRoutes.POST("/invoice", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.CreateInvoice())
+	incomingRoutes.PATCH("/invoice/:invoice_id", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.UpdateInvoice())
+	incomingRoutes.POST("/invoice/:invoice_id/payments", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.AddInvoicePayment())
+	incomingRoutes.POST("/invoice/:invoice_id/pay", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.PayInvoice())
+	incomingRoutes.PUT("/invoice/:invoice_id/payments/:payment_id/tip", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.SetPaymentTip())
+	incomingRoutes.POST("/invoice/:invoice_id/refunds", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.RefundInvoice())
+	incomingRoutes.POST("/invoice/:invoice_id/void", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier), ctl.VoidInvoice())
 }
@@ -3,11 +3,13 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 )
 
-func MenuRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/menus", controller.GetMenus())
-	incomingRoutes.GET("/menus/:menu_id", controller.GetMenu())
-	incomingRoutes.POST("/menus", controller.CreateMenu())
-	incomingRoutes.PATCH("/menus/:menu_id", controller.UpdateMenu())
+func MenuRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/menus", middleware.Authorize(models.StaffRoles...), ctl.GetMenus())
+	incomingRoutes.GET("/menus/:menu_id", middleware.Authorize(models.StaffRoles...), ctl.GetMenu())
+	incomingRoutes.POST("/menus", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateMenu())
+	incomingRoutes.PATCH("/menus/:menu_id", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.UpdateMenu())
 }
@@ -2,14 +2,23 @@ package routes
 
 import (
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderItemRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/orderItems", controller.GetOrderItems())
-	incomingRoutes.GET("/orderItems/:order_item_id", controller.GetOrderItem())
-	incomingRoutes.GET("/orderItems-order/:order_id", controller.GetOrderItemsByOrder())
-	incomingRoutes.POST("/orderItems", controller.CreateOrderItem())
-	incomingRoutes.PATCH("/orderItems/:order_item_id", controller.UpdateOrderItem())
+func OrderItemRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/orderItems", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrderItems())
+	incomingRoutes.GET("/orderItems/:order_item_id", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrderItem())
+	incomingRoutes.GET("/orderItems-order/:order_id", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrderItemsByOrder())
+	incomingRoutes.POST("/orderItems", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter), ctl.CreateOrderItem())
+	incomingRoutes.PATCH("/orderItems/:order_item_id", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter), ctl.UpdateOrderItem())
+	incomingRoutes.POST("/orderItems/:order_item_id/status", middleware.AuthorizeScope(models.ScopeOrderItemsUpdateStatus, models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCook), ctl.SetOrderItemStatus())
+	incomingRoutes.POST("/orderItems/:order_item_id/bump", middleware.AuthorizeScope(models.ScopeOrderItemsUpdateStatus, models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCook), ctl.BumpOrderItem())
+	incomingRoutes.POST("/orderItems/:order_item_id/void", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), ctl.VoidOrderItem())
+	incomingRoutes.PUT("/orderItems/:order_item_id/discount", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.SetOrderItemDiscount())
+	incomingRoutes.DELETE("/orderItems/:order_item_id/discount", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.RemoveOrderItemDiscount())
+	incomingRoutes.GET("/orderItems-station/:station", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetStationQueue())
+	incomingRoutes.GET("/kitchen/feed", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetKitchenFeed())
 }
@@ -2,13 +2,19 @@ package routes
 
 import (
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func OrderRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/orders", controller.GetOrders())
-	incomingRoutes.GET("/orders/:order_id", controller.GetOrder())
-	incomingRoutes.POST("/orders", controller.CreateOrder())
-	incomingRoutes.PATCH("/orders/:order_id", controller.UpdateOrder())
+func OrderRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/orders", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrders())
+	incomingRoutes.GET("/orders/:order_id", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrder())
+	incomingRoutes.POST("/orders", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter), ctl.CreateOrder())
+	incomingRoutes.PATCH("/orders/:order_id", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter), ctl.UpdateOrder())
+	incomingRoutes.POST("/orders/:order_id/transition", middleware.Authorize(models.StaffRoles...), ctl.TransitionOrder())
+	incomingRoutes.GET("/orders/:order_id/totals", middleware.AuthorizeScope(models.ScopeOrdersRead, models.StaffRoles...), ctl.GetOrderTotals())
+	incomingRoutes.PUT("/orders/:order_id/discount", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.SetOrderDiscount())
+	incomingRoutes.DELETE("/orders/:order_id/discount", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.RemoveOrderDiscount())
 }
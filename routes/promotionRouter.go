@@ -0,0 +1,18 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func PromotionRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/promotions", middleware.Authorize(models.StaffRoles...), ctl.GetPromotions())
+	incomingRoutes.GET("/promotions/:promotion_id", middleware.Authorize(models.StaffRoles...), ctl.GetPromotion())
+	incomingRoutes.POST("/promotions", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreatePromotion())
+	incomingRoutes.PATCH("/promotions/:promotion_id", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.UpdatePromotion())
+	incomingRoutes.POST("/orders/:order_id/coupons", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), ctl.ApplyCoupon())
+	incomingRoutes.DELETE("/orders/:order_id/coupons/:code", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter, models.RoleCashier), ctl.RemoveCoupon())
+}
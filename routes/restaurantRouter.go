@@ -0,0 +1,17 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RestaurantRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/restaurants", middleware.Authorize(models.StaffRoles...), ctl.GetRestaurants())
+	incomingRoutes.GET("/restaurants/:restaurant_id", middleware.Authorize(models.StaffRoles...), ctl.GetRestaurant())
+	incomingRoutes.POST("/restaurants", middleware.Authorize(models.RoleOwner), ctl.CreateRestaurant())
+	incomingRoutes.PATCH("/restaurants/:restaurant_id", middleware.Authorize(models.RoleOwner), ctl.UpdateRestaurant())
+	incomingRoutes.GET("/reports/locations", middleware.Authorize(models.RoleOwner), ctl.GetLocationsReport())
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ReversalRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/reversals", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetReversals())
+	incomingRoutes.GET("/reversals/:reversal_id", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleCashier, models.RoleWaiter), ctl.GetReversal())
+	incomingRoutes.POST("/reversals/:reversal_id/approve", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.ApproveReversal())
+	incomingRoutes.POST("/reversals/:reversal_id/reject", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.RejectReversal())
+}
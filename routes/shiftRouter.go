@@ -0,0 +1,15 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ShiftRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/shifts", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetShifts())
+	incomingRoutes.POST("/shifts/close", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CloseShift())
+	incomingRoutes.GET("/shifts/:shift_id/tips", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetShiftTips())
+}
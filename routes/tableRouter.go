@@ -2,13 +2,15 @@ package routes
 
 import (
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func TableRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/tables", controller.GetTables())
-	incomingRoutes.GET("/tables/:table_id", controller.GetTable())
-	incomingRoutes.POST("/tables", controller.CreateTable())
-	incomingRoutes.PATCH("/tables/:table_id", controller.UpdateTable())
+func TableRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/tables", middleware.Authorize(models.StaffRoles...), ctl.GetTables())
+	incomingRoutes.GET("/tables/:table_id", middleware.Authorize(models.StaffRoles...), ctl.GetTable())
+	incomingRoutes.POST("/tables", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateTable())
+	incomingRoutes.PATCH("/tables/:table_id", middleware.Authorize(models.RoleAdmin, models.RoleManager, models.RoleWaiter), ctl.UpdateTable())
 }
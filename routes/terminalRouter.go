@@ -0,0 +1,14 @@
+package routes
+
+import (
+	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TerminalRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller) {
+	incomingRoutes.GET("/terminals", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetTerminals())
+	incomingRoutes.POST("/terminals", middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.CreateTerminal())
+}
@@ -3,11 +3,25 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	controller "restaurant-management-system/controllers"
+	"restaurant-management-system/middleware"
+	"restaurant-management-system/models"
 )
 
-func UserRoutes(incomingRoutes *gin.Engine) {
-	incomingRoutes.GET("/users", controller.GetUsers())
-	incomingRoutes.GET("users/:user_id", controller.GetUser())
-	incomingRoutes.POST("users/signup", controller.SignUp())
-	incomingRoutes.POST("users/signin", controller.SignIn())
+func UserRoutes(incomingRoutes *gin.Engine, ctl *controller.Controller, authenticate gin.HandlerFunc) {
+	incomingRoutes.POST("users/signup", ctl.SignUp())
+	incomingRoutes.POST("users/signin", ctl.LogIn())
+	incomingRoutes.POST("users/refresh", ctl.RefreshToken())
+	incomingRoutes.POST("users/pin-login", ctl.PinLogin())
+	incomingRoutes.POST("users/password/forgot", ctl.ForgotPassword())
+	incomingRoutes.POST("users/password/reset", ctl.ResetPassword())
+
+	authorized := incomingRoutes.Group("/", authenticate, middleware.Authorize(models.StaffRoles...))
+	authorized.GET("/users", middleware.RequireTenant(), middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.GetUsers())
+	authorized.GET("users/:user_id", ctl.GetUser())
+	authorized.PATCH("users/:user_id/role", middleware.RequireTenant(), middleware.Authorize(models.RoleAdmin), ctl.UpdateUserRole())
+	authorized.POST("users/logout", ctl.Logout())
+	authorized.POST("users/password/change", ctl.ChangePassword())
+	authorized.PUT("users/:user_id/pin", ctl.SetPin())
+	authorized.POST("users/:user_id/sessions/revoke-all", ctl.RevokeAllSessions())
+	authorized.POST("users/:user_id/unlock", middleware.RequireTenant(), middleware.Authorize(models.RoleAdmin, models.RoleManager), ctl.UnlockUser())
 }
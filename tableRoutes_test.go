@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestTableLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+
+	var created models.Table
+	s.expect(s.do(http.MethodPost, "/tables", map[string]int{"number_of_guests": 2, "table_number": 7}, manager), http.StatusCreated, &created)
+
+	var tables []models.Table
+	s.expect(s.do(http.MethodGet, "/tables", nil, manager), http.StatusOK, &tables)
+	if len(tables) != 1 || *tables[0].TableNumber != 7 {
+		t.Fatalf("expected table 7, got %v", tables)
+	}
+
+	s.expect(s.do(http.MethodGet, "/tables/"+created.TableId, nil, manager), http.StatusOK, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	var updated models.Table
+	s.expect(s.do(http.MethodPatch, "/tables/"+created.TableId, map[string]int{"number_of_guests": 6}, waiter), http.StatusOK, &updated)
+	if *updated.NumberOfGuests != 6 || *updated.TableNumber != 7 {
+		t.Fatalf("expected 6 guests at table 7, got %d at %d", *updated.NumberOfGuests, *updated.TableNumber)
+	}
+}
+
+func TestCreateTableValidation(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+
+	s.expect(s.do(http.MethodPost, "/tables", map[string]int{"table_number": 7}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/tables", map[string]string{"table_number": "seven"}, manager), http.StatusBadRequest, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodPost, "/tables", map[string]int{"number_of_guests": 2, "table_number": 7}, waiter), http.StatusForbidden, nil)
+}
+
+func TestTablesAreScopedToTheRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	_, manager := s.staff(models.RoleManager)
+	table := s.seedTable(s.seedRestaurant("Elsewhere").RestaurantId, 1)
+
+	s.expect(s.do(http.MethodGet, "/tables/"+table.TableId, nil, manager), http.StatusNotFound, nil)
+	s.expect(s.do(http.MethodPatch, "/tables/"+table.TableId, map[string]int{"number_of_guests": 1}, manager), http.StatusNotFound, nil)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestTerminals(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	s.headersFor(s.seedRestaurant("Elsewhere").RestaurantId, models.RoleManager)
+
+	var created struct {
+		Terminal    models.Terminal `json:"terminal"`
+		TerminalKey string          `json:"terminal_key"`
+	}
+	s.expect(s.do(http.MethodPost, "/terminals", map[string]string{"name": "Front till"}, manager), http.StatusCreated, &created)
+	if created.TerminalKey == "" || created.Terminal.RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected a key for a terminal of %s, got %+v", restaurant.RestaurantId, created)
+	}
+
+	var terminals []models.Terminal
+	s.expect(s.do(http.MethodGet, "/terminals", nil, manager), http.StatusOK, &terminals)
+	if len(terminals) != 1 {
+		t.Fatalf("expected 1 terminal, got %d", len(terminals))
+	}
+
+	s.expect(s.do(http.MethodPost, "/terminals", map[string]string{"name": "T"}, manager), http.StatusBadRequest, nil)
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodGet, "/terminals", nil, waiter), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/terminals", map[string]string{"name": "Front till"}, waiter), http.StatusForbidden, nil)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"restaurant-management-system/controllers"
+	"restaurant-management-system/models"
+)
+
+func TestAutomaticGratuityForLargeParties(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	restaurant.Pricing = &models.PricingSettings{GratuityRate: 18, GratuityMinGuests: 6}
+	if err := s.store.Restaurants.Update(context.Background(), restaurant); err != nil {
+		t.Fatal(err)
+	}
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+
+	party := s.seedTable(restaurant.RestaurantId, 1)
+	guests := 8
+	party.NumberOfGuests = &guests
+	if err := s.store.Tables.Update(context.Background(), party); err != nil {
+		t.Fatal(err)
+	}
+	large, _ := s.seedOrder(restaurant.RestaurantId, party.TableId, steak)
+	small, _ := s.seedOrder(restaurant.RestaurantId, s.seedTable(restaurant.RestaurantId, 2).TableId, steak)
+
+	var totals models.OrderTotals
+	s.expect(s.do(http.MethodGet, "/orders/"+large.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.GratuityRate != 18 || totals.Gratuity != money("3.60") || totals.Total != money("23.60") {
+		t.Fatalf("expected 18%% gratuity for a party of 8, got %+v", totals)
+	}
+	s.expect(s.do(http.MethodGet, "/orders/"+small.OrderId+"/totals", nil, manager), http.StatusOK, &totals)
+	if totals.Gratuity != 0 || totals.Total != money("20") {
+		t.Fatalf("expected no gratuity for a party of 4, got %+v", totals)
+	}
+
+	var invoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": large.OrderId}, manager), http.StatusCreated, &invoice)
+	s.expect(s.do(http.MethodPost, "/invoice/"+invoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "23.60"}, manager), http.StatusCreated, nil)
+
+	var report controllers.ShiftTips
+	s.expect(s.do(http.MethodGet, "/shifts/current/tips", nil, manager), http.StatusOK, &report)
+	if len(report.Servers) != 1 || report.Servers[0].Gratuity != money("3.60") || report.Tips != money("3.60") {
+		t.Fatalf("expected the gratuity among the tips, got %+v", report)
+	}
+}
+
+func TestTipsGoToTheServerUntilTheShiftCloses(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	restaurant.TipOutRates = map[string]float64{models.RoleCook: 10, models.RoleCashier: 5}
+	if err := s.store.Restaurants.Update(context.Background(), restaurant); err != nil {
+		t.Fatal(err)
+	}
+	alice, aliceToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	bob, bobToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	steak := s.seedFood(restaurant.RestaurantId, s.seedMenu(restaurant.RestaurantId).MenuId, "Steak", "20")
+	table := s.seedTable(restaurant.RestaurantId, 1)
+
+	var taken models.Order
+	s.expect(s.do(http.MethodPost, "/orders", map[string]string{"table_id": table.TableId, "order_date": "2026-01-01T00:00:00Z"}, headers{"token": aliceToken}), http.StatusCreated, &taken)
+	if taken.ServerId != alice.UserId {
+		t.Fatalf("expected the order to belong to the waiter who took it, got %q", taken.ServerId)
+	}
+
+	first, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+	second, _ := s.seedOrder(restaurant.RestaurantId, table.TableId, steak)
+	s.expect(s.do(http.MethodPatch, "/orders/"+first.OrderId, map[string]string{"server_id": alice.UserId}, headers{"token": bobToken}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+first.OrderId, map[string]string{"server_id": "unknown"}, manager), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+first.OrderId, map[string]string{"server_id": alice.UserId}, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPatch, "/orders/"+second.OrderId, map[string]string{"server_id": bob.UserId}, manager), http.StatusOK, nil)
+
+	var cardInvoice, cashInvoice models.Invoice
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": first.OrderId}, manager), http.StatusCreated, &cardInvoice)
+	s.expect(s.do(http.MethodPost, "/invoice", map[string]string{"order_id": second.OrderId}, manager), http.StatusCreated, &cashInvoice)
+
+	s.expect(s.do(http.MethodPost, "/invoice/"+cardInvoice.InvoiceId+"/pay", map[string]string{"amount": "20", "tip": "3", "card_token": "tok_visa"}, headers{"token": manager["token"], "Idempotency-Key": "a"}), http.StatusCreated, &cardInvoice)
+	card := cardInvoice.Payments[0]
+	if card.Tip != money("3") || *cardInvoice.PaymentStatus != models.InvoicePaid || s.gateway.Captured(card.TransactionId) != money("23") {
+		t.Fatalf("expected the tip charged on top of the bill, got %+v", cardInvoice)
+	}
+	s.expect(s.do(http.MethodPost, "/invoice/"+cashInvoice.InvoiceId+"/payments", map[string]string{"method": "CASH", "amount": "20", "tip": "2"}, manager), http.StatusCreated, &cashInvoice)
+
+	tipPath := "/invoice/" + cardInvoice.InvoiceId + "/payments/" + card.PaymentId + "/tip"
+	s.expect(s.do(http.MethodPut, tipPath, map[string]string{}, headers{"token": aliceToken}), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPut, "/invoice/"+cardInvoice.InvoiceId+"/payments/unknown/tip", map[string]string{"tip": "4"}, headers{"token": aliceToken}), http.StatusNotFound, nil)
+	var adjusted models.Invoice
+	s.expect(s.do(http.MethodPut, tipPath, map[string]string{"tip": "4"}, headers{"token": aliceToken}), http.StatusOK, &adjusted)
+	if adjusted.Payments[0].Tip != money("4") || s.gateway.Captured(card.TransactionId) != money("24") {
+		t.Fatalf("expected the card tip to be adjusted to 4, got %+v", adjusted.Payments[0])
+	}
+
+	var report controllers.ShiftTips
+	s.expect(s.do(http.MethodGet, "/shifts/current/tips", nil, manager), http.StatusOK, &report)
+	tipsOf := func(report controllers.ShiftTips, serverId string) controllers.ServerTips {
+		for _, server := range report.Servers {
+			if server.ServerId == serverId {
+				return server
+			}
+		}
+		return controllers.ServerTips{}
+	}
+	if aliceTips := tipsOf(report, alice.UserId); aliceTips.CardTips != money("4") || aliceTips.TipOut != money("0.60") || aliceTips.Net != money("3.40") {
+		t.Fatalf("unexpected tips for the first waiter %+v", aliceTips)
+	}
+	if bobTips := tipsOf(report, bob.UserId); bobTips.CashTips != money("2") || bobTips.TipOut != money("0.30") || bobTips.Net != money("1.70") {
+		t.Fatalf("unexpected tips for the second waiter %+v", bobTips)
+	}
+	if report.Tips != money("6") || len(report.Pools) != 2 || report.Pools[0].Role != models.RoleCashier || report.Pools[0].Amount != money("0.30") || report.Pools[1].Amount != money("0.60") {
+		t.Fatalf("unexpected tip pools %+v", report)
+	}
+
+	s.expect(s.do(http.MethodPost, "/shifts/close", nil, headers{"token": aliceToken}), http.StatusForbidden, nil)
+	var shift models.Shift
+	s.expect(s.do(http.MethodPost, "/shifts/close", nil, manager), http.StatusCreated, &shift)
+	s.expect(s.do(http.MethodPut, tipPath, map[string]string{"tip": "5"}, headers{"token": aliceToken}), http.StatusConflict, nil)
+
+	var closed controllers.ShiftTips
+	s.expect(s.do(http.MethodGet, "/shifts/"+shift.ShiftId+"/tips", nil, manager), http.StatusOK, &closed)
+	if closed.Tips != money("6") || closed.TipOut != money("0.90") {
+		t.Fatalf("expected the closed shift to keep its tips, got %+v", closed)
+	}
+	s.expect(s.do(http.MethodGet, "/shifts/current/tips", nil, manager), http.StatusOK, &report)
+	if len(report.Servers) != 0 || !report.Shift.StartedAt.Equal(shift.EndedAt) {
+		t.Fatalf("expected the next shift to start empty, got %+v", report)
+	}
+
+	var shifts []models.Shift
+	s.expect(s.do(http.MethodGet, "/shifts", nil, manager), http.StatusOK, &shifts)
+	if len(shifts) != 1 || shifts[0].ShiftId != shift.ShiftId {
+		t.Fatalf("expected the closed shift to be listed, got %+v", shifts)
+	}
+	s.expect(s.do(http.MethodGet, "/shifts/unknown/tips", nil, manager), http.StatusNotFound, nil)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"restaurant-management-system/models"
+)
+
+func TestSignUpMakesTheFirstUserOwner(t *testing.T) {
+	s := newTestServer(t)
+	signUp := map[string]interface{}{"first_name": "Olive", "last_name": "Owner", "email": "olive@example.com", "password": testPassword, "phone": "555-0100"}
+
+	var owner models.User
+	s.expect(s.do(http.MethodPost, "/users/signup", signUp, nil), http.StatusCreated, &owner)
+	if *owner.Role != models.RoleOwner || owner.RestaurantId != "" {
+		t.Fatalf("expected an owner without a restaurant, got %s in %q", *owner.Role, owner.RestaurantId)
+	}
+
+	s.expect(s.do(http.MethodPost, "/users/signup", signUp, nil), http.StatusConflict, nil)
+}
+
+func TestSignUpJoinsAnExistingRestaurantAsWaiter(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	s.seedUser("", models.RoleOwner)
+	signUp := map[string]interface{}{"first_name": "Walt", "last_name": "Waiter", "email": "walt@example.com", "password": testPassword, "phone": "555-0101"}
+
+	s.expect(s.do(http.MethodPost, "/users/signup", signUp, nil), http.StatusBadRequest, nil)
+
+	signUp["restaurant_id"] = restaurant.RestaurantId
+	signUp["role"] = models.RoleAdmin
+	var waiter models.User
+	s.expect(s.do(http.MethodPost, "/users/signup", signUp, nil), http.StatusCreated, &waiter)
+	if *waiter.Role != models.RoleWaiter || waiter.RestaurantId != restaurant.RestaurantId {
+		t.Fatalf("expected a waiter of %s, got %s of %s", restaurant.RestaurantId, *waiter.Role, waiter.RestaurantId)
+	}
+}
+
+func TestSignUpValidatesThePayload(t *testing.T) {
+	s := newTestServer(t)
+
+	s.expect(s.do(http.MethodPost, "/users/signup", "{", nil), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/users/signup", map[string]interface{}{"email": "not-an-email"}, nil), http.StatusBadRequest, nil)
+}
+
+func TestSignInIssuesTokensAndLocksOutAfterFailures(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, _ := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+
+	var signedIn models.User
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil), http.StatusOK, &signedIn)
+	s.expect(s.do(http.MethodGet, "/foods", nil, headers{"token": *signedIn.Token}), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": "nobody@example.com", "password": testPassword}, nil), http.StatusUnauthorized, nil)
+	for i := 0; i < 5; i++ {
+		s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "wrong-password"}, nil), http.StatusUnauthorized, nil)
+	}
+
+	response := s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil)
+	s.expect(response, http.StatusTooManyRequests, nil)
+	if response.Header().Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+
+	admin := s.headersFor(restaurant.RestaurantId, models.RoleAdmin)
+	s.expect(s.do(http.MethodPost, "/users/"+user.UserId+"/unlock", nil, admin), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil), http.StatusOK, nil)
+}
+
+func TestUnlockingAnAccountLiftsTheLockOnItsIP(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, _ := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+
+	for i := 0; i < 5; i++ {
+		s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "wrong-password"}, nil), http.StatusUnauthorized, nil)
+	}
+	for i := 0; i < 15; i++ {
+		s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": fmt.Sprintf("nobody%d@example.com", i), "password": "wrong-password"}, nil), http.StatusUnauthorized, nil)
+	}
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil), http.StatusTooManyRequests, nil)
+
+	admin := s.headersFor(restaurant.RestaurantId, models.RoleAdmin)
+	s.expect(s.do(http.MethodPost, "/users/"+user.UserId+"/unlock", nil, admin), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil), http.StatusOK, nil)
+}
+
+func TestSignInFailuresAtOnceAreAllCounted(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, _ := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+
+	responses := make([]*httptest.ResponseRecorder, 4)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "wrong-password"}, nil)
+		}(i)
+	}
+	wg.Wait()
+	for _, response := range responses {
+		s.expect(response, http.StatusUnauthorized, nil)
+	}
+
+	attempt, err := s.store.LoginAttempts.Get(t.Context(), "email:"+strings.ToLower(*user.Email))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempt.Failures != len(responses) {
+		t.Fatalf("expected %d failures, got %d", len(responses), attempt.Failures)
+	}
+
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "wrong-password"}, nil), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": testPassword}, nil), http.StatusTooManyRequests, nil)
+}
+
+func TestRefreshRotatesTokensAndDetectsReuse(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, _ := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+
+	var rotated map[string]string
+	s.expect(s.do(http.MethodPost, "/users/refresh", map[string]string{"refresh_token": *user.RefreshToken}, nil), http.StatusOK, &rotated)
+	if rotated["refresh_token"] == "" || rotated["refresh_token"] == *user.RefreshToken {
+		t.Fatal("expected a new refresh token")
+	}
+
+	s.expect(s.do(http.MethodPost, "/users/refresh", map[string]string{"refresh_token": *user.RefreshToken}, nil), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/refresh", map[string]string{"refresh_token": rotated["refresh_token"]}, nil), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/refresh", map[string]string{"refresh_token": rotated["token"]}, nil), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/refresh", map[string]string{}, nil), http.StatusBadRequest, nil)
+}
+
+func TestLogoutRevokesTheToken(t *testing.T) {
+	s := newTestServer(t)
+	_, waiter := s.staff(models.RoleWaiter)
+
+	s.expect(s.do(http.MethodPost, "/users/logout", nil, waiter), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/foods", nil, waiter), http.StatusUnauthorized, nil)
+}
+
+func TestGetUsersIsScopedAndRestrictedToManagers(t *testing.T) {
+	s := newTestServer(t)
+	restaurant, manager := s.staff(models.RoleManager)
+	s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	other := s.seedRestaurant("Elsewhere")
+	s.seedUser(other.RestaurantId, models.RoleWaiter)
+
+	var page struct {
+		TotalCount int           `json:"total_count"`
+		UserItems  []models.User `json:"user_items"`
+	}
+	s.expect(s.do(http.MethodGet, "/users?recordsPerPage=1", nil, manager), http.StatusOK, &page)
+	if page.TotalCount != 2 || len(page.UserItems) != 1 {
+		t.Fatalf("expected 1 of 2 users, got %d of %d", len(page.UserItems), page.TotalCount)
+	}
+
+	waiter := s.headersFor(restaurant.RestaurantId, models.RoleWaiter)
+	s.expect(s.do(http.MethodGet, "/users", nil, waiter), http.StatusForbidden, nil)
+}
+
+func TestGetUserAllowsSelfAndManagersOfTheSameRestaurant(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	waiter, waiterToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	colleague, _ := s.seedUser(restaurant.RestaurantId, models.RoleCook)
+	stranger, _ := s.seedUser(s.seedRestaurant("Elsewhere").RestaurantId, models.RoleCook)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+
+	s.expect(s.do(http.MethodGet, "/users/"+waiter.UserId, nil, headers{"token": waiterToken}), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/users/"+colleague.UserId, nil, headers{"token": waiterToken}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodGet, "/users/"+stranger.UserId, nil, manager), http.StatusNotFound, nil)
+
+	// nobody is shown the password hash or the tokens of a user
+	var shown map[string]interface{}
+	s.expect(s.do(http.MethodGet, "/users/"+colleague.UserId, nil, manager), http.StatusOK, &shown)
+	for _, secret := range []string{"password", "token", "refresh_token"} {
+		if _, found := shown[secret]; found {
+			t.Fatalf("expected no %s in %v", secret, shown)
+		}
+	}
+	var page struct {
+		UserItems []map[string]interface{} `json:"user_items"`
+	}
+	s.expect(s.do(http.MethodGet, "/users", nil, manager), http.StatusOK, &page)
+	for _, listed := range page.UserItems {
+		if _, found := listed["password"]; found || listed["token"] != nil || listed["refresh_token"] != nil {
+			t.Fatalf("expected no secrets in %v", listed)
+		}
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	admin, adminToken := s.seedUser(restaurant.RestaurantId, models.RoleAdmin)
+	waiter, waiterToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	adminHeaders := headers{"token": adminToken}
+
+	var promoted models.User
+	s.expect(s.do(http.MethodPatch, "/users/"+waiter.UserId+"/role", map[string]string{"role": models.RoleCashier}, adminHeaders), http.StatusOK, &promoted)
+	if *promoted.Role != models.RoleCashier {
+		t.Fatalf("expected CASHIER, got %s", *promoted.Role)
+	}
+	// tokens issued with the old role stop being accepted
+	s.expect(s.do(http.MethodGet, "/foods", nil, headers{"token": waiterToken}), http.StatusUnauthorized, nil)
+
+	s.expect(s.do(http.MethodPatch, "/users/"+waiter.UserId+"/role", map[string]string{"role": "CHEF"}, adminHeaders), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPatch, "/users/"+waiter.UserId+"/role", map[string]string{"role": models.RoleOwner}, adminHeaders), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPatch, "/users/"+admin.UserId+"/role", map[string]string{"role": models.RoleManager}, adminHeaders), http.StatusBadRequest, nil)
+
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	s.expect(s.do(http.MethodPatch, "/users/"+waiter.UserId+"/role", map[string]string{"role": models.RoleCook}, manager), http.StatusForbidden, nil)
+}
+
+func TestChangePasswordSignsOutEverywhere(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, token := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	waiter := headers{"token": token}
+
+	s.expect(s.do(http.MethodPost, "/users/password/change", map[string]string{"current_password": "wrong-password", "new_password": "new-password"}, waiter), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/password/change", map[string]string{"current_password": testPassword, "new_password": "new"}, waiter), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/users/password/change", map[string]string{"current_password": testPassword, "new_password": "new-password"}, waiter), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodGet, "/foods", nil, waiter), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "new-password"}, nil), http.StatusOK, nil)
+}
+
+func TestForgotAndResetPassword(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	user, _ := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+
+	s.expect(s.do(http.MethodPost, "/users/password/forgot", map[string]string{"email": "nobody@example.com"}, nil), http.StatusOK, nil)
+	if _, sent := s.notifier.last(); sent {
+		t.Fatal("expected no notification for an unknown e-mail")
+	}
+
+	s.expect(s.do(http.MethodPost, "/users/password/forgot", map[string]string{"email": *user.Email}, nil), http.StatusOK, nil)
+	notification, sent := s.notifier.last()
+	if !sent || notification.To != *user.Email {
+		t.Fatal("expected the reset to be sent to the user")
+	}
+	token := notification.Body[strings.LastIndex(notification.Body, " ")+1:]
+
+	s.expect(s.do(http.MethodPost, "/users/password/reset", map[string]string{"token": "bogus", "new_password": "new-password"}, nil), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPost, "/users/password/reset", map[string]string{"token": token, "new_password": "new-password"}, nil), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPost, "/users/password/reset", map[string]string{"token": token, "new_password": "other-password"}, nil), http.StatusBadRequest, nil)
+
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *user.Email, "password": "new-password"}, nil), http.StatusOK, nil)
+}
+
+func TestPinLoginOnARegisteredTerminal(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+	first, firstToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	second, _ := s.seedUser(restaurant.RestaurantId, models.RoleCashier)
+
+	var created struct {
+		Terminal    models.Terminal `json:"terminal"`
+		TerminalKey string          `json:"terminal_key"`
+	}
+	s.expect(s.do(http.MethodPost, "/terminals", map[string]string{"name": "Bar till"}, manager), http.StatusCreated, &created)
+
+	s.expect(s.do(http.MethodPut, "/users/"+first.UserId+"/pin", map[string]string{"pin": "12ab"}, headers{"token": firstToken}), http.StatusBadRequest, nil)
+	s.expect(s.do(http.MethodPut, "/users/"+first.UserId+"/pin", map[string]string{"pin": "1234"}, headers{"token": firstToken}), http.StatusOK, nil)
+	s.expect(s.do(http.MethodPut, "/users/"+second.UserId+"/pin", map[string]string{"pin": "5678"}, headers{"token": firstToken}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPut, "/users/"+second.UserId+"/pin", map[string]string{"pin": "5678"}, manager), http.StatusOK, nil)
+
+	pinLogin := func(userId string, pin string, terminalKey string) map[string]interface{} {
+		return map[string]interface{}{"terminal_id": created.Terminal.TerminalId, "terminal_key": terminalKey, "user_id": userId, "pin": pin}
+	}
+	s.expect(s.do(http.MethodPost, "/users/pin-login", pinLogin(first.UserId, "1234", "wrong-key"), nil), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodPost, "/users/pin-login", pinLogin(first.UserId, "0000", created.TerminalKey), nil), http.StatusUnauthorized, nil)
+
+	var firstSession, secondSession map[string]interface{}
+	s.expect(s.do(http.MethodPost, "/users/pin-login", pinLogin(first.UserId, "1234", created.TerminalKey), nil), http.StatusOK, &firstSession)
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": firstSession["token"].(string)}), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPost, "/users/pin-login", pinLogin(second.UserId, "5678", created.TerminalKey), nil), http.StatusOK, &secondSession)
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": firstSession["token"].(string)}), http.StatusUnauthorized, nil)
+	s.expect(s.do(http.MethodGet, "/tables", nil, headers{"token": secondSession["token"].(string)}), http.StatusOK, nil)
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	s := newTestServer(t)
+	restaurant := s.seedRestaurant("Harbour")
+	waiter, waiterToken := s.seedUser(restaurant.RestaurantId, models.RoleWaiter)
+	cook, cookToken := s.seedUser(restaurant.RestaurantId, models.RoleCook)
+	stranger, _ := s.seedUser(s.seedRestaurant("Elsewhere").RestaurantId, models.RoleWaiter)
+	manager := s.headersFor(restaurant.RestaurantId, models.RoleManager)
+
+	s.expect(s.do(http.MethodPost, "/users/"+cook.UserId+"/sessions/revoke-all", nil, headers{"token": waiterToken}), http.StatusForbidden, nil)
+	s.expect(s.do(http.MethodPost, "/users/"+stranger.UserId+"/sessions/revoke-all", nil, manager), http.StatusNotFound, nil)
+
+	s.expect(s.do(http.MethodPost, "/users/"+cook.UserId+"/sessions/revoke-all", nil, manager), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/foods", nil, headers{"token": cookToken}), http.StatusUnauthorized, nil)
+
+	var signedIn models.User
+	s.expect(s.do(http.MethodPost, "/users/signin", map[string]string{"email": *cook.Email, "password": testPassword}, nil), http.StatusOK, &signedIn)
+	s.expect(s.do(http.MethodGet, "/foods", nil, headers{"token": *signedIn.Token}), http.StatusOK, nil)
+
+	s.expect(s.do(http.MethodPost, "/users/"+waiter.UserId+"/sessions/revoke-all", nil, headers{"token": waiterToken}), http.StatusOK, nil)
+	s.expect(s.do(http.MethodGet, "/foods", nil, headers{"token": waiterToken}), http.StatusUnauthorized, nil)
+
+	if _, err := s.store.Users.Get(context.Background(), waiter.UserId); err != nil {
+		t.Fatal(err)
+	}
+}